@@ -0,0 +1,86 @@
+package client
+
+import (
+	"context"
+	"fmt"
+)
+
+// OverlayBinding links an overlay to the data source (database connection)
+// it is evaluated against
+type OverlayBinding struct {
+	ID           string `json:"id"`
+	OverlayID    string `json:"overlayId"`
+	DataSourceID string `json:"dataSourceId"`
+	CreatedAt    string `json:"createdAt"`
+}
+
+// OverlayBindingPayload is used for Create and Update
+type OverlayBindingPayload struct {
+	DataSourceID string `json:"dataSourceId"`
+}
+
+func bindingsPath(overlayID string) string {
+	return fmt.Sprintf("/cube-overlays/%s/bindings", overlayID)
+}
+
+// ListOverlayBindings retrieves all bindings of an overlay
+func (c *Client) ListOverlayBindings(ctx context.Context, overlayID string) ([]OverlayBinding, error) {
+	body, err := c.request(ctx, "GET", bindingsPath(overlayID), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	bindings, err := unwrap[[]OverlayBinding](c.ResponseEnvelope, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal overlay bindings: %w", err)
+	}
+	return *bindings, nil
+}
+
+// GetOverlayBinding retrieves a binding of an overlay by ID
+func (c *Client) GetOverlayBinding(ctx context.Context, overlayID, bindingID string) (*OverlayBinding, error) {
+	body, err := c.request(ctx, "GET", fmt.Sprintf("%s/%s", bindingsPath(overlayID), bindingID), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	binding, err := unwrap[OverlayBinding](c.ResponseEnvelope, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal overlay binding: %w", err)
+	}
+	return binding, nil
+}
+
+// CreateOverlayBinding binds an overlay to a data source
+func (c *Client) CreateOverlayBinding(ctx context.Context, overlayID string, payload OverlayBindingPayload) (*OverlayBinding, error) {
+	body, err := c.request(ctx, "POST", bindingsPath(overlayID), payload)
+	if err != nil {
+		return nil, err
+	}
+
+	binding, err := unwrap[OverlayBinding](c.ResponseEnvelope, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal overlay binding: %w", err)
+	}
+	return binding, nil
+}
+
+// UpdateOverlayBinding points an existing binding at another data source
+func (c *Client) UpdateOverlayBinding(ctx context.Context, overlayID, bindingID string, payload OverlayBindingPayload) (*OverlayBinding, error) {
+	body, err := c.request(ctx, "PATCH", fmt.Sprintf("%s/%s", bindingsPath(overlayID), bindingID), payload)
+	if err != nil {
+		return nil, err
+	}
+
+	binding, err := unwrap[OverlayBinding](c.ResponseEnvelope, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal overlay binding: %w", err)
+	}
+	return binding, nil
+}
+
+// DeleteOverlayBinding removes a binding
+func (c *Client) DeleteOverlayBinding(ctx context.Context, overlayID, bindingID string) error {
+	_, err := c.request(ctx, "DELETE", fmt.Sprintf("%s/%s", bindingsPath(overlayID), bindingID), nil)
+	return err
+}
@@ -2,201 +2,1631 @@ package client
 
 import (
 	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"mime"
+	"net"
 	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
+	"unicode/utf8"
+
+	"github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"golang.org/x/sync/singleflight"
+)
+
+// Supported values for Client.AuthScheme
+const (
+	// AuthSchemeBearer sends the token as "Authorization: Bearer <token>"
+	AuthSchemeBearer = "bearer"
+	// AuthSchemeAPIKey sends the token in the X-API-Key header
+	AuthSchemeAPIKey = "api_key"
+)
+
+// Supported values for Client.ResponseEnvelope
+const (
+	// ResponseEnvelopeAuto detects whether a response is wrapped in a
+	// { "data": ... } envelope
+	ResponseEnvelopeAuto = "auto"
+	// ResponseEnvelopeWrapped expects every response to be wrapped
+	ResponseEnvelopeWrapped = "wrapped"
+	// ResponseEnvelopeNone expects every response to be bare
+	ResponseEnvelopeNone = "none"
+)
+
+// Supported values for Client.UpdateMethod
+const (
+	// UpdateMethodPatch sends the changed overlay fields with PATCH
+	UpdateMethodPatch = "PATCH"
+	// UpdateMethodPut replaces the overlay with PUT, for gateways that block
+	// PATCH
+	UpdateMethodPut = "PUT"
 )
 
+// DefaultSigningHeader is the header request signatures are sent in when
+// Client.SigningHeader is empty
+const DefaultSigningHeader = "X-Signature"
+
+// DefaultMaxResponseBytes is the default cap on the size of a response body
+const DefaultMaxResponseBytes = 32 << 20
+
+// DefaultRequestTimeout bounds a request whose context has no deadline of its
+// own, unless Client.RequestTimeout is set. Resource timeouts replace it by
+// setting a deadline on the context.
+const DefaultRequestTimeout = 30 * time.Second
+
+// DefaultRetryWaitMin is the wait before the first retry of a failed request.
+// Each further retry waits twice as long, up to retryWaitMax.
+const DefaultRetryWaitMin = time.Second
+
+const retryWaitMax = 30 * time.Second
+
+// DefaultListCacheTTL is how long a listing of overlays is reused, so that
+// several name lookups in one operation don't each re-list
+const DefaultListCacheTTL = 5 * time.Second
+
+// Defaults for waiting on overlays that are still processing after creation
+const (
+	DefaultPollInterval = 2 * time.Second
+	DefaultPollTimeout  = 5 * time.Minute
+)
+
+// DefaultCreateConsistencyRetries is how many times a read of an overlay
+// that was just created is retried while the API still answers 404
+const DefaultCreateConsistencyRetries = 3
+
+// DefaultCreateConsistencyDelay is the wait between those retries
+const DefaultCreateConsistencyDelay = 500 * time.Millisecond
+
+// Values of CubeOverlay.Status. API versions that process overlays
+// synchronously don't send a status.
+const (
+	// OverlayStatusProcessing means the overlay is still being compiled
+	OverlayStatusProcessing = "processing"
+	// OverlayStatusReady means the overlay has been compiled
+	OverlayStatusReady = "ready"
+)
+
+// Values of CubeOverlay.Type. API versions without overlay types don't send
+// one; their overlays are cube overlays.
+const (
+	OverlayTypeCube      = "cube"
+	OverlayTypeView      = "view"
+	OverlayTypeDashboard = "dashboard"
+)
+
+// CompressionThreshold is the request body size above which bodies are
+// gzipped when Client.CompressRequests is enabled
+const CompressionThreshold = 8 << 10
+
 // Client holds the configuration for the Revos API client
 type Client struct {
 	APIURL     string
 	Token      string
+	AuthScheme string
 	HTTPClient *http.Client
+	// RequestTimeout bounds every request attempt, including reading the
+	// response body, on top of any deadline of the caller's context. Zero
+	// means DefaultRequestTimeout for contexts without a deadline.
+	RequestTimeout time.Duration
+	// MaxRetries is how many times an idempotent request is retried after a
+	// network error or a 429, 502, 503 or 504 response. POST requests are
+	// only retried if they carry an IdempotencyKeyHeader, or failed to
+	// connect. Zero disables retries.
+	MaxRetries int
+	// RetryOnMessages are substrings of a successful response body that
+	// signal a transient failure, for backends that report some errors with
+	// a 2xx status. A matching response is retried like a 503, and fails
+	// with a TransientResponseError if it can't be retried.
+	RetryOnMessages []string
+	// RetryWaitMin is the wait before the first retry. Zero means
+	// DefaultRetryWaitMin.
+	RetryWaitMin time.Duration
+	// RetryMaxElapsedTime caps the total time of a request across all its
+	// attempts and the waits between them. Zero means no cap.
+	RetryMaxElapsedTime time.Duration
+	// MaxResponseBytes caps how much of a response body is read, so a
+	// misbehaving endpoint can't exhaust the provider's memory
+	MaxResponseBytes int64
+	// CompressRequests gzips request bodies larger than CompressionThreshold
+	CompressRequests bool
+	// ResponseEnvelope is how responses are unwrapped, one of the
+	// ResponseEnvelope constants. Empty means ResponseEnvelopeAuto.
+	ResponseEnvelope string
+	// UpdateMethod is the HTTP method overlays are updated with, one of the
+	// UpdateMethod constants. Empty means UpdateMethodPatch.
+	UpdateMethod string
+	// AllowCrossHostRedirect follows redirects to other hosts, sending the
+	// token along. By default they are refused, to not leak the token.
+	AllowCrossHostRedirect bool
+	// ConcurrencyCheck also conditions writes on the overlay's updatedAt, for
+	// APIs that don't return a version
+	ConcurrencyCheck bool
+	// LogHeaders logs the response headers of every request at debug level,
+	// except sensitiveHeaders
+	LogHeaders bool
+	// LogBodies logs the request and response bodies of every request at
+	// trace level, with the values of MaskedKeys masked. Headers aren't
+	// logged with them.
+	LogBodies bool
+	// MaskedKeys are the keys whose values are masked in logged bodies: any
+	// JSON object key containing one of them, ignoring case, at any depth.
+	// Nil means DefaultMaskedKeys.
+	MaskedKeys []string
+	// PollInterval is how often an overlay still processing after creation
+	// is polled. Zero means DefaultPollInterval.
+	PollInterval time.Duration
+	// PollTimeout is how long to wait for an overlay to finish processing.
+	// Zero means DefaultPollTimeout.
+	PollTimeout time.Duration
+	// CreateConsistencyRetries is how many times GetCreatedOverlay retries a
+	// 404, for APIs that don't serve a new overlay by ID right away. Zero
+	// disables the retries.
+	CreateConsistencyRetries int
+	// CreateConsistencyDelay is the wait between those retries. Zero means
+	// DefaultCreateConsistencyDelay.
+	CreateConsistencyDelay time.Duration
+	// SigningKey, if set, signs every request for gateways that require it:
+	// the hex HMAC-SHA256 of the request body as sent, empty for requests
+	// without one, is put in SigningHeader
+	SigningKey string
+	// SigningHeader is the header the signature is sent in. Empty means
+	// DefaultSigningHeader.
+	SigningHeader string
+	// Headers are added to every request, e.g. for a gateway in front of the
+	// API. Reserved headers the client sets itself are skipped.
+	Headers map[string]string
+	// ReadOnly refuses every request other than GET and HEAD with a
+	// ReadOnlyError, so the client can't modify anything
+	ReadOnly bool
+	// StrictDecode reports overlay response fields that CubeOverlay doesn't
+	// know in CubeOverlay.UnknownFields, to detect API version skew
+	StrictDecode bool
+	// Observer is told about every request made. Nil means no observer.
+	Observer RequestObserver
+	// ListCacheTTL is how long ListOverlays results are reused. Zero disables
+	// the cache. Any overlay write invalidates it.
+	ListCacheTTL time.Duration
+	// ReadCacheTTL is how long GET responses are reused for the same path.
+	// Zero, the default, disables the cache. Any request other than a GET or
+	// HEAD invalidates it.
+	ReadCacheTTL time.Duration
+
+	listCache overlayListCache
+	readCache responseCache
+	// dialer is the dialer set by SetDialer, if any
+	dialer *net.Dialer
+}
+
+// overlayListCache memoizes the last ListOverlays result. Concurrent misses
+// share a single request through group.
+type overlayListCache struct {
+	mu        sync.Mutex
+	overlays  []CubeOverlay
+	fetchedAt time.Time
+	// generation is bumped by invalidate, so a listing fetched before a
+	// write is not stored after it
+	generation uint64
+
+	group singleflight.Group
 }
 
+func (lc *overlayListCache) get(ttl time.Duration) ([]CubeOverlay, bool) {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+
+	if lc.overlays == nil || time.Since(lc.fetchedAt) >= ttl {
+		return nil, false
+	}
+	return append([]CubeOverlay(nil), lc.overlays...), true
+}
+
+func (lc *overlayListCache) currentGeneration() uint64 {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+
+	return lc.generation
+}
+
+// set stores a listing fetched at the given generation, unless the cache
+// was invalidated since
+func (lc *overlayListCache) set(overlays []CubeOverlay, generation uint64) {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+
+	if generation != lc.generation {
+		return
+	}
+	lc.overlays = append([]CubeOverlay{}, overlays...)
+	lc.fetchedAt = time.Now()
+}
+
+func (lc *overlayListCache) invalidate() {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+
+	lc.overlays = nil
+	lc.generation++
+	// Later callers must not join a listing started before the write
+	lc.group.Forget(listAllOverlaysPath)
+}
+
+// responseCache memoizes successful GET responses by path
+type responseCache struct {
+	mu      sync.Mutex
+	entries map[string]cachedResponse
+	// generation is bumped by invalidate, so a response fetched before a
+	// write is not stored after it
+	generation uint64
+}
+
+type cachedResponse struct {
+	body      []byte
+	header    http.Header
+	fetchedAt time.Time
+}
+
+func (rc *responseCache) get(path string, ttl time.Duration) ([]byte, http.Header, bool) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	entry, ok := rc.entries[path]
+	if !ok || time.Since(entry.fetchedAt) >= ttl {
+		return nil, nil, false
+	}
+	return append([]byte(nil), entry.body...), entry.header.Clone(), true
+}
+
+func (rc *responseCache) currentGeneration() uint64 {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	return rc.generation
+}
+
+// set stores a response fetched at the given generation, unless the cache
+// was invalidated since
+func (rc *responseCache) set(path string, generation uint64, body []byte, header http.Header) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	if generation != rc.generation {
+		return
+	}
+	if rc.entries == nil {
+		rc.entries = map[string]cachedResponse{}
+	}
+	rc.entries[path] = cachedResponse{
+		body:      append([]byte(nil), body...),
+		header:    header.Clone(),
+		fetchedAt: time.Now(),
+	}
+}
+
+func (rc *responseCache) invalidate() {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	rc.entries = nil
+	rc.generation++
+}
+
+// RequestObserver receives the outcome of every API request, e.g. to record
+// request counts and latencies
+type RequestObserver interface {
+	// ObserveRequest is called once per request, after its response body was
+	// read. status is 0 if no response was received.
+	ObserveRequest(method, path string, status int, dur time.Duration)
+}
+
+// nopObserver is the RequestObserver used when Client.Observer is nil
+type nopObserver struct{}
+
+func (nopObserver) ObserveRequest(method, path string, status int, dur time.Duration) {}
+
+func (c *Client) observer() RequestObserver {
+	if c.Observer == nil {
+		return nopObserver{}
+	}
+	return c.Observer
+}
+
+// OverlayAPI is the set of overlay operations used by the provider. Client
+// is the production implementation; tests can substitute a fake.
+type OverlayAPI interface {
+	CreateOverlay(ctx context.Context, payload OverlayPayload) (*CubeOverlay, error)
+	GetOverlay(ctx context.Context, id string) (*CubeOverlay, error)
+	GetCreatedOverlay(ctx context.Context, id string) (*CubeOverlay, error)
+	GetCreatedOverlayByName(ctx context.Context, name string) (*CubeOverlay, error)
+	GetOverlayIfModified(ctx context.Context, id string, version Version) (*CubeOverlay, bool, error)
+	GetOverlayByName(ctx context.Context, name string) (*CubeOverlay, error)
+	GetOverlayByOrganizationAndName(ctx context.Context, organizationID, name string) (*CubeOverlay, error)
+	UpdateOverlay(ctx context.Context, id string, payload OverlayPayload, precondition Precondition) (*CubeOverlay, error)
+	DeleteOverlay(ctx context.Context, id string, precondition Precondition) error
+	ArchiveOverlay(ctx context.Context, id string, precondition Precondition) error
+	LockOverlay(ctx context.Context, id string, precondition Precondition) (*CubeOverlay, error)
+	UnlockOverlay(ctx context.Context, id string, precondition Precondition) (*CubeOverlay, error)
+	ListOverlays(ctx context.Context) ([]CubeOverlay, error)
+}
+
+var _ OverlayAPI = (*Client)(nil)
+
 // NewClient creates a new Revos API client
 func NewClient(apiURL, token string) *Client {
-	return &Client{
-		APIURL: apiURL,
-		Token:  token,
-		HTTPClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+	c := &Client{
+		APIURL:           apiURL,
+		Token:            token,
+		AuthScheme:       AuthSchemeBearer,
+		MaxResponseBytes: DefaultMaxResponseBytes,
+		ListCacheTTL:     DefaultListCacheTTL,
+		PollInterval:     DefaultPollInterval,
+		PollTimeout:      DefaultPollTimeout,
+
+		CreateConsistencyRetries: DefaultCreateConsistencyRetries,
 	}
+	c.HTTPClient = &http.Client{CheckRedirect: c.checkRedirect}
+	return c
+}
+
+// maxRedirects matches the limit of the default http.Client
+const maxRedirects = 10
+
+// ErrCrossHostRedirect is returned, wrapped, for a redirect to another host
+// without AllowCrossHostRedirect. Retrying can't change the outcome, so it
+// isn't retried.
+var ErrCrossHostRedirect = errors.New("refusing to follow redirect")
+
+// checkRedirect refuses redirects to other hosts unless AllowCrossHostRedirect
+// is set, and re-attaches the credentials the standard library drops or, for
+// the API key header, would forward to any host
+func (c *Client) checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= maxRedirects {
+		return fmt.Errorf("stopped after %d redirects", maxRedirects)
+	}
+
+	from := via[0].URL
+	if req.URL.Host != from.Host && !c.AllowCrossHostRedirect {
+		return fmt.Errorf("%w from %s to another host (%s); "+
+			"update api_url to the new location, or set allow_cross_host_redirect to follow it", ErrCrossHostRedirect, from.Host, req.URL.Host)
+	}
+
+	c.setAuth(req)
+	return nil
+}
+
+// setAuth adds the token to the request according to AuthScheme
+func (c *Client) setAuth(req *http.Request) {
+	switch c.AuthScheme {
+	case AuthSchemeAPIKey:
+		req.Header.Set("X-API-Key", c.Token)
+	default:
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.Token))
+	}
+}
+
+// signPayload returns the hex HMAC-SHA256 of a request body with key
+func signPayload(key string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
 }
 
 // CubeOverlay represents the overlay resource from the API
 type CubeOverlay struct {
-	ID             string          `json:"id"`
-	Name           string          `json:"name"`
-	Description    string          `json:"description"`
-	OrganizationID string          `json:"organizationId"`
-	Data           json.RawMessage `json:"data"` // Keeping as RawMessage to support dynamic structure
-	CreatedBy      string          `json:"createdBy"`
-	CreatedAt      string          `json:"createdAt"`
-	UpdatedAt      string          `json:"updatedAt"`
+	ID             string            `json:"id"`
+	Name           string            `json:"name"`
+	Description    string            `json:"description"`
+	OrganizationID string            `json:"organizationId"`
+	Data           json.RawMessage   `json:"data"` // Keeping as RawMessage to support dynamic structure
+	CreatedBy      string            `json:"createdBy"`
+	CreatedAt      string            `json:"createdAt"`
+	UpdatedAt      string            `json:"updatedAt"`
+	Tags           map[string]string `json:"tags,omitempty"`
+	// Archived overlays are retained by the API but hidden from listings
+	Archived bool `json:"archived,omitempty"`
+	// Locked overlays can't be edited in the Revos UI, only through the API
+	Locked bool `json:"locked,omitempty"`
+	// Status is OverlayStatusProcessing while the API compiles the overlay
+	// after creation, and empty if the API processes overlays synchronously
+	Status string `json:"status,omitempty"`
+	// Enabled is false for deactivated overlays. API versions without
+	// activation don't send it; use IsEnabled.
+	Enabled *bool `json:"enabled,omitempty"`
+	// Type is one of the OverlayType constants. API versions without
+	// overlay types don't send it; use OverlayType.
+	Type string `json:"type,omitempty"`
+	// Version is the concurrency token of the overlay, taken from the
+	// "version" field or, if absent, the ETag response header
+	Version Version `json:"version,omitempty"`
+	// Warnings are advisories the API returned with a create or update,
+	// such as deprecated syntax in the data. They are not stored.
+	Warnings []string `json:"warnings,omitempty"`
+	// UnknownFields lists the response fields not decoded into the struct.
+	// It is only set with Client.StrictDecode.
+	UnknownFields []string `json:"-"`
+}
+
+// IsEnabled reports whether the overlay is active. Overlays are active
+// unless the API says otherwise.
+func (o *CubeOverlay) IsEnabled() bool {
+	return o.Enabled == nil || *o.Enabled
+}
+
+// OverlayType returns the type of the overlay, OverlayTypeCube unless the
+// API says otherwise
+func (o *CubeOverlay) OverlayType() string {
+	if o.Type == "" {
+		return OverlayTypeCube
+	}
+	return o.Type
+}
+
+// Version is an opaque concurrency token. The API may send it as a string or
+// a number, so both are accepted.
+type Version string
+
+func (v *Version) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		*v = Version(s)
+		return nil
+	}
+
+	var n json.Number
+	if err := json.Unmarshal(b, &n); err != nil {
+		return fmt.Errorf("version must be a string or number, got %s", b)
+	}
+	*v = Version(n.String())
+	return nil
+}
+
+// weak reports whether the version is a weak ETag. If-Match uses strong
+// comparison, so a weak ETag never matches there.
+func (v Version) weak() bool {
+	return strings.HasPrefix(string(v), `W/"`)
+}
+
+// ifMatch formats the version as an If-Match or If-None-Match header value.
+// ETags are sent back verbatim; bare version values are quoted as HTTP
+// requires.
+func (v Version) ifMatch() string {
+	s := string(v)
+	if strings.HasPrefix(s, `"`) || strings.HasPrefix(s, `W/"`) {
+		return s
+	}
+	return strconv.Quote(s)
+}
+
+// RequestIDHeader carries the ID that correlates a request with the API's logs
+const RequestIDHeader = "X-Request-ID"
+
+// responseIDHeaders are the response headers the API may echo a request or
+// trace ID in, in order of preference
+var responseIDHeaders = []string{RequestIDHeader, "X-Trace-ID"}
+
+// reservedHeaders are set by the client itself and can't be replaced by
+// Client.Headers
+var reservedHeaders = map[string]bool{
+	"Authorization":    true,
+	"X-Api-Key":        true,
+	"Content-Type":     true,
+	"Content-Encoding": true,
+	RequestIDHeader:    true,
+}
+
+// IsReservedHeader reports whether name is a header Client.Headers can't set
+func IsReservedHeader(name string) bool {
+	return reservedHeaders[http.CanonicalHeaderKey(name)]
+}
+
+// sensitiveHeaders are never logged, as they may carry credentials
+var sensitiveHeaders = map[string]bool{
+	"Authorization":       true,
+	"Proxy-Authorization": true,
+	"Cookie":              true,
+	"Set-Cookie":          true,
+	"X-Api-Key":           true,
+}
+
+// loggableHeaders returns the headers of h that are safe to log, with
+// multiple values joined by commas
+func loggableHeaders(h http.Header) map[string]interface{} {
+	headers := make(map[string]interface{}, len(h))
+	for k, v := range h {
+		if sensitiveHeaders[http.CanonicalHeaderKey(k)] {
+			continue
+		}
+		headers[k] = strings.Join(v, ", ")
+	}
+	return headers
+}
+
+// DefaultMaskedKeys are masked in logged bodies when Client.MaskedKeys is nil
+var DefaultMaskedKeys = []string{"password", "secret", "token", "credential", "apikey", "privatekey"}
+
+// maskedValue replaces the values of masked keys in logged bodies
+const maskedValue = "***"
+
+// loggableBody returns a body for logging with the values of maskedKeys
+// masked. A body that isn't JSON can't be masked and is logged as is.
+func loggableBody(body []byte, maskedKeys []string) string {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var v interface{}
+	if err := decoder.Decode(&v); err != nil {
+		return string(body)
+	}
+	masked, err := json.Marshal(maskValue(v, maskedKeys))
+	if err != nil {
+		return string(body)
+	}
+	return string(masked)
+}
+
+// maskValue replaces the values of object keys containing any of
+// maskedKeys, ignoring case, with maskedValue
+func maskValue(v interface{}, maskedKeys []string) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, elem := range v {
+			if isMaskedKey(k, maskedKeys) {
+				v[k] = maskedValue
+			} else {
+				v[k] = maskValue(elem, maskedKeys)
+			}
+		}
+	case []interface{}:
+		for i, elem := range v {
+			v[i] = maskValue(elem, maskedKeys)
+		}
+	}
+	return v
+}
+
+func isMaskedKey(key string, maskedKeys []string) bool {
+	key = strings.ToLower(key)
+	for _, masked := range maskedKeys {
+		if masked != "" && strings.Contains(key, strings.ToLower(masked)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Client) maskedKeys() []string {
+	if c.MaskedKeys == nil {
+		return DefaultMaskedKeys
+	}
+	return c.MaskedKeys
+}
+
+// TransientResponseError is returned for a successful response whose body
+// contains one of Client.RetryOnMessages
+type TransientResponseError struct {
+	StatusCode int
+	// Message is the RetryOnMessages entry the body contains
+	Message   string
+	RequestID string
+}
+
+func (e *TransientResponseError) Error() string {
+	return fmt.Sprintf("API reported a transient error with status %d: response contains %q (request ID: %s)", e.StatusCode, e.Message, e.RequestID)
+}
+
+// APIError is returned when the API responds with a 4xx or 5xx status
+type APIError struct {
+	StatusCode int
+	Body       string
+	// RequestID identifies the failed request in the API's logs
+	RequestID string
+}
+
+func (e *APIError) Error() string {
+	if e.RequestID != "" {
+		return fmt.Sprintf("API error %d: %s (request ID: %s)", e.StatusCode, e.Body, e.RequestID)
+	}
+	return fmt.Sprintf("API error %d: %s", e.StatusCode, e.Body)
+}
+
+// FieldError is a validation error the API reported for one field of a
+// request
+type FieldError struct {
+	// Path locates the field, such as "cubes.0.name" in the overlay data or
+	// "name" for the overlay name. It is empty for errors about the whole
+	// request.
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+// FieldErrors returns the per-field validation errors of a 400 or 422
+// response with a body such as
+// {"errors":[{"path":"cubes.0.name","message":"required"}]}. It returns nil
+// for other responses and bodies that don't have that structure.
+func (e *APIError) FieldErrors() []FieldError {
+	if e.StatusCode != http.StatusBadRequest && e.StatusCode != http.StatusUnprocessableEntity {
+		return nil
+	}
+
+	var body struct {
+		Errors []FieldError `json:"errors"`
+	}
+	if err := json.Unmarshal([]byte(e.Body), &body); err != nil {
+		return nil
+	}
+	for _, fieldErr := range body.Errors {
+		if fieldErr.Message == "" {
+			return nil
+		}
+	}
+	return body.Errors
+}
+
+// ReadOnlyError is returned for requests refused because Client.ReadOnly is
+// set
+type ReadOnlyError struct {
+	Method string
+	Path   string
+}
+
+func (e *ReadOnlyError) Error() string {
+	return fmt.Sprintf("refusing %s %s: the client is read-only", e.Method, e.Path)
+}
+
+// NotFoundError is returned by lookups that found no matching overlay
+// without the API itself responding 404, such as GetOverlayByName
+type NotFoundError struct {
+	Name string
+	// OrganizationID is set if the lookup was scoped to an organization
+	OrganizationID string
+}
+
+func (e *NotFoundError) Error() string {
+	if e.OrganizationID != "" {
+		return fmt.Sprintf("overlay with name %q not found in organization %q", e.Name, e.OrganizationID)
+	}
+	return fmt.Sprintf("overlay with name %q not found", e.Name)
+}
+
+// IsNotFound reports whether err is an API 404 response or a NotFoundError
+func IsNotFound(err error) bool {
+	var apiErr *APIError
+	var notFoundErr *NotFoundError
+	return (errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound) || errors.As(err, &notFoundErr)
+}
+
+// IsPreconditionFailed reports whether err is an API 412 response, which is
+// returned when the If-Match version no longer matches the overlay
+func IsPreconditionFailed(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusPreconditionFailed
+}
+
+// IsNotModified reports whether err is an API 304 response to a conditional
+// request, meaning the resource still has the version sent
+func IsNotModified(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotModified
 }
 
 // OverlayPayload is used for Create and Update
 type OverlayPayload struct {
-	Name        string          `json:"name"`
-	Description string          `json:"description,omitempty"`
+	Name string `json:"name"`
+	// Description is always sent, so that clearing it clears it on the server
+	Description string          `json:"description"`
 	Data        json.RawMessage `json:"data"`
+	// Tags is always sent, as an empty object when there are none, so that
+	// removing the last tag clears it on the server
+	Tags map[string]string `json:"tags"`
+	// Enabled activates or deactivates the overlay. Nil leaves it as is.
+	Enabled *bool `json:"enabled,omitempty"`
+	// Type is the overlay type. Empty leaves it to the API.
+	Type string `json:"type,omitempty"`
 }
 
-func (c *Client) request(method, path string, body interface{}) ([]byte, error) {
-	var bodyReader io.Reader
+// freshReadsKey is the context key set by withFreshReads
+type freshReadsKey struct{}
+
+// withFreshReads returns a context whose GETs skip the read cache, for reads
+// that wait for the API to change, such as consistency retries and polling.
+// Their responses still refresh the cache.
+func withFreshReads(ctx context.Context) context.Context {
+	return context.WithValue(ctx, freshReadsKey{}, true)
+}
+
+// freshReads reports whether ctx comes from withFreshReads
+func freshReads(ctx context.Context) bool {
+	fresh, _ := ctx.Value(freshReadsKey{}).(bool)
+	return fresh
+}
+
+func (c *Client) request(ctx context.Context, method, path string, body interface{}) ([]byte, error) {
+	respBody, _, err := c.requestWithHeaders(ctx, method, path, body, nil)
+	return respBody, err
+}
+
+// requestWithHeaders performs a request with additional request headers and
+// returns the response headers along with the body
+func (c *Client) requestWithHeaders(ctx context.Context, method, path string, body interface{}, header http.Header) ([]byte, http.Header, error) {
+	if c.ReadOnly && method != http.MethodGet && method != http.MethodHead {
+		return nil, nil, &ReadOnlyError{Method: method, Path: path}
+	}
+
+	// Requests with their own headers, such as conditional ones, bypass the
+	// cache. Cache hits aren't reported to the Observer.
+	if c.ReadCacheTTL > 0 && method == http.MethodGet && body == nil && len(header) == 0 {
+		if !freshReads(ctx) {
+			if respBody, respHeader, ok := c.readCache.get(path, c.ReadCacheTTL); ok {
+				return respBody, respHeader, nil
+			}
+		}
+		generation := c.readCache.currentGeneration()
+		respBody, respHeader, err := c.retry(ctx, method, path, nil, false, header)
+		if err == nil {
+			c.readCache.set(path, generation, respBody, respHeader)
+		}
+		return respBody, respHeader, err
+	}
+	if method != http.MethodGet && method != http.MethodHead {
+		// Invalidate once the write is done, even if it failed, as it may
+		// still have been applied
+		defer c.readCache.invalidate()
+	}
+
+	var payload []byte
+	compressed := false
 	if body != nil {
 		jsonBody, err := json.Marshal(body)
 		if err != nil {
-			return nil, fmt.Errorf("failed to marshal body: %w", err)
+			return nil, nil, fmt.Errorf("failed to marshal body: %w", err)
+		}
+		if c.LogBodies {
+			tflog.Trace(ctx, "API request body", map[string]interface{}{
+				"method": method,
+				"path":   path,
+				"body":   loggableBody(jsonBody, c.maskedKeys()),
+			})
+		}
+		if c.CompressRequests && len(jsonBody) > CompressionThreshold {
+			if jsonBody, err = gzipBytes(jsonBody); err != nil {
+				return nil, nil, fmt.Errorf("failed to compress body: %w", err)
+			}
+			compressed = true
+		}
+		payload = jsonBody
+	}
+
+	return c.retry(ctx, method, path, payload, compressed, header)
+}
+
+// retry performs a request, retrying it as allowed by MaxRetries and
+// RetryMaxElapsedTime
+func (c *Client) retry(ctx context.Context, method, path string, payload []byte, compressed bool, header http.Header) ([]byte, http.Header, error) {
+	if c.RetryMaxElapsedTime > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.RetryMaxElapsedTime)
+		defer cancel()
+	}
+
+	wait := c.RetryWaitMin
+	if wait <= 0 {
+		wait = DefaultRetryWaitMin
+	}
+	for retry := 0; ; retry++ {
+		respBody, respHeader, err := c.attempt(ctx, method, path, payload, compressed, header)
+		if err == nil || retry >= c.MaxRetries || !retryable(method, header, err) || ctx.Err() != nil {
+			return respBody, respHeader, err
+		}
+
+		// Give up rather than wait past the retry budget
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < wait {
+			return nil, nil, err
+		}
+
+		tflog.Debug(ctx, "Retrying API request", map[string]interface{}{
+			"method": method,
+			"path":   path,
+			"retry":  retry + 1,
+			"wait":   wait.String(),
+			"error":  err.Error(),
+		})
+
+		if sleepContext(ctx, wait) != nil {
+			return nil, nil, err
+		}
+
+		if wait *= 2; wait > retryWaitMax {
+			wait = retryWaitMax
 		}
-		bodyReader = bytes.NewReader(jsonBody)
+	}
+}
+
+// sleepContext waits for d or until ctx is done, in which case it returns
+// ctx.Err(). The timer is stopped either way, so nothing outlives a
+// cancelled operation.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// IdempotencyKeyHeader is the request header that lets the API recognize a
+// repeated POST, so that a POST sending it may be retried like an
+// idempotent request
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// retryable reports whether a failed request may be sent again: it must be
+// idempotent, and have failed in the network, with a status that signals a
+// transient problem, or with a TransientResponseError. Any other request
+// may only be retried if it never reached the API, as the API may have
+// processed it even if it failed.
+func retryable(method string, header http.Header, err error) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodPatch, http.MethodDelete:
+	case http.MethodPost:
+		if header.Get(IdempotencyKeyHeader) == "" {
+			return IsNotSent(err)
+		}
+	default:
+		return IsNotSent(err)
+	}
+
+	var transientErr *TransientResponseError
+	if errors.As(err, &transientErr) {
+		return true
+	}
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		switch apiErr.StatusCode {
+		case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	}
+
+	if errors.Is(err, ErrCrossHostRedirect) {
+		return false
+	}
+	var urlErr *url.Error
+	return errors.As(err, &urlErr)
+}
+
+// IsNotSent reports whether a request failed before it was sent, because no
+// connection to the API could be made
+func IsNotSent(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// attemptContext bounds a single request attempt. RequestTimeout applies to
+// every attempt, so each gets a fresh deadline, composed with any deadline
+// ctx already has. Without it, DefaultRequestTimeout applies to contexts
+// that have no deadline of their own.
+func (c *Client) attemptContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.RequestTimeout > 0 {
+		return context.WithTimeout(ctx, c.RequestTimeout)
+	}
+	if _, ok := ctx.Deadline(); !ok {
+		return context.WithTimeout(ctx, DefaultRequestTimeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+// attempt sends a request once. Its deadline also covers reading the
+// response body, which http.Client.Timeout wouldn't compose with the caller's
+// context.
+func (c *Client) attempt(ctx context.Context, method, path string, payload []byte, compressed bool, header http.Header) ([]byte, http.Header, error) {
+	ctx, cancel := c.attemptContext(ctx)
+	defer cancel()
+
+	var bodyReader io.Reader
+	if payload != nil {
+		bodyReader = bytes.NewReader(payload)
 	}
 
 	url := fmt.Sprintf("%s%s", c.APIURL, path)
-	req, err := http.NewRequest(method, url, bodyReader)
+	req, err := http.NewRequestWithContext(ctx, method, url, bodyReader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	requestID, err := uuid.GenerateUUID()
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, nil, fmt.Errorf("failed to generate request ID: %w", err)
 	}
 
+	for k, v := range c.Headers {
+		if !IsReservedHeader(k) {
+			req.Header.Set(k, v)
+		}
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	req.Header.Set(RequestIDHeader, requestID)
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.Token))
+	if compressed {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+	// Accept-Encoding is deliberately left unset: the transport then requests
+	// gzip itself and transparently decompresses the response
+	c.setAuth(req)
+	if c.SigningKey != "" {
+		signingHeader := c.SigningHeader
+		if signingHeader == "" {
+			signingHeader = DefaultSigningHeader
+		}
+		req.Header.Set(signingHeader, signPayload(c.SigningKey, payload))
+	}
+
+	tflog.Debug(ctx, "Sending API request", map[string]interface{}{
+		"method":     method,
+		"path":       path,
+		"request_id": requestID,
+	})
+
+	status := 0
+	start := time.Now()
+	defer func() {
+		c.observer().ObserveRequest(method, path, status, time.Since(start))
+	}()
 
 	resp, err := c.HTTPClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
+		return nil, nil, fmt.Errorf("request failed (request ID: %s): %w", requestID, err)
 	}
 	defer resp.Body.Close()
+	status = resp.StatusCode
 
-	respBody, err := io.ReadAll(resp.Body)
+	if c.LogHeaders {
+		tflog.Debug(ctx, "API response headers", map[string]interface{}{
+			"method":  method,
+			"path":    path,
+			"status":  resp.StatusCode,
+			"headers": loggableHeaders(resp.Header),
+		})
+	}
+
+	// Prefer the ID the API logged the request under, if it reports one
+	for _, h := range responseIDHeaders {
+		if id := resp.Header.Get(h); id != "" {
+			requestID = id
+			break
+		}
+	}
+
+	maxBytes := c.MaxResponseBytes
+	if maxBytes <= 0 {
+		maxBytes = DefaultMaxResponseBytes
+	}
+
+	// Read one byte past the limit to tell a body of exactly maxBytes from a larger one
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, maxBytes+1))
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
+		return nil, nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+	if int64(len(respBody)) > maxBytes {
+		return nil, nil, fmt.Errorf("response body exceeds the maximum of %d bytes (status %d)", maxBytes, resp.StatusCode)
+	}
+
+	if c.LogBodies && len(respBody) > 0 {
+		tflog.Trace(ctx, "API response body", map[string]interface{}{
+			"method":     method,
+			"path":       path,
+			"status":     resp.StatusCode,
+			"request_id": requestID,
+			"body":       loggableBody(respBody, c.maskedKeys()),
+		})
+	}
+
+	// A 304 carries no body, so callers of conditional requests get it as
+	// an error to tell it apart from an empty response
+	if resp.StatusCode == http.StatusNotModified {
+		return nil, resp.Header, &APIError{StatusCode: resp.StatusCode, RequestID: requestID}
 	}
 
 	if resp.StatusCode >= 400 {
-		return nil, fmt.Errorf("API error %d: %s", resp.StatusCode, string(respBody))
+		tflog.Debug(ctx, "API request failed", map[string]interface{}{
+			"method":      method,
+			"path":        path,
+			"status_code": resp.StatusCode,
+			"request_id":  requestID,
+		})
+		return nil, nil, &APIError{StatusCode: resp.StatusCode, Body: errorBody(respBody, resp.Header.Get("Content-Type")), RequestID: requestID}
 	}
 
-	return respBody, nil
+	for _, message := range c.RetryOnMessages {
+		if message != "" && bytes.Contains(respBody, []byte(message)) {
+			tflog.Debug(ctx, "API response reports a transient error", map[string]interface{}{
+				"method":     method,
+				"path":       path,
+				"message":    message,
+				"request_id": requestID,
+			})
+			return nil, nil, &TransientResponseError{StatusCode: resp.StatusCode, Message: message, RequestID: requestID}
+		}
+	}
+
+	return respBody, resp.Header, nil
 }
 
-// GetOverlay retrieves an overlay by ID
-func (c *Client) GetOverlay(id string) (*CubeOverlay, error) {
-	body, err := c.request("GET", fmt.Sprintf("/cube-overlays/%s", id), nil)
+// maxErrorBodyLength is how much of a non-JSON error body is kept
+const maxErrorBodyLength = 500
+
+// errorBody returns the body of an error response for APIError. A body that
+// isn't JSON, such as the HTML error page of a proxy in front of the API, is
+// collapsed to one line and truncated so it doesn't swamp the diagnostic.
+// Bodies without a Content-Type are kept as is.
+func errorBody(body []byte, contentType string) string {
+	if contentType == "" || isJSONContentType(contentType) {
+		return string(body)
+	}
+
+	summary := strings.Join(strings.Fields(string(body)), " ")
+	if len(summary) <= maxErrorBodyLength {
+		return summary
+	}
+	cut := maxErrorBodyLength
+	for cut > 0 && !utf8.RuneStart(summary[cut]) {
+		cut--
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = contentType
+	}
+	return fmt.Sprintf("%s... (truncated %d-byte %s response)", summary[:cut], len(body), mediaType)
+}
+
+// isJSONContentType reports whether a Content-Type header denotes JSON,
+// including structured types such as application/problem+json
+func isJSONContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+func gzipBytes(b []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(b); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// unwrap decodes an API response body into T. The API may return the entity
+// either bare or wrapped in a { "data": ... } envelope. With
+// ResponseEnvelopeAuto, the body is first decoded into a map to check for a
+// top-level "data" key. An overlay carries its own "data" field too, so an
+// object that also has an "id" key is treated as a bare entity rather than an
+// envelope. The other modes skip the guess.
+func unwrap[T any](envelope string, body []byte) (*T, error) {
+	body, err := unwrapBody(envelope, body)
 	if err != nil {
 		return nil, err
 	}
 
-	var overlay CubeOverlay
-	// Handle API wrapper { "data": ... } if present, based on CLI code
-	// CLI says: 
-	// const json = (await response.json()) as { data?: T } | T;
-    // if (typeof json === "object" && json !== null && "data" in json) ...
-	
-	// We'll try to unmarshal into a wrapper first
-	var wrapper struct {
-		Data *CubeOverlay `json:"data"`
-	}
-	// Also try unmarshalling directly
-	// Or just unmarshal into a generic map to check for "data" key
-	
-	// Let's stick to a simple heuristic: if it looks like {data: ...}
-	if err := json.Unmarshal(body, &wrapper); err == nil && wrapper.Data != nil && wrapper.Data.ID != "" {
-		return wrapper.Data, nil
-	}
-	
-	if err := json.Unmarshal(body, &overlay); err != nil {
+	var v T
+	if err := json.Unmarshal(body, &v); err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+// unwrapBody removes the response envelope from body, if there is one
+func unwrapBody(envelope string, body []byte) ([]byte, error) {
+	switch envelope {
+	case ResponseEnvelopeNone:
+	case ResponseEnvelopeWrapped:
+		var wrapper map[string]json.RawMessage
+		if err := json.Unmarshal(body, &wrapper); err != nil {
+			return nil, fmt.Errorf("expected a response wrapped in a \"data\" envelope: %w", err)
+		}
+		inner, ok := wrapper["data"]
+		if !ok {
+			return nil, fmt.Errorf("expected a response wrapped in a \"data\" envelope")
+		}
+		if string(bytes.TrimSpace(inner)) == "null" {
+			return nil, fmt.Errorf("response envelope contains no data")
+		}
+		body = inner
+	default:
+		var probe map[string]json.RawMessage
+		if err := json.Unmarshal(body, &probe); err == nil {
+			inner, hasData := probe["data"]
+			_, hasID := probe["id"]
+			if hasData && !hasID {
+				if string(bytes.TrimSpace(inner)) == "null" {
+					return nil, fmt.Errorf("response envelope contains no data")
+				}
+				body = inner
+			}
+		}
+	}
+	return body, nil
+}
+
+// unknownFields returns the sorted keys of the JSON object in body that T
+// doesn't decode, matching keys the same way json.Unmarshal does
+func unknownFields[T any](body []byte) []string {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return nil
+	}
+
+	var unknown []string
+	for key, value := range fields {
+		field, err := json.Marshal(map[string]json.RawMessage{key: value})
+		if err != nil {
+			continue
+		}
+		d := json.NewDecoder(bytes.NewReader(field))
+		d.DisallowUnknownFields()
+		var v T
+		if err := d.Decode(&v); err != nil && strings.HasPrefix(err.Error(), "json: unknown field") {
+			unknown = append(unknown, key)
+		}
+	}
+	sort.Strings(unknown)
+	return unknown
+}
+
+// decodeOverlay unwraps an overlay response, falling back to the ETag header
+// for the version when the body doesn't carry one
+func (c *Client) decodeOverlay(body []byte, header http.Header) (*CubeOverlay, error) {
+	body, err := unwrapBody(c.ResponseEnvelope, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal overlay: %w", err)
+	}
+	overlay := &CubeOverlay{}
+	if err := json.Unmarshal(body, overlay); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal overlay: %w", err)
 	}
-	
-	return &overlay, nil
+	if c.StrictDecode {
+		overlay.UnknownFields = unknownFields[CubeOverlay](body)
+	}
+	if overlay.Version == "" {
+		overlay.Version = Version(header.Get("ETag"))
+	}
+	return overlay, nil
+}
+
+// Precondition is what a write to an overlay is conditioned on, so that it
+// fails with a 412 if the overlay changed since it was last read
+type Precondition struct {
+	// Version is sent as If-Match, if set and not a weak ETag
+	Version Version
+	// UpdatedAt is the overlay's updatedAt as last read. With
+	// ConcurrencyCheck it is sent as If-Unmodified-Since, for APIs that
+	// don't version overlays.
+	UpdatedAt string
+}
+
+// preconditionHeader returns the conditional request headers for p, or nil
+// if there are none. A weak ETag can't satisfy If-Match, so it isn't sent and
+// the write is unconditional.
+func (c *Client) preconditionHeader(ctx context.Context, p Precondition) http.Header {
+	header := http.Header{}
+	switch {
+	case p.Version.weak():
+		tflog.Debug(ctx, "Not sending a weak ETag as If-Match, which only matches strong ETags", map[string]interface{}{
+			"etag": string(p.Version),
+		})
+	case p.Version != "":
+		header.Set("If-Match", p.Version.ifMatch())
+	}
+	if c.ConcurrencyCheck && p.UpdatedAt != "" {
+		if updatedAt, err := time.Parse(time.RFC3339Nano, p.UpdatedAt); err == nil {
+			header.Set("If-Unmodified-Since", updatedAt.UTC().Format(http.TimeFormat))
+		}
+	}
+	if len(header) == 0 {
+		return nil
+	}
+	return header
+}
+
+// GetOverlay retrieves an overlay by ID
+func (c *Client) GetOverlay(ctx context.Context, id string) (*CubeOverlay, error) {
+	body, header, err := c.requestWithHeaders(ctx, "GET", fmt.Sprintf("/cube-overlays/%s", id), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.decodeOverlay(body, header)
+}
+
+// GetOverlayIfModified retrieves an overlay like GetOverlay unless it still
+// has version, which the API reports with a 304 to a conditional request, in
+// which case it returns false and no overlay. With ReadCacheTTL, cached reads
+// are preferred, as conditional requests bypass the cache.
+func (c *Client) GetOverlayIfModified(ctx context.Context, id string, version Version) (*CubeOverlay, bool, error) {
+	if version == "" || c.ReadCacheTTL > 0 {
+		overlay, err := c.GetOverlay(ctx, id)
+		return overlay, true, err
+	}
+
+	header := http.Header{}
+	header.Set("If-None-Match", version.ifMatch())
+	body, respHeader, err := c.requestWithHeaders(ctx, "GET", fmt.Sprintf("/cube-overlays/%s", id), nil, header)
+	if IsNotModified(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, true, err
+	}
+	overlay, err := c.decodeOverlay(body, respHeader)
+	return overlay, true, err
+}
+
+// GetCreatedOverlay retrieves an overlay that was just created, retrying up
+// to CreateConsistencyRetries times while the API answers 404, as eventually
+// consistent API versions do for a moment after a create. Its reads bypass
+// the read cache, which would answer every retry the same.
+func (c *Client) GetCreatedOverlay(ctx context.Context, id string) (*CubeOverlay, error) {
+	ctx = withFreshReads(ctx)
+	return c.retryUntilCreated(ctx, id, func() (*CubeOverlay, error) {
+		return c.GetOverlay(ctx, id)
+	})
+}
+
+// GetCreatedOverlayByName finds an overlay that was just created, e.g. for
+// API versions that return neither the overlay nor its location. An archived
+// overlay with the same name is an older one, so only active overlays are
+// considered, and the lookup is retried like GetCreatedOverlay until the new
+// overlay is listed. Like the list cache, the read cache is bypassed.
+func (c *Client) GetCreatedOverlayByName(ctx context.Context, name string) (*CubeOverlay, error) {
+	ctx = withFreshReads(ctx)
+	return c.retryUntilCreated(ctx, name, func() (*CubeOverlay, error) {
+		c.listCache.invalidate()
+		return c.findOverlayByName(ctx, "", name, false)
+	})
+}
+
+// retryUntilCreated calls get, for the overlay identified by key, until it
+// doesn't fail with a not found error or CreateConsistencyRetries run out
+func (c *Client) retryUntilCreated(ctx context.Context, key string, get func() (*CubeOverlay, error)) (*CubeOverlay, error) {
+	delay := c.CreateConsistencyDelay
+	if delay <= 0 {
+		delay = DefaultCreateConsistencyDelay
+	}
+
+	overlay, err := get()
+	for attempt := 1; attempt <= c.CreateConsistencyRetries && IsNotFound(err); attempt++ {
+		tflog.Debug(ctx, "Overlay not found yet, retrying", map[string]interface{}{
+			"overlay": key,
+			"attempt": attempt,
+		})
+		if err := sleepContext(ctx, delay); err != nil {
+			return nil, fmt.Errorf("waiting for overlay %s to be readable: %w", key, err)
+		}
+		overlay, err = get()
+	}
+	return overlay, err
 }
 
 // CreateOverlay creates a new overlay
-func (c *Client) CreateOverlay(payload OverlayPayload) (*CubeOverlay, error) {
-	body, err := c.request("POST", "/cube-overlays", payload)
+func (c *Client) CreateOverlay(ctx context.Context, payload OverlayPayload) (*CubeOverlay, error) {
+	defer c.listCache.invalidate()
+	body, header, err := c.requestWithHeaders(ctx, "POST", "/cube-overlays", payload, nil)
+	if err != nil {
+		return nil, err
+	}
+	var overlay *CubeOverlay
+	if isEmptyBody(body) {
+		// Some API versions don't echo the created overlay, so fetch it,
+		// by the Location header if there is one and by name otherwise
+		if location, parseErr := url.Parse(header.Get("Location")); parseErr == nil && location.Path != "" {
+			overlay, err = c.GetCreatedOverlay(ctx, path.Base(location.Path))
+		} else {
+			overlay, err = c.GetCreatedOverlayByName(ctx, payload.Name)
+		}
+	} else {
+		overlay, err = c.decodeOverlay(body, header)
+	}
 	if err != nil {
 		return nil, err
 	}
+	return c.waitUntilProcessed(ctx, overlay)
+}
+
+// waitUntilProcessed polls an overlay until it is no longer processing, so
+// that it isn't read back half compiled
+func (c *Client) waitUntilProcessed(ctx context.Context, overlay *CubeOverlay) (*CubeOverlay, error) {
+	if overlay.Status != OverlayStatusProcessing {
+		return overlay, nil
+	}
 
-	var wrapper struct {
-		Data *CubeOverlay `json:"data"`
+	interval, timeout := c.PollInterval, c.PollTimeout
+	if interval <= 0 {
+		interval = DefaultPollInterval
 	}
-	if err := json.Unmarshal(body, &wrapper); err == nil && wrapper.Data != nil {
-		return wrapper.Data, nil
+	if timeout <= 0 {
+		timeout = DefaultPollTimeout
 	}
-	
-	var overlay CubeOverlay
-	if err := json.Unmarshal(body, &overlay); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal overlay: %w", err)
+	deadline := time.Now().Add(timeout)
+
+	for overlay.Status == OverlayStatusProcessing {
+		if time.Now().Add(interval).After(deadline) {
+			return nil, fmt.Errorf("overlay %s is still processing after %s", overlay.ID, timeout)
+		}
+
+		tflog.Debug(ctx, "Waiting for overlay to finish processing", map[string]interface{}{
+			"id": overlay.ID,
+		})
+		if err := sleepContext(ctx, interval); err != nil {
+			return nil, fmt.Errorf("waiting for overlay %s to finish processing: %w", overlay.ID, err)
+		}
+
+		var err error
+		overlay, err = c.GetCreatedOverlay(ctx, overlay.ID)
+		if err != nil {
+			return nil, err
+		}
 	}
-	return &overlay, nil
+	return overlay, nil
 }
 
-// UpdateOverlay updates an existing overlay
-func (c *Client) UpdateOverlay(id string, payload OverlayPayload) (*CubeOverlay, error) {
-	body, err := c.request("PATCH", fmt.Sprintf("/cube-overlays/%s", id), payload)
+// UpdateOverlay updates an existing overlay with UpdateMethod. It fails with
+// a 412 if the overlay no longer meets precondition.
+func (c *Client) UpdateOverlay(ctx context.Context, id string, payload OverlayPayload, precondition Precondition) (*CubeOverlay, error) {
+	defer c.listCache.invalidate()
+	method := http.MethodPatch
+	if c.UpdateMethod == UpdateMethodPut {
+		method = http.MethodPut
+		// PUT replaces the overlay, so a payload that leaves activation as
+		// is has to send the current activation
+		if payload.Enabled == nil {
+			current, err := c.GetOverlay(ctx, id)
+			if err != nil {
+				return nil, err
+			}
+			payload.Enabled = current.Enabled
+		}
+	}
+	body, header, err := c.requestWithHeaders(ctx, method, fmt.Sprintf("/cube-overlays/%s", id), payload, c.preconditionHeader(ctx, precondition))
 	if err != nil {
 		return nil, err
 	}
+	if isEmptyBody(body) {
+		// Some API versions respond 204 without the updated overlay
+		return c.GetOverlay(ctx, id)
+	}
+	return c.decodeOverlay(body, header)
+}
+
+// isEmptyBody reports whether a successful response has no content, as with
+// a 204 No Content
+func isEmptyBody(body []byte) bool {
+	return len(bytes.TrimSpace(body)) == 0
+}
+
+// DeleteOverlay deletes an overlay. It fails with a 412 if the overlay no
+// longer meets precondition.
+func (c *Client) DeleteOverlay(ctx context.Context, id string, precondition Precondition) error {
+	defer c.listCache.invalidate()
+	_, _, err := c.requestWithHeaders(ctx, "DELETE", fmt.Sprintf("/cube-overlays/%s", id), nil, c.preconditionHeader(ctx, precondition))
+	return err
+}
+
+// DeleteOverlays deletes several overlays unconditionally, e.g. to clean up
+// after tests. It doesn't stop at the first failure: if any overlay can't be
+// deleted, it returns a *BatchDeleteError listing which were deleted and why
+// the others weren't. Overlays that are already gone count as deleted.
+func (c *Client) DeleteOverlays(ctx context.Context, ids []string) error {
+	batchErr := &BatchDeleteError{Failed: map[string]error{}}
+	for _, id := range ids {
+		if err := c.DeleteOverlay(ctx, id, Precondition{}); err != nil && !IsNotFound(err) {
+			batchErr.Failed[id] = err
+			continue
+		}
+		batchErr.Deleted = append(batchErr.Deleted, id)
+	}
+	if len(batchErr.Failed) > 0 {
+		return batchErr
+	}
+	return nil
+}
+
+// BatchDeleteError is returned by DeleteOverlays when some overlays couldn't
+// be deleted
+type BatchDeleteError struct {
+	// Deleted are the IDs of the overlays that were deleted, in the order
+	// they were given
+	Deleted []string
+	// Failed maps the IDs of the overlays that weren't deleted to the error
+	Failed map[string]error
+}
 
-	var wrapper struct {
-		Data *CubeOverlay `json:"data"`
+func (e *BatchDeleteError) Error() string {
+	failures := make([]string, 0, len(e.Failed))
+	for _, id := range e.failedIDs() {
+		failures = append(failures, fmt.Sprintf("%s: %s", id, e.Failed[id]))
 	}
-	if err := json.Unmarshal(body, &wrapper); err == nil && wrapper.Data != nil {
-		return wrapper.Data, nil
+	msg := fmt.Sprintf("failed to delete %d of %d overlays: %s", len(e.Failed), len(e.Failed)+len(e.Deleted), strings.Join(failures, "; "))
+	if len(e.Deleted) > 0 {
+		msg += fmt.Sprintf(" (deleted: %s)", strings.Join(e.Deleted, ", "))
 	}
+	return msg
+}
 
-	var overlay CubeOverlay
-	if err := json.Unmarshal(body, &overlay); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal overlay: %w", err)
+// Unwrap returns the errors of the failed deletions, for errors.Is and
+// errors.As
+func (e *BatchDeleteError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Failed))
+	for _, id := range e.failedIDs() {
+		errs = append(errs, e.Failed[id])
 	}
-	return &overlay, nil
+	return errs
 }
 
-// DeleteOverlay deletes an overlay
-func (c *Client) DeleteOverlay(id string) error {
-	_, err := c.request("DELETE", fmt.Sprintf("/cube-overlays/%s", id), nil)
+func (e *BatchDeleteError) failedIDs() []string {
+	ids := make([]string, 0, len(e.Failed))
+	for id := range e.Failed {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// ArchiveOverlay archives an overlay instead of deleting it. The overlay is
+// kept by the API but no longer listed. It fails with a 412 if the overlay
+// no longer meets precondition.
+func (c *Client) ArchiveOverlay(ctx context.Context, id string, precondition Precondition) error {
+	defer c.listCache.invalidate()
+	_, _, err := c.requestWithHeaders(ctx, "POST", fmt.Sprintf("/cube-overlays/%s/archive", id), nil, c.preconditionHeader(ctx, precondition))
 	return err
 }
 
-// ListOverlays retrieves all overlays
-func (c *Client) ListOverlays() ([]CubeOverlay, error) {
-	body, err := c.request("GET", "/cube-overlays", nil)
+// LockOverlay locks an overlay against edits in the Revos UI, so it can
+// only be changed through the API. It fails with a 412 if the overlay no
+// longer meets precondition.
+func (c *Client) LockOverlay(ctx context.Context, id string, precondition Precondition) (*CubeOverlay, error) {
+	return c.setOverlayLock(ctx, id, "lock", precondition)
+}
+
+// UnlockOverlay lifts the lock of an overlay. It fails with a 412 if the
+// overlay no longer meets precondition.
+func (c *Client) UnlockOverlay(ctx context.Context, id string, precondition Precondition) (*CubeOverlay, error) {
+	return c.setOverlayLock(ctx, id, "unlock", precondition)
+}
+
+func (c *Client) setOverlayLock(ctx context.Context, id, action string, precondition Precondition) (*CubeOverlay, error) {
+	defer c.listCache.invalidate()
+	body, header, err := c.requestWithHeaders(ctx, "POST", fmt.Sprintf("/cube-overlays/%s/%s", id, action), nil, c.preconditionHeader(ctx, precondition))
 	if err != nil {
 		return nil, err
 	}
-
-	// Try wrapper format first
-	var wrapper struct {
-		Data []CubeOverlay `json:"data"`
+	if isEmptyBody(body) {
+		return c.GetOverlay(ctx, id)
 	}
-	if err := json.Unmarshal(body, &wrapper); err == nil && wrapper.Data != nil {
-		return wrapper.Data, nil
+	return c.decodeOverlay(body, header)
+}
+
+// ListOverlays retrieves all overlays that are not archived. Results are
+// reused for ListCacheTTL.
+func (c *Client) ListOverlays(ctx context.Context) ([]CubeOverlay, error) {
+	all, err := c.ListAllOverlays(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	// Try direct array
-	var overlays []CubeOverlay
-	if err := json.Unmarshal(body, &overlays); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal overlays: %w", err)
+	overlays := make([]CubeOverlay, 0, len(all))
+	for _, overlay := range all {
+		if !overlay.Archived {
+			overlays = append(overlays, overlay)
+		}
 	}
 	return overlays, nil
 }
 
-// GetOverlayByName retrieves an overlay by its name
-func (c *Client) GetOverlayByName(name string) (*CubeOverlay, error) {
-	overlays, err := c.ListOverlays()
+const listAllOverlaysPath = "/cube-overlays?includeArchived=true"
+
+// ListAllOverlays retrieves all overlays including archived ones, through
+// the same cache as ListOverlays. Concurrent calls that miss the cache share
+// one request, bounded by RequestTimeout, or DefaultRequestTimeout, rather
+// than by the context of the caller that happened to start it.
+func (c *Client) ListAllOverlays(ctx context.Context) ([]CubeOverlay, error) {
+	if overlays, ok := c.listCache.get(c.ListCacheTTL); ok {
+		return overlays, nil
+	}
+
+	timeout := c.RequestTimeout
+	if timeout <= 0 {
+		timeout = DefaultRequestTimeout
+	}
+	results := c.listCache.group.DoChan(listAllOverlaysPath, func() (interface{}, error) {
+		// A caller going away mustn't fail the request for the others
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), timeout)
+		defer cancel()
+
+		generation := c.listCache.currentGeneration()
+		body, err := c.request(ctx, "GET", listAllOverlaysPath, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		overlays, err := unwrap[[]CubeOverlay](c.ResponseEnvelope, body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal overlays: %w", err)
+		}
+		if c.ListCacheTTL > 0 {
+			c.listCache.set(*overlays, generation)
+		}
+		return *overlays, nil
+	})
+
+	var result singleflight.Result
+	select {
+	case result = <-results:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if result.Err != nil {
+		return nil, result.Err
+	}
+	// Callers sharing a request must not see each other's modifications
+	return append([]CubeOverlay(nil), result.Val.([]CubeOverlay)...), nil
+}
+
+// GetOverlayByName retrieves an overlay by its name. Archived overlays are
+// found too, so they can be imported; an active overlay takes precedence over
+// an archived one with the same name.
+func (c *Client) GetOverlayByName(ctx context.Context, name string) (*CubeOverlay, error) {
+	return c.findOverlayByName(ctx, "", name, true)
+}
+
+// GetOverlayByOrganizationAndName is GetOverlayByName restricted to one
+// organization, for tokens with access to several that reuse names
+func (c *Client) GetOverlayByOrganizationAndName(ctx context.Context, organizationID, name string) (*CubeOverlay, error) {
+	return c.findOverlayByName(ctx, organizationID, name, true)
+}
+
+// findOverlayByName looks up an overlay by name, in any organization if
+// organizationID is empty. Active overlays are preferred, and archived ones
+// are only returned with includeArchived.
+func (c *Client) findOverlayByName(ctx context.Context, organizationID, name string, includeArchived bool) (*CubeOverlay, error) {
+	overlays, err := c.ListAllOverlays(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	for _, overlay := range overlays {
-		if overlay.Name == name {
+	var archived *CubeOverlay
+	for i, overlay := range overlays {
+		if overlay.Name != name || (organizationID != "" && overlay.OrganizationID != organizationID) {
+			continue
+		}
+		if !overlay.Archived {
 			return &overlay, nil
 		}
+		if archived == nil && includeArchived {
+			archived = &overlays[i]
+		}
+	}
+	if archived != nil {
+		return archived, nil
 	}
-	return nil, fmt.Errorf("overlay with name %q not found", name)
+	return nil, &NotFoundError{Name: name, OrganizationID: organizationID}
 }
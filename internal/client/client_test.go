@@ -0,0 +1,2577 @@
+package client
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflogtest"
+	"go.uber.org/goleak"
+)
+
+func TestUnwrapOverlay(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		expectedID  string
+		expectedDef string
+		expectError bool
+	}{
+		{
+			name:        "wrapped",
+			body:        `{"data": {"id": "ov-1", "name": "foo", "data": {"a": 1}}}`,
+			expectedID:  "ov-1",
+			expectedDef: `{"a": 1}`,
+		},
+		{
+			name:        "unwrapped",
+			body:        `{"id": "ov-1", "name": "foo", "data": {"a": 1}}`,
+			expectedID:  "ov-1",
+			expectedDef: `{"a": 1}`,
+		},
+		{
+			name:        "unwrapped with data key first",
+			body:        `{"data": {"a": 1}, "id": "ov-1", "name": "foo"}`,
+			expectedID:  "ov-1",
+			expectedDef: `{"a": 1}`,
+		},
+		{
+			name:        "wrapped with empty id",
+			body:        `{"data": {"id": "", "name": "foo", "data": {"a": 1}}}`,
+			expectedID:  "",
+			expectedDef: `{"a": 1}`,
+		},
+		{
+			name:        "wrapped with extra envelope fields",
+			body:        `{"data": {"id": "ov-1", "name": "foo", "data": {}}, "meta": {"requestId": "r"}}`,
+			expectedID:  "ov-1",
+			expectedDef: `{}`,
+		},
+		{
+			name:        "wrapped null",
+			body:        `{"data": null}`,
+			expectError: true,
+		},
+		{
+			name:        "invalid JSON",
+			body:        `not json`,
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			overlay, err := unwrap[CubeOverlay](ResponseEnvelopeAuto, []byte(tt.body))
+			if tt.expectError {
+				if err == nil {
+					t.Fatalf("expected error, got overlay %+v", overlay)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if overlay.ID != tt.expectedID {
+				t.Errorf("ID = %q, want %q", overlay.ID, tt.expectedID)
+			}
+			if string(overlay.Data) != tt.expectedDef {
+				t.Errorf("Data = %s, want %s", overlay.Data, tt.expectedDef)
+			}
+		})
+	}
+}
+
+func TestUnwrapOverlayList(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		expectedLen int
+		expectError bool
+	}{
+		{
+			name:        "wrapped",
+			body:        `{"data": [{"id": "a"}, {"id": "b"}]}`,
+			expectedLen: 2,
+		},
+		{
+			name:        "unwrapped",
+			body:        `[{"id": "a"}, {"id": "b"}, {"id": "c"}]`,
+			expectedLen: 3,
+		},
+		{
+			name:        "wrapped empty",
+			body:        `{"data": []}`,
+			expectedLen: 0,
+		},
+		{
+			name:        "object without envelope",
+			body:        `{"items": []}`,
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			overlays, err := unwrap[[]CubeOverlay](ResponseEnvelopeAuto, []byte(tt.body))
+			if tt.expectError {
+				if err == nil {
+					t.Fatalf("expected error, got %+v", overlays)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if len(*overlays) != tt.expectedLen {
+				t.Errorf("len = %d, want %d", len(*overlays), tt.expectedLen)
+			}
+		})
+	}
+}
+
+func TestUnwrap_ResponseEnvelope(t *testing.T) {
+	// An overlay whose own definition has a top-level "data" key, and which
+	// the API returns without an "id"
+	const overlay = `{"name":"nested","data":{"data":{"cubes":[]}}}`
+
+	tests := []struct {
+		name         string
+		envelope     string
+		body         string
+		expectedData string
+		expectError  bool
+	}{
+		{
+			name:         "auto wrapped",
+			envelope:     ResponseEnvelopeAuto,
+			body:         `{"data":` + overlay + `}`,
+			expectedData: `{"data":{"cubes":[]}}`,
+		},
+		{
+			name:         "auto misreads a bare overlay without an id",
+			envelope:     ResponseEnvelopeAuto,
+			body:         overlay,
+			expectedData: `{"cubes":[]}`,
+		},
+		{
+			name:         "wrapped",
+			envelope:     ResponseEnvelopeWrapped,
+			body:         `{"data":` + overlay + `}`,
+			expectedData: `{"data":{"cubes":[]}}`,
+		},
+		{
+			name:        "wrapped without envelope",
+			envelope:    ResponseEnvelopeWrapped,
+			body:        `{"id":"ov-1","name":"nested"}`,
+			expectError: true,
+		},
+		{
+			name:        "wrapped null",
+			envelope:    ResponseEnvelopeWrapped,
+			body:        `{"data":null}`,
+			expectError: true,
+		},
+		{
+			name:         "none",
+			envelope:     ResponseEnvelopeNone,
+			body:         overlay,
+			expectedData: `{"data":{"cubes":[]}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := unwrap[CubeOverlay](tt.envelope, []byte(tt.body))
+			if tt.expectError {
+				if err == nil {
+					t.Fatalf("expected error, got overlay %+v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if string(got.Data) != tt.expectedData {
+				t.Errorf("Data = %s, want %s", got.Data, tt.expectedData)
+			}
+		})
+	}
+}
+
+func TestGetOverlay_ResponseEnvelope(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"name":"nested","data":{"data":{"cubes":[]}}}`))
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, "token")
+	c.ResponseEnvelope = ResponseEnvelopeNone
+
+	overlay, err := c.GetOverlay(context.Background(), "ov-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if overlay.Name != "nested" || string(overlay.Data) != `{"data":{"cubes":[]}}` {
+		t.Errorf("overlay = %+v, want the bare overlay", overlay)
+	}
+}
+
+func TestGetOverlay_Wrapped(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data": {"id": "ov-1", "name": "foo", "data": {"a": 1}}}`))
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, "token")
+	overlay, err := c.GetOverlay(context.Background(), "ov-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if overlay.ID != "ov-1" || overlay.Name != "foo" {
+		t.Errorf("unexpected overlay: %+v", overlay)
+	}
+}
+
+func TestRequest_AuthScheme(t *testing.T) {
+	tests := []struct {
+		name           string
+		scheme         string
+		expectedAuth   string
+		expectedAPIKey string
+	}{
+		{
+			name:         "default bearer",
+			scheme:       "",
+			expectedAuth: "Bearer secret",
+		},
+		{
+			name:         "bearer",
+			scheme:       AuthSchemeBearer,
+			expectedAuth: "Bearer secret",
+		},
+		{
+			name:           "api key",
+			scheme:         AuthSchemeAPIKey,
+			expectedAPIKey: "secret",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got http.Header
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = r.Header.Clone()
+				w.Write([]byte(`[]`))
+			}))
+			defer server.Close()
+
+			c := NewClient(server.URL, "secret")
+			if tt.scheme != "" {
+				c.AuthScheme = tt.scheme
+			}
+			if _, err := c.ListOverlays(context.Background()); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if auth := got.Get("Authorization"); auth != tt.expectedAuth {
+				t.Errorf("Authorization = %q, want %q", auth, tt.expectedAuth)
+			}
+			if key := got.Get("X-API-Key"); key != tt.expectedAPIKey {
+				t.Errorf("X-API-Key = %q, want %q", key, tt.expectedAPIKey)
+			}
+		})
+	}
+}
+
+func TestOverlayVersion(t *testing.T) {
+	tests := []struct {
+		name            string
+		body            string
+		etag            string
+		expectedVersion Version
+		expectedIfMatch string
+	}{
+		{
+			name:            "string field",
+			body:            `{"id": "ov-1", "version": "abc"}`,
+			expectedVersion: "abc",
+			expectedIfMatch: `"abc"`,
+		},
+		{
+			name:            "number field",
+			body:            `{"id": "ov-1", "version": 7}`,
+			expectedVersion: "7",
+			expectedIfMatch: `"7"`,
+		},
+		{
+			name:            "etag header",
+			body:            `{"id": "ov-1"}`,
+			etag:            `W/"xyz"`,
+			expectedVersion: `W/"xyz"`,
+			expectedIfMatch: "",
+		},
+		{
+			name:            "field takes precedence over header",
+			body:            `{"id": "ov-1", "version": 7}`,
+			etag:            `"other"`,
+			expectedVersion: "7",
+			expectedIfMatch: `"7"`,
+		},
+		{
+			name:            "none",
+			body:            `{"id": "ov-1"}`,
+			expectedVersion: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			if tt.etag != "" {
+				header.Set("ETag", tt.etag)
+			}
+
+			overlay, err := (&Client{}).decodeOverlay([]byte(tt.body), header)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if overlay.Version != tt.expectedVersion {
+				t.Errorf("Version = %q, want %q", overlay.Version, tt.expectedVersion)
+			}
+			if got := (&Client{}).preconditionHeader(context.Background(), Precondition{Version: overlay.Version}).Get("If-Match"); got != tt.expectedIfMatch {
+				t.Errorf("If-Match = %q, want %q", got, tt.expectedIfMatch)
+			}
+		})
+	}
+}
+
+func TestDecodeOverlay_StrictDecode(t *testing.T) {
+	body := `{"data": {"id": "ov-1", "name": "sales", "Description": "case-insensitive match", "cubeCount": 3, "owner": {"id": "u-1"}}}`
+
+	tests := []struct {
+		name     string
+		strict   bool
+		expected []string
+	}{
+		{name: "lenient", strict: false, expected: nil},
+		{name: "strict", strict: true, expected: []string{"cubeCount", "owner"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Client{StrictDecode: tt.strict}
+			overlay, err := c.decodeOverlay([]byte(body), http.Header{})
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if overlay.ID != "ov-1" || overlay.Description != "case-insensitive match" {
+				t.Errorf("overlay not decoded: %+v", overlay)
+			}
+			if !reflect.DeepEqual(overlay.UnknownFields, tt.expected) {
+				t.Errorf("UnknownFields = %v, want %v", overlay.UnknownFields, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCreateAndUpdateOverlay_EmptyResponse(t *testing.T) {
+	tests := []struct {
+		name     string
+		location string
+	}{
+		{name: "with location", location: "/cube-overlays/ov-1"},
+		{name: "absolute location", location: "https://api.revos.io/cube-overlays/ov-1?view=full"},
+		{name: "without location"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests = append(requests, r.Method+" "+r.URL.RequestURI())
+				switch {
+				case r.Method == http.MethodPost:
+					if tt.location != "" {
+						w.Header().Set("Location", tt.location)
+					}
+					w.WriteHeader(http.StatusNoContent)
+				case r.Method == http.MethodPatch:
+					w.WriteHeader(http.StatusNoContent)
+				case r.URL.Path == "/cube-overlays":
+					w.Write([]byte(`[{"id":"ov-1","name":"sales"}]`))
+				default:
+					w.Header().Set("ETag", `"2"`)
+					w.Write([]byte(`{"id":"ov-1","name":"sales"}`))
+				}
+			}))
+			defer server.Close()
+
+			c := NewClient(server.URL, "token")
+
+			created, err := c.CreateOverlay(context.Background(), OverlayPayload{Name: "sales"})
+			if err != nil {
+				t.Fatalf("CreateOverlay: %s", err)
+			}
+			if created.ID != "ov-1" {
+				t.Errorf("created ID = %q, want ov-1", created.ID)
+			}
+
+			updated, err := c.UpdateOverlay(context.Background(), "ov-1", OverlayPayload{Name: "sales"}, Precondition{})
+			if err != nil {
+				t.Fatalf("UpdateOverlay: %s", err)
+			}
+			if updated.ID != "ov-1" || updated.Version != `"2"` {
+				t.Errorf("updated = %+v, want ov-1 at version 2", updated)
+			}
+
+			lookup := "GET /cube-overlays/ov-1"
+			if tt.location == "" {
+				lookup = "GET " + listAllOverlaysPath
+			}
+			expected := []string{"POST /cube-overlays", lookup, "PATCH /cube-overlays/ov-1", "GET /cube-overlays/ov-1"}
+			if !reflect.DeepEqual(requests, expected) {
+				t.Errorf("requests = %v, want %v", requests, expected)
+			}
+		})
+	}
+}
+
+func TestCreateOverlay_EmptyResponseSkipsArchivedNamesake(t *testing.T) {
+	tests := []struct {
+		name string
+		// listedFrom is the list request from which the new overlay is
+		// listed, or 0 for never
+		listedFrom    int
+		readCacheTTL  time.Duration
+		expectedID    string
+		expectedLists int
+	}{
+		{name: "listed eventually", listedFrom: 2, expectedID: "ov-new", expectedLists: 2},
+		{name: "listed eventually with a read cache", listedFrom: 2, readCacheTTL: time.Minute, expectedID: "ov-new", expectedLists: 2},
+		{name: "never listed", expectedLists: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var lists int
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == http.MethodPost {
+					w.WriteHeader(http.StatusNoContent)
+					return
+				}
+				lists++
+				// An archived overlay with the same name is listed from the
+				// start
+				overlays := `{"id":"ov-old","name":"sales","organizationId":"org-1","archived":true}`
+				if tt.listedFrom > 0 && lists >= tt.listedFrom {
+					overlays += `,{"id":"ov-new","name":"sales","organizationId":"org-1"}`
+				}
+				w.Write([]byte("[" + overlays + "]"))
+			}))
+			defer server.Close()
+
+			c := NewClient(server.URL, "token")
+			c.CreateConsistencyRetries = 2
+			c.CreateConsistencyDelay = time.Millisecond
+			c.ReadCacheTTL = tt.readCacheTTL
+
+			created, err := c.CreateOverlay(context.Background(), OverlayPayload{Name: "sales"})
+			if tt.expectedID == "" {
+				if !IsNotFound(err) {
+					t.Errorf("expected a not found error, got %v (%+v)", err, created)
+				}
+			} else if err != nil {
+				t.Fatalf("CreateOverlay: %s", err)
+			} else if created.ID != tt.expectedID {
+				t.Errorf("created ID = %q, want %s", created.ID, tt.expectedID)
+			}
+			if lists != tt.expectedLists {
+				t.Errorf("%d list requests, want %d", lists, tt.expectedLists)
+			}
+		})
+	}
+}
+
+func TestCreateOverlay_WaitsUntilProcessed(t *testing.T) {
+	tests := []struct {
+		name            string
+		pollsUntilReady int
+		pollTimeout     time.Duration
+		readCacheTTL    time.Duration
+		expectedError   string
+	}{
+		{name: "synchronous", pollsUntilReady: 0},
+		{name: "processing then ready", pollsUntilReady: 2},
+		{name: "processing then ready with a read cache", pollsUntilReady: 2, pollTimeout: time.Second, readCacheTTL: time.Minute},
+		{name: "timeout", pollsUntilReady: 1000, pollTimeout: 50 * time.Millisecond, expectedError: "still processing"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gets int
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == http.MethodGet {
+					gets++
+				}
+				status := OverlayStatusReady
+				if gets < tt.pollsUntilReady {
+					status = OverlayStatusProcessing
+				}
+				if tt.pollsUntilReady == 0 {
+					status = ""
+				}
+				fmt.Fprintf(w, `{"id":"ov-1","name":"foo","status":%q}`, status)
+			}))
+			defer server.Close()
+
+			c := NewClient(server.URL, "token")
+			c.PollInterval = time.Millisecond
+			c.PollTimeout = tt.pollTimeout
+			c.ReadCacheTTL = tt.readCacheTTL
+
+			overlay, err := c.CreateOverlay(context.Background(), OverlayPayload{Name: "foo"})
+			if tt.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+					t.Fatalf("expected an error containing %q, got %v", tt.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CreateOverlay: %s", err)
+			}
+			if overlay.Status == OverlayStatusProcessing {
+				t.Error("returned an overlay that is still processing")
+			}
+			if gets != tt.pollsUntilReady {
+				t.Errorf("polled %d times, want %d", gets, tt.pollsUntilReady)
+			}
+		})
+	}
+}
+
+func TestUpdateOverlay_ClearsDescription(t *testing.T) {
+	var payload map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("decoding payload: %s", err)
+		}
+		w.Write([]byte(`{"id":"ov-1","name":"foo"}`))
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, "token")
+	if _, err := c.UpdateOverlay(context.Background(), "ov-1", OverlayPayload{Name: "foo", Description: ""}, Precondition{}); err != nil {
+		t.Fatalf("UpdateOverlay: %s", err)
+	}
+
+	// Omitting it would leave the previous description on the server
+	if description, ok := payload["description"]; !ok || description != "" {
+		t.Errorf("payload description = %v (sent: %t), want an empty string", description, ok)
+	}
+}
+
+func TestUpdateOverlay_PreconditionFailed(t *testing.T) {
+	var ifMatch string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ifMatch = r.Header.Get("If-Match")
+		w.WriteHeader(http.StatusPreconditionFailed)
+		w.Write([]byte(`{"message": "version mismatch"}`))
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, "token")
+	_, err := c.UpdateOverlay(context.Background(), "ov-1", OverlayPayload{Name: "foo"}, Precondition{Version: `"3"`})
+	if !IsPreconditionFailed(err) {
+		t.Fatalf("expected precondition failed error, got %v", err)
+	}
+	if IsNotFound(err) {
+		t.Error("412 should not be reported as not found")
+	}
+	if ifMatch != `"3"` {
+		t.Errorf("If-Match = %q, want %q", ifMatch, `"3"`)
+	}
+}
+
+func TestUpdateOverlay_WeakETag(t *testing.T) {
+	var ifMatch []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `W/"1"`)
+		if r.Method == http.MethodPatch {
+			ifMatch = r.Header.Values("If-Match")
+			if len(ifMatch) > 0 {
+				// Strong comparison never matches a weak ETag
+				w.WriteHeader(http.StatusPreconditionFailed)
+				w.Write([]byte(`{"message": "version mismatch"}`))
+				return
+			}
+		}
+		w.Write([]byte(`{"id": "ov-1", "name": "foo"}`))
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, "token")
+	overlay, err := c.GetOverlay(context.Background(), "ov-1")
+	if err != nil {
+		t.Fatalf("GetOverlay: %s", err)
+	}
+	if overlay.Version != `W/"1"` {
+		t.Fatalf("Version = %q, want %q", overlay.Version, `W/"1"`)
+	}
+
+	if _, err := c.UpdateOverlay(context.Background(), "ov-1", OverlayPayload{Name: "foo"}, Precondition{Version: overlay.Version}); err != nil {
+		t.Fatalf("UpdateOverlay: %s", err)
+	}
+	if len(ifMatch) != 0 {
+		t.Errorf("If-Match = %q, want none for a weak ETag", ifMatch)
+	}
+}
+
+func TestGetOverlayByName_NotFound(t *testing.T) {
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		w.Write([]byte(`{"data":[{"id":"ov-1","name":"foo"}]}`))
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, "token")
+	c.ListCacheTTL = 0
+
+	_, err := c.GetOverlayByName(context.Background(), "bar")
+	var notFoundErr *NotFoundError
+	if !errors.As(err, &notFoundErr) || notFoundErr.Name != "bar" {
+		t.Fatalf("expected a NotFoundError for bar, got %v", err)
+	}
+	if !IsNotFound(err) {
+		t.Error("expected IsNotFound to report a missing name")
+	}
+
+	// A failing listing is not a missing name
+	status = http.StatusInternalServerError
+	_, err = c.GetOverlayByName(context.Background(), "bar")
+	if err == nil || IsNotFound(err) {
+		t.Errorf("expected a server error, got %v", err)
+	}
+}
+
+func TestGetOverlayByOrganizationAndName(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":[{"id":"ov-1","name":"sales","organizationId":"org-1"},{"id":"ov-2","name":"sales","organizationId":"org-2"}]}`))
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, "token")
+
+	for _, tt := range []struct{ organizationID, expectedID string }{{"org-1", "ov-1"}, {"org-2", "ov-2"}} {
+		overlay, err := c.GetOverlayByOrganizationAndName(context.Background(), tt.organizationID, "sales")
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %s", tt.organizationID, err)
+		}
+		if overlay.ID != tt.expectedID {
+			t.Errorf("%s: ID = %q, want %q", tt.organizationID, overlay.ID, tt.expectedID)
+		}
+	}
+
+	_, err := c.GetOverlayByOrganizationAndName(context.Background(), "org-3", "sales")
+	var notFoundErr *NotFoundError
+	if !errors.As(err, &notFoundErr) || notFoundErr.OrganizationID != "org-3" {
+		t.Fatalf("expected a NotFoundError for org-3, got %v", err)
+	}
+}
+
+func TestPreconditionHeader(t *testing.T) {
+	tests := []struct {
+		name             string
+		concurrencyCheck bool
+		precondition     Precondition
+		expected         http.Header
+	}{
+		{
+			name:     "none",
+			expected: nil,
+		},
+		{
+			name:         "version",
+			precondition: Precondition{Version: "3", UpdatedAt: "2024-01-02T03:04:05Z"},
+			expected:     http.Header{"If-Match": {`"3"`}},
+		},
+		{
+			name:             "updated_at",
+			concurrencyCheck: true,
+			precondition:     Precondition{UpdatedAt: "2024-01-02T03:04:05+01:00"},
+			expected:         http.Header{"If-Unmodified-Since": {"Tue, 02 Jan 2024 02:04:05 GMT"}},
+		},
+		{
+			name:             "both",
+			concurrencyCheck: true,
+			precondition:     Precondition{Version: "3", UpdatedAt: "2024-01-02T03:04:05.123Z"},
+			expected:         http.Header{"If-Match": {`"3"`}, "If-Unmodified-Since": {"Tue, 02 Jan 2024 03:04:05 GMT"}},
+		},
+		{
+			name:             "unparseable updated_at",
+			concurrencyCheck: true,
+			precondition:     Precondition{UpdatedAt: "yesterday"},
+			expected:         nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Client{ConcurrencyCheck: tt.concurrencyCheck}
+			if got := c.preconditionHeader(context.Background(), tt.precondition); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("preconditionHeader() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestDeleteOverlay_StaleUpdatedAt(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		since, err := http.ParseTime(r.Header.Get("If-Unmodified-Since"))
+		if err != nil || since.Before(time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)) {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			w.Write([]byte(`{"message":"stale"}`))
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, "token")
+	c.ConcurrencyCheck = true
+
+	err := c.DeleteOverlay(context.Background(), "ov-1", Precondition{UpdatedAt: "2024-01-01T00:00:00Z"})
+	if !IsPreconditionFailed(err) {
+		t.Errorf("expected precondition failed error, got %v", err)
+	}
+	if err := c.DeleteOverlay(context.Background(), "ov-1", Precondition{UpdatedAt: "2024-06-01T00:00:00Z"}); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+}
+
+func TestDeleteOverlays(t *testing.T) {
+	var mu sync.Mutex
+	var deleted []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := strings.TrimPrefix(r.URL.Path, "/cube-overlays/")
+		switch id {
+		case "ov-2":
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(`{"message":"boom"}`))
+		case "ov-4":
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"message":"forbidden"}`))
+		case "ov-gone":
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			mu.Lock()
+			deleted = append(deleted, id)
+			mu.Unlock()
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, "token")
+
+	if err := c.DeleteOverlays(context.Background(), []string{"ov-1", "ov-gone"}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	deleted = nil
+	err := c.DeleteOverlays(context.Background(), []string{"ov-1", "ov-2", "ov-3", "ov-4", "ov-gone"})
+	var batchErr *BatchDeleteError
+	if !errors.As(err, &batchErr) {
+		t.Fatalf("expected a *BatchDeleteError, got %v", err)
+	}
+	if !reflect.DeepEqual(deleted, []string{"ov-1", "ov-3"}) {
+		t.Errorf("DELETE requests succeeded for %v, want all but the failures attempted", deleted)
+	}
+	if !reflect.DeepEqual(batchErr.Deleted, []string{"ov-1", "ov-3", "ov-gone"}) {
+		t.Errorf("Deleted = %v", batchErr.Deleted)
+	}
+	if len(batchErr.Failed) != 2 || batchErr.Failed["ov-2"] == nil || batchErr.Failed["ov-4"] == nil {
+		t.Errorf("Failed = %v, want ov-2 and ov-4", batchErr.Failed)
+	}
+	for _, want := range []string{"failed to delete 2 of 5 overlays", "ov-2: API error 500", "ov-4: API error 403", "deleted: ov-1, ov-3, ov-gone"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q doesn't contain %q", err, want)
+		}
+	}
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Error("expected the API errors to be unwrappable")
+	}
+}
+
+func TestRequest_MaxResponseBytes(t *testing.T) {
+	body := `{"id": "ov-1", "name": "` + strings.Repeat("x", 100) + `"}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name        string
+		limit       int64
+		expectError bool
+	}{
+		{name: "default limit", limit: 0},
+		{name: "exactly at limit", limit: int64(len(body))},
+		{name: "one byte over limit", limit: int64(len(body)) - 1, expectError: true},
+		{name: "far over limit", limit: 10, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewClient(server.URL, "token")
+			if tt.limit > 0 {
+				c.MaxResponseBytes = tt.limit
+			}
+
+			_, err := c.GetOverlay(context.Background(), "ov-1")
+			if tt.expectError {
+				if err == nil || !strings.Contains(err.Error(), "exceeds the maximum") {
+					t.Fatalf("expected size limit error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+		})
+	}
+}
+
+func TestRequest_Retry(t *testing.T) {
+	tests := []struct {
+		name             string
+		status           func(request int32) int
+		create           bool
+		expectError      bool
+		expectedRequests int32
+	}{
+		{
+			name: "transient failures",
+			status: func(request int32) int {
+				if request <= 2 {
+					return http.StatusServiceUnavailable
+				}
+				return http.StatusOK
+			},
+			expectedRequests: 3,
+		},
+		{
+			name:             "retries exhausted",
+			status:           func(int32) int { return http.StatusBadGateway },
+			expectError:      true,
+			expectedRequests: 4,
+		},
+		{
+			name:             "client errors aren't retried",
+			status:           func(int32) int { return http.StatusBadRequest },
+			expectError:      true,
+			expectedRequests: 1,
+		},
+		{
+			name:             "non-idempotent requests aren't retried",
+			status:           func(int32) int { return http.StatusServiceUnavailable },
+			create:           true,
+			expectError:      true,
+			expectedRequests: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status(requests.Add(1)))
+				w.Write([]byte(`{"id":"ov-1","name":"foo"}`))
+			}))
+			defer server.Close()
+
+			c := NewClient(server.URL, "token")
+			c.MaxRetries = 3
+			c.RetryWaitMin = time.Millisecond
+
+			var err error
+			if tt.create {
+				_, err = c.CreateOverlay(context.Background(), OverlayPayload{Name: "foo"})
+			} else {
+				_, err = c.GetOverlay(context.Background(), "ov-1")
+			}
+			if tt.expectError != (err != nil) {
+				t.Errorf("error = %v, want error: %t", err, tt.expectError)
+			}
+			if got := requests.Load(); got != tt.expectedRequests {
+				t.Errorf("requests = %d, want %d", got, tt.expectedRequests)
+			}
+		})
+	}
+}
+
+func TestRequest_RetryPost(t *testing.T) {
+	tests := []struct {
+		name             string
+		method           string
+		idempotencyKey   string
+		expectedRequests int32
+	}{
+		{name: "POST without idempotency key", method: http.MethodPost, expectedRequests: 1},
+		{name: "POST with idempotency key", method: http.MethodPost, idempotencyKey: "create-1", expectedRequests: 3},
+		{name: "PATCH", method: http.MethodPatch, expectedRequests: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The API may process the request before the client times out
+			var requests atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests.Add(1)
+				// Read the body, so the server notices when the client gives up
+				io.Copy(io.Discard, r.Body)
+				select {
+				case <-r.Context().Done():
+				case <-time.After(time.Second):
+				}
+			}))
+			defer server.Close()
+
+			c := NewClient(server.URL, "token")
+			c.MaxRetries = 2
+			c.RetryWaitMin = time.Millisecond
+			c.RequestTimeout = 20 * time.Millisecond
+
+			var header http.Header
+			if tt.idempotencyKey != "" {
+				header = http.Header{IdempotencyKeyHeader: []string{tt.idempotencyKey}}
+			}
+			_, _, err := c.requestWithHeaders(context.Background(), tt.method, "/cube-overlays", OverlayPayload{Name: "foo"}, header)
+			if err == nil {
+				t.Fatal("expected a timeout error")
+			}
+			if got := requests.Load(); got != tt.expectedRequests {
+				t.Errorf("requests = %d, want %d", got, tt.expectedRequests)
+			}
+		})
+	}
+
+	t.Run("POST that failed to connect", func(t *testing.T) {
+		var dials atomic.Int32
+		c := NewClient("http://revos.invalid", "token")
+		c.MaxRetries = 2
+		c.RetryWaitMin = time.Millisecond
+		c.HTTPClient.Transport = &http.Transport{
+			DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+				dials.Add(1)
+				return nil, &net.OpError{Op: "dial", Net: network, Err: errors.New("connection refused")}
+			},
+		}
+
+		if _, err := c.CreateOverlay(context.Background(), OverlayPayload{Name: "foo"}); err == nil {
+			t.Fatal("expected a connection error")
+		}
+		if got := dials.Load(); got != 3 {
+			t.Errorf("connection attempts = %d, want 3", got)
+		}
+	})
+}
+
+func TestRequest_RetryMaxElapsedTime(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, "token")
+	c.MaxRetries = 100
+	c.RetryWaitMin = 20 * time.Millisecond
+	c.RetryMaxElapsedTime = 200 * time.Millisecond
+
+	// Waits of 20, 40 and 80ms fit in the budget; the next 160ms doesn't
+	start := time.Now()
+	_, err := c.GetOverlay(context.Background(), "ov-1")
+	elapsed := time.Since(start)
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("expected the last 503 error, got %v", err)
+	}
+	if got := requests.Load(); got != 4 {
+		t.Errorf("requests = %d, want 4", got)
+	}
+	if elapsed > c.RetryMaxElapsedTime {
+		t.Errorf("request took %s, longer than the %s budget", elapsed, c.RetryMaxElapsedTime)
+	}
+}
+
+func TestRequest_CustomHeaders(t *testing.T) {
+	var got http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		w.Write([]byte(`{"id":"ov-1","name":"foo"}`))
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, "secret")
+	c.Headers = map[string]string{
+		"X-Tenant":            "acme",
+		"CF-Access-Client-Id": "client-id",
+		"authorization":       "Bearer stolen",
+		"Content-Type":        "text/plain",
+		"X-Request-ID":        "fixed",
+	}
+
+	if _, err := c.CreateOverlay(context.Background(), OverlayPayload{Name: "foo"}); err != nil {
+		t.Fatalf("CreateOverlay: %s", err)
+	}
+
+	expected := map[string]string{
+		"X-Tenant":            "acme",
+		"Cf-Access-Client-Id": "client-id",
+		"Authorization":       "Bearer secret",
+		"Content-Type":        "application/json",
+	}
+	for name, want := range expected {
+		if v := got.Get(name); v != want {
+			t.Errorf("%s = %q, want %q", name, v, want)
+		}
+	}
+	if v := got.Get("X-Request-ID"); v == "fixed" {
+		t.Error("custom headers replaced the request ID")
+	}
+}
+
+func TestRequest_ReadOnly(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method)
+		w.Write([]byte(`{"id":"ov-1","name":"foo"}`))
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	c := NewClient(server.URL, "token")
+	c.ReadOnly = true
+
+	if _, err := c.GetOverlay(ctx, "ov-1"); err != nil {
+		t.Fatalf("GetOverlay: %s", err)
+	}
+
+	writes := map[string]func() error{
+		"POST": func() error {
+			_, err := c.CreateOverlay(ctx, OverlayPayload{Name: "foo"})
+			return err
+		},
+		"PATCH": func() error {
+			_, err := c.UpdateOverlay(ctx, "ov-1", OverlayPayload{Name: "foo"}, Precondition{})
+			return err
+		},
+		"DELETE": func() error {
+			return c.DeleteOverlay(ctx, "ov-1", Precondition{})
+		},
+	}
+	for method, write := range writes {
+		t.Run(method, func(t *testing.T) {
+			err := write()
+			var readOnlyErr *ReadOnlyError
+			if !errors.As(err, &readOnlyErr) || readOnlyErr.Method != method {
+				t.Errorf("expected a read-only error for %s, got %v", method, err)
+			}
+		})
+	}
+
+	if fmt.Sprint(requests) != "[GET]" {
+		t.Errorf("requests = %v, want only the GET to reach the API", requests)
+	}
+}
+
+func TestRequest_NonJSONErrorBody(t *testing.T) {
+	page := "<!DOCTYPE html>\n<html>\n<head><title>502 Bad Gateway</title></head>\n<body>\n" +
+		strings.Repeat("<p>The upstream server is unavailable.</p>\n", 200) + "</body>\n</html>\n"
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		expected    string
+		maxLength   int
+	}{
+		{
+			name:        "HTML page",
+			contentType: "text/html; charset=utf-8",
+			body:        page,
+			expected:    "<!DOCTYPE html> <html> <head><title>502 Bad Gateway</title></head>",
+		},
+		{
+			name:        "truncation note",
+			contentType: "text/html; charset=utf-8",
+			body:        page,
+			expected:    fmt.Sprintf("... (truncated %d-byte text/html response)", len(page)),
+			maxLength:   maxErrorBodyLength + 200,
+		},
+		{
+			name:        "short plain text",
+			contentType: "text/plain",
+			body:        "upstream\n  connect error\n",
+			expected:    "API error 502: upstream connect error",
+		},
+		{
+			name:        "JSON is kept",
+			contentType: "application/problem+json",
+			body:        `{"title": "Bad Gateway",` + "\n" + `"detail": "` + strings.Repeat("x", 600) + `"}`,
+			expected:    strings.Repeat("x", 600),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", tt.contentType)
+				w.WriteHeader(http.StatusBadGateway)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			c := NewClient(server.URL, "token")
+			_, err := c.GetOverlay(context.Background(), "ov-1")
+
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadGateway {
+				t.Fatalf("expected a 502 APIError, got %v", err)
+			}
+			if !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("error doesn't contain %q:\n%s", tt.expected, err)
+			}
+			if tt.maxLength > 0 && len(err.Error()) > tt.maxLength {
+				t.Errorf("error is %d bytes long, want at most %d:\n%s", len(err.Error()), tt.maxLength, err)
+			}
+		})
+	}
+}
+
+func TestRequest_Timeout(t *testing.T) {
+	// The server sends the headers and part of the body, then stalls
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id":"ov-1",`))
+		w.(http.Flusher).Flush()
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	tests := []struct {
+		name           string
+		requestTimeout time.Duration
+		callerTimeout  time.Duration
+	}{
+		{name: "request timeout within caller deadline", requestTimeout: 50 * time.Millisecond, callerTimeout: time.Minute},
+		{name: "caller deadline within request timeout", requestTimeout: time.Minute, callerTimeout: 50 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewClient(server.URL, "secret")
+			c.RequestTimeout = tt.requestTimeout
+
+			ctx, cancel := context.WithTimeout(context.Background(), tt.callerTimeout)
+			defer cancel()
+
+			start := time.Now()
+			_, err := c.GetOverlay(ctx, "ov-1")
+			if err == nil || !strings.Contains(err.Error(), "deadline exceeded") {
+				t.Fatalf("expected a deadline error, got %v", err)
+			}
+			if elapsed := time.Since(start); elapsed > 10*time.Second {
+				t.Errorf("request took %s, want the shorter timeout to apply", elapsed)
+			}
+		})
+	}
+
+	t.Run("fresh deadline per request", func(t *testing.T) {
+		fast := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(20 * time.Millisecond)
+			w.Write([]byte(`{"id":"ov-1","name":"foo"}`))
+		}))
+		defer fast.Close()
+
+		c := NewClient(fast.URL, "secret")
+		c.RequestTimeout = 100 * time.Millisecond
+
+		// Together the requests outlast the timeout, but each fits within it
+		ctx := context.Background()
+		for i := 0; i < 8; i++ {
+			if _, err := c.GetOverlay(ctx, "ov-1"); err != nil {
+				t.Fatalf("request %d: %s", i, err)
+			}
+		}
+	})
+}
+
+func TestRequest_Compression(t *testing.T) {
+	large := json.RawMessage(`{"sql":"` + strings.Repeat("select 1 union all ", CompressionThreshold/10) + `"}`)
+
+	tests := []struct {
+		name              string
+		compress          bool
+		data              json.RawMessage
+		expectGzipRequest bool
+	}{
+		{name: "disabled", compress: false, data: large},
+		{name: "enabled, small body", compress: true, data: json.RawMessage(`{"a":1}`)},
+		{name: "enabled, large body", compress: true, data: large, expectGzipRequest: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var reqBody io.Reader = r.Body
+				gotGzip := r.Header.Get("Content-Encoding") == "gzip"
+				if gotGzip != tt.expectGzipRequest {
+					t.Errorf("request Content-Encoding gzip = %v, want %v", gotGzip, tt.expectGzipRequest)
+				}
+				if gotGzip {
+					zr, err := gzip.NewReader(r.Body)
+					if err != nil {
+						t.Fatalf("request body is not gzip: %s", err)
+					}
+					reqBody = zr
+				}
+
+				var payload OverlayPayload
+				if err := json.NewDecoder(reqBody).Decode(&payload); err != nil {
+					t.Fatalf("failed to decode request: %s", err)
+				}
+
+				overlay, _ := json.Marshal(CubeOverlay{ID: "ov-1", Name: payload.Name, Data: payload.Data})
+				if !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+					t.Errorf("expected the transport to request gzip responses")
+					w.Write(overlay)
+					return
+				}
+				w.Header().Set("Content-Encoding", "gzip")
+				zw := gzip.NewWriter(w)
+				zw.Write(overlay)
+				zw.Close()
+			}))
+			defer server.Close()
+
+			c := NewClient(server.URL, "token")
+			c.CompressRequests = tt.compress
+
+			overlay, err := c.CreateOverlay(context.Background(), OverlayPayload{Name: "foo", Data: tt.data})
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if overlay.Name != "foo" || !bytes.Equal(overlay.Data, tt.data) {
+				t.Errorf("round trip mismatch: %+v", overlay)
+			}
+		})
+	}
+}
+
+func TestRequest_RequestID(t *testing.T) {
+	tests := []struct {
+		name         string
+		echoHeader   string
+		echoID       string
+		expectEchoed bool
+	}{
+		{
+			name: "generated ID",
+		},
+		{
+			name:         "echoed request ID",
+			echoHeader:   "X-Request-ID",
+			echoID:       "srv-123",
+			expectEchoed: true,
+		},
+		{
+			name:         "echoed trace ID",
+			echoHeader:   "X-Trace-ID",
+			echoID:       "trace-456",
+			expectEchoed: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sent string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				sent = r.Header.Get("X-Request-ID")
+				if tt.echoHeader != "" {
+					w.Header().Set(tt.echoHeader, tt.echoID)
+				}
+				w.WriteHeader(http.StatusInternalServerError)
+				w.Write([]byte(`{"message":"boom"}`))
+			}))
+			defer server.Close()
+
+			c := NewClient(server.URL, "secret")
+			_, err := c.GetOverlay(context.Background(), "ov-1")
+
+			if sent == "" {
+				t.Fatal("expected an X-Request-ID header")
+			}
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("expected an APIError, got %v", err)
+			}
+
+			want := sent
+			if tt.expectEchoed {
+				want = tt.echoID
+			}
+			if apiErr.RequestID != want {
+				t.Errorf("RequestID = %q, want %q", apiErr.RequestID, want)
+			}
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("error %q does not contain the request ID %q", err, want)
+			}
+		})
+	}
+}
+
+func TestRequest_RequestIDUnique(t *testing.T) {
+	seen := map[string]bool{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen[r.Header.Get("X-Request-ID")] = true
+		w.Write([]byte(`[]`))
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, "secret")
+	c.ListCacheTTL = 0
+	for i := 0; i < 3; i++ {
+		if _, err := c.ListOverlays(context.Background()); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+	if len(seen) != 3 {
+		t.Errorf("expected 3 distinct request IDs, got %v", seen)
+	}
+}
+
+type observedRequest struct {
+	method, path string
+	status       int
+	dur          time.Duration
+}
+
+type recordingObserver struct {
+	requests []observedRequest
+}
+
+func (o *recordingObserver) ObserveRequest(method, path string, status int, dur time.Duration) {
+	o.requests = append(o.requests, observedRequest{method: method, path: path, status: status, dur: dur})
+}
+
+func TestRequest_Observer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(5 * time.Millisecond)
+		if r.Method == http.MethodDelete {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"id":"ov-1","name":"foo"}`))
+	}))
+
+	observer := &recordingObserver{}
+	c := NewClient(server.URL, "token")
+	c.Observer = observer
+
+	ctx := context.Background()
+	if _, err := c.GetOverlay(ctx, "ov-1"); err != nil {
+		t.Fatalf("GetOverlay: %s", err)
+	}
+	if err := c.DeleteOverlay(ctx, "ov-1", Precondition{}); !IsNotFound(err) {
+		t.Fatalf("expected a not found error, got %v", err)
+	}
+	server.Close()
+	if _, err := c.GetOverlay(ctx, "ov-1"); err == nil {
+		t.Fatal("expected an error from a closed server")
+	}
+
+	expected := []observedRequest{
+		{method: "GET", path: "/cube-overlays/ov-1", status: http.StatusOK},
+		{method: "DELETE", path: "/cube-overlays/ov-1", status: http.StatusNotFound},
+		{method: "GET", path: "/cube-overlays/ov-1", status: 0},
+	}
+	if len(observer.requests) != len(expected) {
+		t.Fatalf("observed %d requests, want %d: %+v", len(observer.requests), len(expected), observer.requests)
+	}
+	for i, want := range expected {
+		got := observer.requests[i]
+		if got.method != want.method || got.path != want.path || got.status != want.status {
+			t.Errorf("request %d = %s %s %d, want %s %s %d", i, got.method, got.path, got.status, want.method, want.path, want.status)
+		}
+		if want.status != 0 && got.dur < 5*time.Millisecond {
+			t.Errorf("request %d duration = %s, want at least 5ms", i, got.dur)
+		}
+	}
+}
+
+func TestListOverlays_Cache(t *testing.T) {
+	lists := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			lists++
+			w.Write([]byte(`{"data":[{"id":"ov-1","name":"foo"},{"id":"ov-2","name":"bar"}]}`))
+		default:
+			w.Write([]byte(`{"data":{"id":"ov-3","name":"baz"}}`))
+		}
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	c := NewClient(server.URL, "secret")
+
+	// Back to back name lookups share one listing
+	for _, name := range []string{"foo", "bar"} {
+		if _, err := c.GetOverlayByName(ctx, name); err != nil {
+			t.Fatalf("GetOverlayByName(%q): %s", name, err)
+		}
+	}
+	if lists != 1 {
+		t.Errorf("list requests = %d, want 1", lists)
+	}
+
+	// Writes invalidate the cache
+	if _, err := c.CreateOverlay(ctx, OverlayPayload{Name: "baz"}); err != nil {
+		t.Fatalf("CreateOverlay: %s", err)
+	}
+	if _, err := c.GetOverlayByName(ctx, "foo"); err != nil {
+		t.Fatalf("GetOverlayByName: %s", err)
+	}
+	if lists != 2 {
+		t.Errorf("list requests after create = %d, want 2", lists)
+	}
+
+	// So does expiry
+	c.listCache.fetchedAt = time.Now().Add(-c.ListCacheTTL)
+	if _, err := c.ListOverlays(ctx); err != nil {
+		t.Fatalf("ListOverlays: %s", err)
+	}
+	if lists != 3 {
+		t.Errorf("list requests after expiry = %d, want 3", lists)
+	}
+
+	// Callers can't modify the cached listing
+	overlays, _ := c.ListOverlays(ctx)
+	overlays[0].Name = "changed"
+	if overlay, err := c.GetOverlayByName(ctx, "foo"); err != nil || overlay.ID != "ov-1" {
+		t.Errorf("cached listing was modified: %v, %v", overlay, err)
+	}
+}
+
+func TestListOverlays_Concurrent(t *testing.T) {
+	var lists atomic.Int32
+	started := make(chan struct{})
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if lists.Add(1) == 1 {
+			close(started)
+		}
+		<-release
+		w.Write([]byte(`{"data":[{"id":"ov-1","name":"foo"}]}`))
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	c := NewClient(server.URL, "secret")
+
+	const callers = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			overlays, err := c.ListOverlays(ctx)
+			if err == nil && len(overlays) != 1 {
+				err = fmt.Errorf("got %d overlays, want 1", len(overlays))
+			}
+			errs <- err
+		}()
+	}
+
+	// Hold the first request until the other callers have had time to join it
+	<-started
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Errorf("ListOverlays: %s", err)
+		}
+	}
+	if got := lists.Load(); got != 1 {
+		t.Errorf("list requests = %d, want 1", got)
+	}
+}
+
+func TestListOverlays_ConcurrentFirstCallerCancelled(t *testing.T) {
+	var lists atomic.Int32
+	started := make(chan struct{})
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if lists.Add(1) == 1 {
+			close(started)
+		}
+		select {
+		case <-release:
+		case <-r.Context().Done():
+			return
+		}
+		w.Write([]byte(`{"data":[{"id":"ov-1","name":"foo"}]}`))
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, "secret")
+
+	firstCtx, cancelFirst := context.WithCancel(context.Background())
+	first := make(chan error)
+	go func() {
+		_, err := c.ListOverlays(firstCtx)
+		first <- err
+	}()
+	<-started
+
+	second := make(chan error)
+	go func() {
+		overlays, err := c.ListOverlays(context.Background())
+		if err == nil && len(overlays) != 1 {
+			err = fmt.Errorf("got %d overlays, want 1", len(overlays))
+		}
+		second <- err
+	}()
+
+	// The first caller gives up while the second waits on its request
+	time.Sleep(50 * time.Millisecond)
+	cancelFirst()
+	if err := <-first; !errors.Is(err, context.Canceled) {
+		t.Errorf("first caller: expected context.Canceled, got %v", err)
+	}
+	close(release)
+	if err := <-second; err != nil {
+		t.Errorf("second caller: %s", err)
+	}
+	if got := lists.Load(); got != 1 {
+		t.Errorf("list requests = %d, want 1", got)
+	}
+}
+
+func TestListOverlays_InvalidateDuringList(t *testing.T) {
+	release := make(chan struct{})
+	var lists atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if lists.Add(1) == 1 {
+			<-release
+		}
+		w.Write([]byte(`{"data":[{"id":"ov-1","name":"foo"}]}`))
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	c := NewClient(server.URL, "secret")
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		c.ListOverlays(ctx)
+	}()
+	for lists.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// A write while the listing is in flight keeps it out of the cache
+	c.listCache.invalidate()
+	close(release)
+	<-done
+
+	if _, err := c.ListOverlays(ctx); err != nil {
+		t.Fatalf("ListOverlays: %s", err)
+	}
+	if got := lists.Load(); got != 2 {
+		t.Errorf("list requests = %d, want 2", got)
+	}
+}
+
+func TestListOverlays_Archived(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("includeArchived") != "true" {
+			t.Errorf("expected the listing to include archived overlays, got %s", r.URL)
+		}
+		w.Write([]byte(`{"data":[{"id":"ov-1","name":"old","archived":true},{"id":"ov-2","name":"reused","archived":true},{"id":"ov-3","name":"reused"}]}`))
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	c := NewClient(server.URL, "secret")
+
+	overlays, err := c.ListOverlays(ctx)
+	if err != nil {
+		t.Fatalf("ListOverlays: %s", err)
+	}
+	if len(overlays) != 1 || overlays[0].ID != "ov-3" {
+		t.Errorf("ListOverlays = %v, want only ov-3", overlays)
+	}
+
+	for name, want := range map[string]string{"old": "ov-1", "reused": "ov-3"} {
+		overlay, err := c.GetOverlayByName(ctx, name)
+		if err != nil {
+			t.Fatalf("GetOverlayByName(%q): %s", name, err)
+		}
+		if overlay.ID != want {
+			t.Errorf("GetOverlayByName(%q) = %s, want %s", name, overlay.ID, want)
+		}
+	}
+}
+
+func TestRequest_Redirects(t *testing.T) {
+	tests := []struct {
+		name          string
+		scheme        string
+		crossHost     bool
+		allow         bool
+		expectedError string
+	}{
+		{name: "same host bearer", scheme: AuthSchemeBearer},
+		{name: "same host api key", scheme: AuthSchemeAPIKey},
+		{name: "cross host refused", scheme: AuthSchemeBearer, crossHost: true, expectedError: "allow_cross_host_redirect"},
+		{name: "cross host api key refused", scheme: AuthSchemeAPIKey, crossHost: true, expectedError: "allow_cross_host_redirect"},
+		{name: "cross host allowed", scheme: AuthSchemeBearer, crossHost: true, allow: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got http.Header
+			target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = r.Header.Clone()
+				w.Write([]byte(`{"data":{"id":"ov-1"}}`))
+			}))
+			defer target.Close()
+
+			origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/moved/cube-overlays/ov-1" {
+					got = r.Header.Clone()
+					w.Write([]byte(`{"data":{"id":"ov-1"}}`))
+					return
+				}
+				location := "/moved" + r.URL.Path
+				if tt.crossHost {
+					location = target.URL + r.URL.Path
+				}
+				http.Redirect(w, r, location, http.StatusTemporaryRedirect)
+			}))
+			defer origin.Close()
+
+			c := NewClient(origin.URL, "secret")
+			c.AuthScheme = tt.scheme
+			c.AllowCrossHostRedirect = tt.allow
+
+			_, err := c.GetOverlay(context.Background(), "ov-1")
+			if tt.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+					t.Fatalf("error = %v, want it to contain %q", err, tt.expectedError)
+				}
+				if got != nil {
+					t.Error("expected the redirect target not to be requested")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			switch tt.scheme {
+			case AuthSchemeAPIKey:
+				if key := got.Get("X-API-Key"); key != "secret" {
+					t.Errorf("X-API-Key after redirect = %q, want %q", key, "secret")
+				}
+			default:
+				if auth := got.Get("Authorization"); auth != "Bearer secret" {
+					t.Errorf("Authorization after redirect = %q, want %q", auth, "Bearer secret")
+				}
+			}
+		})
+	}
+}
+
+func TestRequest_CrossHostRedirectNotRetried(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id":"ov-1"}`))
+	}))
+	defer target.Close()
+
+	var requests int
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		http.Redirect(w, r, target.URL+r.URL.Path, http.StatusTemporaryRedirect)
+	}))
+	defer origin.Close()
+
+	c := NewClient(origin.URL, "secret")
+	c.MaxRetries = 3
+
+	_, err := c.GetOverlay(context.Background(), "ov-1")
+	if !errors.Is(err, ErrCrossHostRedirect) {
+		t.Fatalf("error = %v, want ErrCrossHostRedirect", err)
+	}
+	if requests != 1 {
+		t.Errorf("%d requests, want 1", requests)
+	}
+}
+
+func TestRequest_RedirectLoop(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, r.URL.Path, http.StatusFound)
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, "secret")
+	_, err := c.GetOverlay(context.Background(), "ov-1")
+	if err == nil || !strings.Contains(err.Error(), "stopped after 10 redirects") {
+		t.Errorf("error = %v, want a redirect limit error", err)
+	}
+}
+
+func TestListOverlayVersions(t *testing.T) {
+	tests := []struct {
+		name          string
+		status        int
+		body          string
+		expected      int
+		expectedError bool
+	}{
+		{name: "versions", status: http.StatusOK, body: `{"data":[{"versionId":"v1"},{"versionId":"v2"}]}`, expected: 2},
+		{name: "versioning not enabled", status: http.StatusNotFound, body: `{"message":"Not Found"}`, expected: 0},
+		{name: "not implemented", status: http.StatusNotImplemented, body: `{"message":"Not Implemented"}`, expected: 0},
+		{name: "server error", status: http.StatusInternalServerError, body: `{"message":"boom"}`, expectedError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/cube-overlays/ov-1/versions" {
+					t.Errorf("path = %s", r.URL.Path)
+				}
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			versions, err := NewClient(server.URL, "token").ListOverlayVersions(context.Background(), "ov-1")
+			if tt.expectedError {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if versions == nil || len(versions) != tt.expected {
+				t.Errorf("got %v, want %d versions", versions, tt.expected)
+			}
+		})
+	}
+}
+
+func TestLoggableHeaders(t *testing.T) {
+	h := http.Header{}
+	h.Set("X-RateLimit-Remaining", "42")
+	h.Add("Vary", "Accept")
+	h.Add("Vary", "Accept-Encoding")
+	h.Set("Set-Cookie", "session=secret")
+	h.Set("Authorization", "Bearer secret")
+	h.Set("X-API-Key", "secret")
+	h["cookie"] = []string{"session=secret"}
+
+	expected := map[string]interface{}{
+		"X-Ratelimit-Remaining": "42",
+		"Vary":                  "Accept, Accept-Encoding",
+	}
+	if got := loggableHeaders(h); !reflect.DeepEqual(got, expected) {
+		t.Errorf("loggableHeaders() = %v, want %v", got, expected)
+	}
+}
+
+func TestRequest_LogHeaders(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Remaining", "42")
+		w.Header().Set("Set-Cookie", "session=secret")
+		w.Write([]byte(`{"id":"ov-1"}`))
+	}))
+	defer server.Close()
+
+	for _, logHeaders := range []bool{false, true} {
+		var output bytes.Buffer
+		ctx := tflogtest.RootLogger(context.Background(), &output)
+
+		c := NewClient(server.URL, "secret")
+		c.LogHeaders = logHeaders
+		if _, err := c.GetOverlay(ctx, "ov-1"); err != nil {
+			t.Fatalf("GetOverlay: %s", err)
+		}
+
+		logged := output.String()
+		if strings.Contains(logged, "secret") {
+			t.Errorf("log_headers=%t: credentials were logged:\n%s", logHeaders, logged)
+		}
+		if got := strings.Contains(logged, "X-Ratelimit-Remaining"); got != logHeaders {
+			t.Errorf("log_headers=%t: headers logged = %t:\n%s", logHeaders, got, logged)
+		}
+	}
+}
+
+func TestLoggableBody(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		maskedKeys []string
+		expected   string
+	}{
+		{
+			name:       "nested keys",
+			body:       `{"name":"sales","data":{"cubes":[{"sql":"SELECT 1","dbPassword":"hunter2","auth":{"accessToken":"abc"}}]}}`,
+			maskedKeys: DefaultMaskedKeys,
+			expected:   `{"data":{"cubes":[{"auth":{"accessToken":"***"},"dbPassword":"***","sql":"SELECT 1"}]},"name":"sales"}`,
+		},
+		{
+			name:       "objects and numbers",
+			body:       `{"secret":{"a":1},"count":12345678901234567890}`,
+			maskedKeys: DefaultMaskedKeys,
+			expected:   `{"count":12345678901234567890,"secret":"***"}`,
+		},
+		{
+			name:       "custom keys ignore case",
+			body:       `{"password":"kept","connection":{"PIN":"1234"}}`,
+			maskedKeys: []string{"pin"},
+			expected:   `{"connection":{"PIN":"***"},"password":"kept"}`,
+		},
+		{
+			name:       "not JSON",
+			body:       `<html>Bad Gateway</html>`,
+			maskedKeys: DefaultMaskedKeys,
+			expected:   `<html>Bad Gateway</html>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := loggableBody([]byte(tt.body), tt.maskedKeys); got != tt.expected {
+				t.Errorf("loggableBody() = %s, want %s", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRequest_LogBodies(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id":"ov-1","name":"sales","data":{"token":"response-secret","cubes":[]}}`))
+	}))
+	defer server.Close()
+
+	payload := OverlayPayload{Name: "sales", Data: json.RawMessage(`{"password":"request-secret","cubes":[]}`)}
+	for _, logBodies := range []bool{false, true} {
+		var output bytes.Buffer
+		ctx := tflogtest.RootLogger(context.Background(), &output)
+
+		c := NewClient(server.URL, "bearer-secret")
+		c.LogBodies = logBodies
+		if _, err := c.CreateOverlay(ctx, payload); err != nil {
+			t.Fatalf("CreateOverlay: %s", err)
+		}
+
+		logged := output.String()
+		if strings.Contains(logged, "secret") {
+			t.Errorf("log_request_bodies=%t: secrets were logged:\n%s", logBodies, logged)
+		}
+		for _, message := range []string{"API request body", "API response body"} {
+			if got := strings.Contains(logged, message); got != logBodies {
+				t.Errorf("log_request_bodies=%t: %q logged = %t:\n%s", logBodies, message, got, logged)
+			}
+		}
+		if logBodies && !strings.Contains(logged, `\"password\":\"***\"`) {
+			t.Errorf("masked request data not logged:\n%s", logged)
+		}
+	}
+}
+
+func TestRequest_ReadCache(t *testing.T) {
+	var gets atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			gets.Add(1)
+		}
+		w.Write([]byte(`{"id":"ov-1","name":"foo"}`))
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	c := NewClient(server.URL, "secret")
+
+	// Disabled by default
+	for i := 0; i < 2; i++ {
+		if _, err := c.GetOverlay(ctx, "ov-1"); err != nil {
+			t.Fatalf("GetOverlay: %s", err)
+		}
+	}
+	if got := gets.Load(); got != 2 {
+		t.Fatalf("GET requests without a TTL = %d, want 2", got)
+	}
+
+	gets.Store(0)
+	c.ReadCacheTTL = time.Minute
+
+	// A second GET within the TTL is served from the cache
+	for i := 0; i < 2; i++ {
+		overlay, err := c.GetOverlay(ctx, "ov-1")
+		if err != nil || overlay.Name != "foo" {
+			t.Fatalf("GetOverlay: %v, %v", overlay, err)
+		}
+	}
+	if got := gets.Load(); got != 1 {
+		t.Errorf("GET requests = %d, want 1", got)
+	}
+
+	// Other paths are cached separately
+	if _, err := c.GetOverlay(ctx, "ov-2"); err != nil {
+		t.Fatalf("GetOverlay: %s", err)
+	}
+	if got := gets.Load(); got != 2 {
+		t.Errorf("GET requests for another path = %d, want 2", got)
+	}
+
+	// Writes invalidate the cache
+	if err := c.DeleteOverlay(ctx, "ov-3", Precondition{}); err != nil {
+		t.Fatalf("DeleteOverlay: %s", err)
+	}
+	if _, err := c.GetOverlay(ctx, "ov-1"); err != nil {
+		t.Fatalf("GetOverlay: %s", err)
+	}
+	if got := gets.Load(); got != 3 {
+		t.Errorf("GET requests after a write = %d, want 3", got)
+	}
+
+	// So does expiry
+	c.ReadCacheTTL = time.Nanosecond
+	time.Sleep(time.Millisecond)
+	if _, err := c.GetOverlay(ctx, "ov-1"); err != nil {
+		t.Fatalf("GetOverlay: %s", err)
+	}
+	if got := gets.Load(); got != 4 {
+		t.Errorf("GET requests after expiry = %d, want 4", got)
+	}
+}
+
+func TestRequest_ReadCacheErrors(t *testing.T) {
+	var gets atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gets.Add(1)
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error":"not found"}`))
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	c := NewClient(server.URL, "secret")
+	c.ReadCacheTTL = time.Minute
+
+	for i := 0; i < 2; i++ {
+		if _, err := c.GetOverlay(ctx, "ov-1"); !IsNotFound(err) {
+			t.Fatalf("expected a not found error, got %v", err)
+		}
+	}
+	if got := gets.Load(); got != 2 {
+		t.Errorf("GET requests = %d, want errors not to be cached", got)
+	}
+}
+
+func TestRequest_ReadCacheInvalidateDuringGet(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var gets atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet && gets.Add(1) == 1 {
+			close(started)
+			<-release
+		}
+		w.Write([]byte(`{"id":"ov-1","name":"foo"}`))
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	c := NewClient(server.URL, "secret")
+	c.ReadCacheTTL = time.Minute
+
+	done := make(chan error)
+	go func() {
+		_, err := c.GetOverlay(ctx, "ov-1")
+		done <- err
+	}()
+
+	// A write finishing while the GET is in flight makes its response stale
+	<-started
+	if err := c.DeleteOverlay(ctx, "ov-2", Precondition{}); err != nil {
+		t.Fatalf("DeleteOverlay: %s", err)
+	}
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatalf("GetOverlay: %s", err)
+	}
+
+	if _, err := c.GetOverlay(ctx, "ov-1"); err != nil {
+		t.Fatalf("GetOverlay: %s", err)
+	}
+	if got := gets.Load(); got != 2 {
+		t.Errorf("GET requests = %d, want the stale response not to be cached", got)
+	}
+}
+
+func TestRequest_ReadCacheConcurrent(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id":"ov-1","name":"foo"}`))
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	c := NewClient(server.URL, "secret")
+	c.ReadCacheTTL = time.Minute
+
+	const callers = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			var err error
+			if i%5 == 0 {
+				err = c.DeleteOverlay(ctx, "ov-2", Precondition{})
+			} else {
+				var overlay *CubeOverlay
+				overlay, err = c.GetOverlay(ctx, fmt.Sprintf("ov-%d", i%3))
+				if err == nil && overlay.Name != "foo" {
+					err = fmt.Errorf("got name %q, want foo", overlay.Name)
+				}
+			}
+			errs <- err
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestAPIError_FieldErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		body     string
+		expected []FieldError
+	}{
+		{
+			name:   "unprocessable entity",
+			status: http.StatusUnprocessableEntity,
+			body:   `{"errors":[{"path":"cubes.0.name","message":"required"},{"path":"name","message":"too long"}]}`,
+			expected: []FieldError{
+				{Path: "cubes.0.name", Message: "required"},
+				{Path: "name", Message: "too long"},
+			},
+		},
+		{
+			name:     "bad request without path",
+			status:   http.StatusBadRequest,
+			body:     `{"errors":[{"message":"definition is empty"}]}`,
+			expected: []FieldError{{Message: "definition is empty"}},
+		},
+		{name: "other status", status: http.StatusConflict, body: `{"errors":[{"path":"name","message":"taken"}]}`},
+		{name: "plain message", status: http.StatusBadRequest, body: `{"message":"invalid overlay"}`},
+		{name: "string errors", status: http.StatusBadRequest, body: `{"errors":["cubes.0.name is required"]}`},
+		{name: "missing message", status: http.StatusBadRequest, body: `{"errors":[{"path":"name"}]}`},
+		{name: "not JSON", status: http.StatusBadRequest, body: `Bad Request`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := &APIError{StatusCode: tt.status, Body: tt.body}
+			if got := err.FieldErrors(); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("FieldErrors() = %#v, want %#v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestUpdateOverlay_UpdateMethod(t *testing.T) {
+	var methods []string
+	var payload map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		methods = append(methods, r.Method)
+		if r.Method == http.MethodGet {
+			w.Write([]byte(`{"id":"ov-1","name":"foo","enabled":false}`))
+			return
+		}
+		payload = nil
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("decoding payload: %s", err)
+		}
+		w.Write([]byte(`{"id":"ov-1","name":"foo"}`))
+	}))
+	defer server.Close()
+
+	enabled := true
+	tests := []struct {
+		name            string
+		updateMethod    string
+		enabled         *bool
+		expectedMethods []string
+		expectedEnabled interface{}
+	}{
+		{name: "default", expectedMethods: []string{"PATCH"}},
+		{name: "patch", updateMethod: UpdateMethodPatch, expectedMethods: []string{"PATCH"}},
+		{name: "put", updateMethod: UpdateMethodPut, enabled: &enabled, expectedMethods: []string{"PUT"}, expectedEnabled: true},
+		{name: "put keeps activation", updateMethod: UpdateMethodPut, expectedMethods: []string{"GET", "PUT"}, expectedEnabled: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			methods = nil
+			c := NewClient(server.URL, "token")
+			c.UpdateMethod = tt.updateMethod
+
+			_, err := c.UpdateOverlay(context.Background(), "ov-1", OverlayPayload{Name: "foo", Data: json.RawMessage(`{}`), Enabled: tt.enabled}, Precondition{})
+			if err != nil {
+				t.Fatalf("UpdateOverlay: %s", err)
+			}
+			if !reflect.DeepEqual(methods, tt.expectedMethods) {
+				t.Errorf("methods = %v, want %v", methods, tt.expectedMethods)
+			}
+			if payload["enabled"] != tt.expectedEnabled {
+				t.Errorf("payload enabled = %v, want %v", payload["enabled"], tt.expectedEnabled)
+			}
+		})
+	}
+}
+
+func TestSignPayload(t *testing.T) {
+	// RFC 4231 test case 2
+	got := signPayload("Jefe", []byte("what do ya want for nothing?"))
+	if want := "5bdcc146bf60754e6a042426089575c75a003f089d2739839dec58b964ec3843"; got != want {
+		t.Errorf("signPayload = %s, want %s", got, want)
+	}
+}
+
+func TestRequest_Signing(t *testing.T) {
+	signatures := map[string]string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		signatures[r.Method] = r.Header.Get("X-Gateway-Signature")
+		w.Write([]byte(`{"id":"ov-1","name":"foo"}`))
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, "token")
+	c.SigningKey = "secret"
+	c.SigningHeader = "X-Gateway-Signature"
+
+	payload := OverlayPayload{Name: "foo", Data: json.RawMessage(`{}`), Tags: map[string]string{}}
+	if _, err := c.UpdateOverlay(context.Background(), "ov-1", payload, Precondition{}); err != nil {
+		t.Fatalf("UpdateOverlay: %s", err)
+	}
+	if _, err := c.GetOverlay(context.Background(), "ov-1"); err != nil {
+		t.Fatalf("GetOverlay: %s", err)
+	}
+
+	body, _ := json.Marshal(payload)
+	if got, want := signatures["PATCH"], signPayload("secret", body); got != want {
+		t.Errorf("PATCH signature = %q, want %q", got, want)
+	}
+	if got, want := signatures["GET"], signPayload("secret", nil); got != want {
+		t.Errorf("GET signature = %q, want %q", got, want)
+	}
+
+	// Without a key nothing is signed
+	c.SigningKey = ""
+	if _, err := c.GetOverlay(context.Background(), "ov-1"); err != nil {
+		t.Fatalf("GetOverlay: %s", err)
+	}
+	if got := signatures["GET"]; got != "" {
+		t.Errorf("unsigned request has signature %q", got)
+	}
+}
+
+func TestRequest_CancelDoesNotLeak(t *testing.T) {
+	defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+	started := make(chan struct{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/cube-overlays/body":
+			// Send part of the body, then hang until the client goes away
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"id":"ov-1",`))
+			w.(http.Flusher).Flush()
+		case "/cube-overlays/unavailable":
+			w.WriteHeader(http.StatusServiceUnavailable)
+			started <- struct{}{}
+			return
+		case "/cube-overlays":
+			// A created overlay that never finishes processing
+			w.Write([]byte(`{"id":"ov-1","status":"processing"}`))
+			started <- struct{}{}
+			return
+		}
+		started <- struct{}{}
+		<-r.Context().Done()
+	}))
+
+	tests := []struct {
+		name string
+		call func(ctx context.Context, c *Client) error
+	}{
+		{name: "waiting for the response", call: func(ctx context.Context, c *Client) error {
+			_, err := c.GetOverlay(ctx, "headers")
+			return err
+		}},
+		{name: "reading the body", call: func(ctx context.Context, c *Client) error {
+			_, err := c.GetOverlay(ctx, "body")
+			return err
+		}},
+		{name: "waiting to retry", call: func(ctx context.Context, c *Client) error {
+			_, err := c.GetOverlay(ctx, "unavailable")
+			return err
+		}},
+		{name: "waiting for processing", call: func(ctx context.Context, c *Client) error {
+			_, err := c.CreateOverlay(ctx, OverlayPayload{Name: "foo"})
+			return err
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewClient(server.URL, "token")
+			c.MaxRetries = 3
+			c.RetryWaitMin = time.Minute
+			c.PollInterval = time.Minute
+
+			ctx, cancel := context.WithCancel(context.Background())
+			go func() {
+				<-started
+				cancel()
+			}()
+
+			done := make(chan error, 1)
+			go func() { done <- tt.call(ctx, c) }()
+			select {
+			case err := <-done:
+				// Cancelled retries return the last error rather than the
+				// context's
+				if err == nil {
+					t.Error("expected an error after cancellation")
+				}
+			case <-time.After(10 * time.Second):
+				t.Fatal("request didn't return after cancellation")
+			}
+			c.HTTPClient.CloseIdleConnections()
+		})
+	}
+
+	server.Close()
+}
+
+func TestGetOverlayIfModified(t *testing.T) {
+	var ifNoneMatch []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ifNoneMatch = append(ifNoneMatch, r.Header.Get("If-None-Match"))
+		w.Header().Set("ETag", `"2"`)
+		if r.Header.Get("If-None-Match") == `"2"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Write([]byte(`{"id":"ov-1","name":"foo"}`))
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, "token")
+	ctx := context.Background()
+
+	overlay, modified, err := c.GetOverlayIfModified(ctx, "ov-1", `"2"`)
+	if err != nil || modified || overlay != nil {
+		t.Errorf("unchanged version: got %v, %t, %v; want no overlay, not modified", overlay, modified, err)
+	}
+
+	// Bare versions are quoted like ETags
+	overlay, modified, err = c.GetOverlayIfModified(ctx, "ov-1", "1")
+	if err != nil || !modified || overlay == nil || overlay.Version != `"2"` {
+		t.Errorf("changed version: got %v, %t, %v; want the overlay at version 2", overlay, modified, err)
+	}
+
+	// Without a version, or with the read cache, reads are unconditional
+	overlay, modified, err = c.GetOverlayIfModified(ctx, "ov-1", "")
+	if err != nil || !modified || overlay == nil {
+		t.Errorf("no version: got %v, %t, %v; want the overlay", overlay, modified, err)
+	}
+	c.ReadCacheTTL = time.Minute
+	if _, _, err := c.GetOverlayIfModified(ctx, "ov-1", `"2"`); err != nil {
+		t.Fatalf("GetOverlayIfModified: %s", err)
+	}
+
+	if want := []string{`"2"`, `"1"`, "", ""}; !reflect.DeepEqual(ifNoneMatch, want) {
+		t.Errorf("If-None-Match = %q, want %q", ifNoneMatch, want)
+	}
+}
+
+func TestSetDialer(t *testing.T) {
+	c := NewClient("https://api.revos.io", "token")
+	if c.HTTPClient.Transport != nil {
+		t.Fatal("expected the default transport without SetDialer")
+	}
+
+	c.SetDialer(DialerConfig{})
+	if c.dialer.Timeout != DefaultDialTimeout || c.dialer.KeepAlive != DefaultKeepAlive {
+		t.Errorf("default dialer = %s/%s, want %s/%s", c.dialer.Timeout, c.dialer.KeepAlive, DefaultDialTimeout, DefaultKeepAlive)
+	}
+
+	c.SetDialer(DialerConfig{DialTimeout: 5 * time.Second, KeepAlive: time.Minute})
+	if c.dialer.Timeout != 5*time.Second || c.dialer.KeepAlive != time.Minute {
+		t.Errorf("dialer = %s/%s, want 5s/1m", c.dialer.Timeout, c.dialer.KeepAlive)
+	}
+	transport, ok := c.HTTPClient.Transport.(*http.Transport)
+	if !ok {
+		t.Fatalf("transport = %T, want *http.Transport", c.HTTPClient.Transport)
+	}
+	if transport == http.DefaultTransport || transport.DialContext == nil {
+		t.Error("expected a copy of the default transport dialing through the dialer")
+	}
+	if transport.Proxy == nil || transport.TLSHandshakeTimeout != http.DefaultTransport.(*http.Transport).TLSHandshakeTimeout {
+		t.Error("expected the other settings of the default transport to be kept")
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id":"ov-1"}`))
+	}))
+	defer server.Close()
+	c = NewClient(server.URL, "token")
+	c.SetDialer(DialerConfig{DNSCacheTTL: time.Minute})
+	if _, err := c.GetOverlay(context.Background(), "ov-1"); err != nil {
+		t.Errorf("GetOverlay through the caching dialer: %s", err)
+	}
+}
+
+func TestCachingDialer(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+	_, port, _ := net.SplitHostPort(listener.Addr().String())
+	address := net.JoinHostPort("api.revos.test", port)
+
+	lookups := 0
+	var lookupErr error
+	d := &cachingDialer{
+		dialer: &net.Dialer{},
+		ttl:    time.Hour,
+		lookup: func(ctx context.Context, host string) ([]net.IPAddr, error) {
+			lookups++
+			if lookupErr != nil {
+				return nil, lookupErr
+			}
+			return []net.IPAddr{{IP: net.ParseIP("127.0.0.1")}}, nil
+		},
+	}
+	dial := func() error {
+		conn, err := d.DialContext(context.Background(), "tcp", address)
+		if err == nil {
+			conn.Close()
+		}
+		return err
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := dial(); err != nil {
+			t.Fatalf("dial: %s", err)
+		}
+	}
+	if lookups != 1 {
+		t.Errorf("lookups = %d, want 1 while cached", lookups)
+	}
+
+	// An expired entry is refreshed, and kept if the lookup fails
+	d.cache["api.revos.test"] = dnsCacheEntry{addrs: d.cache["api.revos.test"].addrs}
+	lookupErr = &net.DNSError{Err: "server misbehaving", Name: "api.revos.test", IsTemporary: true}
+	if err := dial(); err != nil {
+		t.Errorf("dial with a failing lookup: %s", err)
+	}
+	if lookups != 2 {
+		t.Errorf("lookups = %d, want the expired entry looked up again", lookups)
+	}
+
+	// A failed dial expires the entry, but during a DNS outage its
+	// addresses are still used
+	refused, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, refusedPort, _ := net.SplitHostPort(refused.Addr().String())
+	refused.Close()
+	refusedAddress := net.JoinHostPort("api.revos.test", refusedPort)
+	if _, err := d.DialContext(context.Background(), "tcp", refusedAddress); err == nil || errors.Is(err, lookupErr) {
+		t.Errorf("dial to a closed port = %v, want a connection error", err)
+	}
+	if entry := d.cache["api.revos.test"]; len(entry.addrs) == 0 || time.Now().Before(entry.expires) {
+		t.Errorf("entry after a failed dial = %+v, want its addresses kept and expired", entry)
+	}
+	if err := dial(); err != nil {
+		t.Errorf("dial after a failed dial with a failing lookup: %s", err)
+	}
+
+	// Without any cached addresses, lookup errors are returned
+	delete(d.cache, "api.revos.test")
+	if err := dial(); !errors.Is(err, lookupErr) {
+		t.Errorf("dial = %v, want the lookup error", err)
+	}
+
+	// One deadline bounds resolving and dialing all addresses
+	d.dialer.Timeout = time.Minute
+	var deadline time.Time
+	d.lookup = func(ctx context.Context, host string) ([]net.IPAddr, error) {
+		deadline, _ = ctx.Deadline()
+		return []net.IPAddr{{IP: net.ParseIP("127.0.0.1")}}, nil
+	}
+	if err := dial(); err != nil {
+		t.Fatalf("dial with a timeout: %s", err)
+	}
+	if deadline.IsZero() || time.Until(deadline) > time.Minute {
+		t.Errorf("lookup deadline = %s, want within the dial timeout", deadline)
+	}
+
+	// IP addresses are dialed without a lookup
+	lookups = 0
+	conn, err := d.DialContext(context.Background(), "tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatalf("dial IP: %s", err)
+	}
+	conn.Close()
+	if lookups != 0 {
+		t.Errorf("lookups = %d, want none for an IP address", lookups)
+	}
+}
+
+func TestRequest_RetryOnMessages(t *testing.T) {
+	tests := []struct {
+		name             string
+		retryOnMessages  []string
+		transient        int32
+		create           bool
+		expectError      bool
+		expectedRequests int32
+	}{
+		{
+			name:             "transient body then success",
+			retryOnMessages:  []string{"database connection reset"},
+			transient:        2,
+			expectedRequests: 3,
+		},
+		{
+			name:             "retries exhausted",
+			retryOnMessages:  []string{"database connection reset"},
+			transient:        10,
+			expectError:      true,
+			expectedRequests: 4,
+		},
+		{
+			name:             "no messages configured",
+			transient:        10,
+			expectedRequests: 1,
+		},
+		{
+			name:             "other messages",
+			retryOnMessages:  []string{"deadlock detected"},
+			transient:        10,
+			expectedRequests: 1,
+		},
+		{
+			name:             "non-idempotent requests aren't retried",
+			retryOnMessages:  []string{"database connection reset"},
+			transient:        10,
+			create:           true,
+			expectError:      true,
+			expectedRequests: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if requests.Add(1) <= tt.transient {
+					w.Write([]byte(`{"id":"ov-1","name":"foo","error":"ERROR: database connection reset by peer"}`))
+					return
+				}
+				w.Write([]byte(`{"id":"ov-1","name":"foo"}`))
+			}))
+			defer server.Close()
+
+			c := NewClient(server.URL, "token")
+			c.MaxRetries = 3
+			c.RetryWaitMin = time.Millisecond
+			c.RetryOnMessages = tt.retryOnMessages
+
+			var err error
+			if tt.create {
+				_, err = c.CreateOverlay(context.Background(), OverlayPayload{Name: "foo"})
+			} else {
+				_, err = c.GetOverlay(context.Background(), "ov-1")
+			}
+			if tt.expectError != (err != nil) {
+				t.Errorf("error = %v, want error: %t", err, tt.expectError)
+			}
+			var transientErr *TransientResponseError
+			if tt.expectError && !errors.As(err, &transientErr) {
+				t.Errorf("error = %v, want a *TransientResponseError", err)
+			}
+			if got := requests.Load(); got != tt.expectedRequests {
+				t.Errorf("requests = %d, want %d", got, tt.expectedRequests)
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package client
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Defaults of DialerConfig, matching http.DefaultTransport
+const (
+	DefaultDialTimeout = 30 * time.Second
+	DefaultKeepAlive   = 30 * time.Second
+)
+
+// DialerConfig configures how connections to the API are made
+type DialerConfig struct {
+	// DialTimeout bounds establishing a connection, including resolving the
+	// host. Zero means DefaultDialTimeout.
+	DialTimeout time.Duration
+	// KeepAlive is the interval between TCP keep-alive probes. Zero means
+	// DefaultKeepAlive.
+	KeepAlive time.Duration
+	// DNSCacheTTL is how long the resolved addresses of a host are reused
+	// for new connections. If resolving fails, expired addresses are used
+	// until it succeeds again. Zero disables the cache.
+	DNSCacheTTL time.Duration
+}
+
+// SetDialer makes the client connect through a dialer configured by cfg,
+// on a copy of http.DefaultTransport. Without it, the client uses
+// http.DefaultTransport itself.
+func (c *Client) SetDialer(cfg DialerConfig) {
+	timeout := cfg.DialTimeout
+	if timeout <= 0 {
+		timeout = DefaultDialTimeout
+	}
+	keepAlive := cfg.KeepAlive
+	if keepAlive <= 0 {
+		keepAlive = DefaultKeepAlive
+	}
+	c.dialer = &net.Dialer{Timeout: timeout, KeepAlive: keepAlive}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = c.dialer.DialContext
+	if cfg.DNSCacheTTL > 0 {
+		transport.DialContext = (&cachingDialer{
+			dialer: c.dialer,
+			ttl:    cfg.DNSCacheTTL,
+			lookup: net.DefaultResolver.LookupIPAddr,
+		}).DialContext
+	}
+	c.HTTPClient.Transport = transport
+}
+
+// cachingDialer dials through dialer, resolving host names itself and
+// reusing the addresses for ttl
+type cachingDialer struct {
+	dialer *net.Dialer
+	ttl    time.Duration
+	lookup func(ctx context.Context, host string) ([]net.IPAddr, error)
+
+	mu    sync.Mutex
+	cache map[string]dnsCacheEntry
+}
+
+type dnsCacheEntry struct {
+	addrs   []net.IPAddr
+	expires time.Time
+}
+
+func (d *cachingDialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil || net.ParseIP(host) != nil {
+		return d.dialer.DialContext(ctx, network, address)
+	}
+
+	// The dial timeout bounds the whole connection, however many addresses
+	// are tried, as it does when the dialer resolves the host itself
+	if d.dialer.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, d.dialer.Timeout)
+		defer cancel()
+	}
+
+	addrs, err := d.resolve(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	var firstErr error
+	for _, addr := range addrs {
+		conn, err := d.dialer.DialContext(ctx, network, net.JoinHostPort(addr.String(), port))
+		if err == nil {
+			return conn, nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	// The host may have moved, so resolve it again next time, but keep the
+	// addresses in case resolving fails
+	d.expire(host)
+	return nil, firstErr
+}
+
+// resolve returns the cached addresses of host, looking them up if they
+// expired. Expired addresses are still returned if the lookup fails.
+func (d *cachingDialer) resolve(ctx context.Context, host string) ([]net.IPAddr, error) {
+	d.mu.Lock()
+	entry, ok := d.cache[host]
+	d.mu.Unlock()
+	if ok && time.Now().Before(entry.expires) {
+		return entry.addrs, nil
+	}
+
+	addrs, err := d.lookup(ctx, host)
+	if err != nil || len(addrs) == 0 {
+		if ok {
+			return entry.addrs, nil
+		}
+		if err == nil {
+			err = &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+		}
+		return nil, err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.cache == nil {
+		d.cache = map[string]dnsCacheEntry{}
+	}
+	d.cache[host] = dnsCacheEntry{addrs: addrs, expires: time.Now().Add(d.ttl)}
+	return addrs, nil
+}
+
+// expire makes the next dial to host resolve it again
+func (d *cachingDialer) expire(host string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if entry, ok := d.cache[host]; ok {
+		entry.expires = time.Time{}
+		d.cache[host] = entry
+	}
+}
@@ -0,0 +1,95 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// OverlaySearchParams are the filters of SearchOverlays. Zero values don't
+// filter.
+type OverlaySearchParams struct {
+	// NameContains matches overlays whose name contains it, ignoring case
+	NameContains string
+	// CreatedBy matches overlays created by this user
+	CreatedBy string
+	// CreatedAfter matches overlays created after this time
+	CreatedAfter time.Time
+}
+
+func (p OverlaySearchParams) query() url.Values {
+	query := url.Values{}
+	if p.NameContains != "" {
+		query.Set("nameContains", p.NameContains)
+	}
+	if p.CreatedBy != "" {
+		query.Set("createdBy", p.CreatedBy)
+	}
+	if !p.CreatedAfter.IsZero() {
+		query.Set("createdAfter", p.CreatedAfter.UTC().Format(time.RFC3339))
+	}
+	return query
+}
+
+// matches reports whether an overlay matches the filters, like the search
+// endpoint. Overlays without a valid createdAt don't match CreatedAfter.
+func (p OverlaySearchParams) matches(overlay CubeOverlay) bool {
+	if p.NameContains != "" && !strings.Contains(strings.ToLower(overlay.Name), strings.ToLower(p.NameContains)) {
+		return false
+	}
+	if p.CreatedBy != "" && overlay.CreatedBy != p.CreatedBy {
+		return false
+	}
+	if !p.CreatedAfter.IsZero() {
+		createdAt, err := time.Parse(time.RFC3339, overlay.CreatedAt)
+		if err != nil || !createdAt.After(p.CreatedAfter) {
+			return false
+		}
+	}
+	return true
+}
+
+// SearchOverlays retrieves the overlays that are not archived and match
+// params, filtered by the API. If the API has no search endpoint, it lists
+// all overlays and filters them itself.
+func (c *Client) SearchOverlays(ctx context.Context, params OverlaySearchParams) ([]CubeOverlay, error) {
+	body, err := c.request(ctx, "GET", "/cube-overlays/search?"+params.query().Encode(), nil)
+	if IsNotFound(err) {
+		tflog.Debug(ctx, "Overlay search not supported by the API, filtering the overlay list")
+		return c.filterOverlays(ctx, params)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	found, err := unwrap[[]CubeOverlay](c.ResponseEnvelope, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal overlay search results: %w", err)
+	}
+	overlays := make([]CubeOverlay, 0, len(*found))
+	for _, overlay := range *found {
+		if !overlay.Archived {
+			overlays = append(overlays, overlay)
+		}
+	}
+	return overlays, nil
+}
+
+func (c *Client) filterOverlays(ctx context.Context, params OverlaySearchParams) ([]CubeOverlay, error) {
+	all, err := c.ListOverlays(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	overlays := make([]CubeOverlay, 0, len(all))
+	for _, overlay := range all {
+		if params.matches(overlay) {
+			overlays = append(overlays, overlay)
+		}
+	}
+	return overlays, nil
+}
@@ -0,0 +1,88 @@
+package client
+
+import (
+	"context"
+	"fmt"
+)
+
+// OverlayShare grants a principal (user or team) access to an overlay
+type OverlayShare struct {
+	ID          string `json:"id"`
+	OverlayID   string `json:"overlayId"`
+	PrincipalID string `json:"principalId"`
+	Role        string `json:"role"`
+	CreatedAt   string `json:"createdAt"`
+}
+
+// OverlaySharePayload is used for Create and Update. The principal of an
+// existing share can't be changed, so it is omitted on update.
+type OverlaySharePayload struct {
+	PrincipalID string `json:"principalId,omitempty"`
+	Role        string `json:"role"`
+}
+
+func sharesPath(overlayID string) string {
+	return fmt.Sprintf("/cube-overlays/%s/shares", overlayID)
+}
+
+// ListOverlayShares retrieves all shares of an overlay
+func (c *Client) ListOverlayShares(ctx context.Context, overlayID string) ([]OverlayShare, error) {
+	body, err := c.request(ctx, "GET", sharesPath(overlayID), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	shares, err := unwrap[[]OverlayShare](c.ResponseEnvelope, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal overlay shares: %w", err)
+	}
+	return *shares, nil
+}
+
+// GetOverlayShare retrieves a share of an overlay by ID
+func (c *Client) GetOverlayShare(ctx context.Context, overlayID, shareID string) (*OverlayShare, error) {
+	body, err := c.request(ctx, "GET", fmt.Sprintf("%s/%s", sharesPath(overlayID), shareID), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	share, err := unwrap[OverlayShare](c.ResponseEnvelope, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal overlay share: %w", err)
+	}
+	return share, nil
+}
+
+// CreateOverlayShare shares an overlay with a principal
+func (c *Client) CreateOverlayShare(ctx context.Context, overlayID string, payload OverlaySharePayload) (*OverlayShare, error) {
+	body, err := c.request(ctx, "POST", sharesPath(overlayID), payload)
+	if err != nil {
+		return nil, err
+	}
+
+	share, err := unwrap[OverlayShare](c.ResponseEnvelope, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal overlay share: %w", err)
+	}
+	return share, nil
+}
+
+// UpdateOverlayShare changes the role of an existing share
+func (c *Client) UpdateOverlayShare(ctx context.Context, overlayID, shareID string, payload OverlaySharePayload) (*OverlayShare, error) {
+	body, err := c.request(ctx, "PATCH", fmt.Sprintf("%s/%s", sharesPath(overlayID), shareID), payload)
+	if err != nil {
+		return nil, err
+	}
+
+	share, err := unwrap[OverlayShare](c.ResponseEnvelope, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal overlay share: %w", err)
+	}
+	return share, nil
+}
+
+// DeleteOverlayShare revokes a share
+func (c *Client) DeleteOverlayShare(ctx context.Context, overlayID, shareID string) error {
+	_, err := c.request(ctx, "DELETE", fmt.Sprintf("%s/%s", sharesPath(overlayID), shareID), nil)
+	return err
+}
@@ -0,0 +1,76 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// OverlayTemplate is a parameterized overlay definition. Its data may contain
+// placeholders, so it isn't validated as a Cube definition.
+type OverlayTemplate struct {
+	ID          string          `json:"id"`
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Data        json.RawMessage `json:"data"`
+	CreatedAt   string          `json:"createdAt"`
+	UpdatedAt   string          `json:"updatedAt"`
+}
+
+// OverlayTemplatePayload is used for Create and Update
+type OverlayTemplatePayload struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Data        json.RawMessage `json:"data"`
+}
+
+const templatesPath = "/cube-overlay-templates"
+
+// GetOverlayTemplate retrieves a template by ID
+func (c *Client) GetOverlayTemplate(ctx context.Context, id string) (*OverlayTemplate, error) {
+	body, err := c.request(ctx, "GET", fmt.Sprintf("%s/%s", templatesPath, id), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	template, err := unwrap[OverlayTemplate](c.ResponseEnvelope, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal overlay template: %w", err)
+	}
+	return template, nil
+}
+
+// CreateOverlayTemplate creates a template
+func (c *Client) CreateOverlayTemplate(ctx context.Context, payload OverlayTemplatePayload) (*OverlayTemplate, error) {
+	body, err := c.request(ctx, "POST", templatesPath, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	template, err := unwrap[OverlayTemplate](c.ResponseEnvelope, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal overlay template: %w", err)
+	}
+	return template, nil
+}
+
+// UpdateOverlayTemplate updates an existing template
+func (c *Client) UpdateOverlayTemplate(ctx context.Context, id string, payload OverlayTemplatePayload) (*OverlayTemplate, error) {
+	body, err := c.request(ctx, "PATCH", fmt.Sprintf("%s/%s", templatesPath, id), payload)
+	if err != nil {
+		return nil, err
+	}
+
+	template, err := unwrap[OverlayTemplate](c.ResponseEnvelope, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal overlay template: %w", err)
+	}
+	return template, nil
+}
+
+// DeleteOverlayTemplate deletes a template. Overlays rendered from it are
+// not affected.
+func (c *Client) DeleteOverlayTemplate(ctx context.Context, id string) error {
+	_, err := c.request(ctx, "DELETE", fmt.Sprintf("%s/%s", templatesPath, id), nil)
+	return err
+}
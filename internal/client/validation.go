@@ -0,0 +1,33 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// OverlayValidation is the result of validating an overlay definition
+type OverlayValidation struct {
+	Valid    bool     `json:"valid"`
+	Errors   []string `json:"errors"`
+	Warnings []string `json:"warnings"`
+}
+
+type overlayValidationRequest struct {
+	Data json.RawMessage `json:"data"`
+}
+
+// ValidateOverlay checks an overlay definition without creating an overlay.
+// An invalid definition is reported in the result, not as an error.
+func (c *Client) ValidateOverlay(ctx context.Context, data json.RawMessage) (*OverlayValidation, error) {
+	body, err := c.request(ctx, "POST", "/cube-overlays/validate", overlayValidationRequest{Data: data})
+	if err != nil {
+		return nil, err
+	}
+
+	validation, err := unwrap[OverlayValidation](c.ResponseEnvelope, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal overlay validation: %w", err)
+	}
+	return validation, nil
+}
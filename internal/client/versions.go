@@ -0,0 +1,34 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// OverlayVersion is a historical revision of an overlay
+type OverlayVersion struct {
+	VersionID string `json:"versionId"`
+	CreatedAt string `json:"createdAt"`
+	CreatedBy string `json:"createdBy"`
+}
+
+// ListOverlayVersions retrieves the revisions of an overlay. If the API
+// doesn't track revisions, it returns no versions rather than an error.
+func (c *Client) ListOverlayVersions(ctx context.Context, overlayID string) ([]OverlayVersion, error) {
+	body, err := c.request(ctx, "GET", fmt.Sprintf("/cube-overlays/%s/versions", overlayID), nil)
+	if err != nil {
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && (apiErr.StatusCode == http.StatusNotFound || apiErr.StatusCode == http.StatusNotImplemented) {
+			return []OverlayVersion{}, nil
+		}
+		return nil, err
+	}
+
+	versions, err := unwrap[[]OverlayVersion](c.ResponseEnvelope, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal overlay versions: %w", err)
+	}
+	return *versions, nil
+}
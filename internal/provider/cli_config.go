@@ -0,0 +1,45 @@
+package provider
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// cliConfig is the part of the Revos CLI config file the provider reads
+type cliConfig struct {
+	APIURL string `json:"api_url"`
+	Token  string `json:"token"`
+}
+
+// defaultCLIConfigPath returns where the Revos CLI keeps its config file
+func defaultCLIConfigPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".revos", "config.json"), nil
+}
+
+// loadCLIConfig reads the Revos CLI config file at path. A missing file
+// isn't an error, since the CLI may never have been logged in; it returns
+// an empty config.
+func loadCLIConfig(path string) (cliConfig, error) {
+	var config cliConfig
+
+	contents, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return config, nil
+	}
+	if err != nil {
+		return config, err
+	}
+
+	if err := json.Unmarshal(contents, &config); err != nil {
+		return config, fmt.Errorf("invalid JSON in %s: %w", path, err)
+	}
+	return config, nil
+}
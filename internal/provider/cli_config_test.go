@@ -0,0 +1,57 @@
+package provider
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadCLIConfig(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, contents string) string {
+		p := filepath.Join(dir, name)
+		if err := os.WriteFile(p, []byte(contents), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+
+	tests := []struct {
+		name        string
+		path        string
+		expected    cliConfig
+		expectError bool
+	}{
+		{
+			name:     "full",
+			path:     write("full.json", `{"api_url": "https://api.revos.ai", "token": "cli-token", "profile": "default"}`),
+			expected: cliConfig{APIURL: "https://api.revos.ai", Token: "cli-token"},
+		},
+		{
+			name:     "token only",
+			path:     write("token.json", `{"token": "cli-token"}`),
+			expected: cliConfig{Token: "cli-token"},
+		},
+		{
+			name: "missing",
+			path: filepath.Join(dir, "missing.json"),
+		},
+		{
+			name:        "invalid",
+			path:        write("invalid.json", `api_url = "https://api.revos.ai"`),
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := loadCLIConfig(tt.path)
+			if (err != nil) != tt.expectError {
+				t.Fatalf("err = %v, expectError %v", err, tt.expectError)
+			}
+			if config != tt.expected {
+				t.Errorf("config = %+v, want %+v", config, tt.expected)
+			}
+		})
+	}
+}
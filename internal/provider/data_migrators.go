@@ -0,0 +1,104 @@
+package provider
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// dataMigrator rewrites overlay data stored in a legacy layout to the
+// current one. Migrators must leave data already in the current layout
+// unchanged, as they run on every read.
+type dataMigrator func(map[string]interface{}) map[string]interface{}
+
+// dataMigrators are applied in order to the data returned by the API before
+// it is compared with the state, so overlays the backend hasn't migrated yet
+// don't show a diff against configurations written in the current layout.
+// Add a migrator here when the Cube definition schema deprecates a layout.
+var dataMigrators = []dataMigrator{
+	migrateJoinRelationships,
+}
+
+// migrateData applies dataMigrators to a JSON object. Data that isn't a JSON
+// object, or that no migrator changes, is returned as is.
+func migrateData(data string) string {
+	obj, err := decodeObject(data)
+	if err != nil || obj == nil {
+		return data
+	}
+	original, _ := decodeObject(data)
+
+	for _, migrate := range dataMigrators {
+		obj = migrate(obj)
+	}
+	if reflect.DeepEqual(obj, original) {
+		return data
+	}
+
+	migrated, err := json.Marshal(obj)
+	if err != nil {
+		return data
+	}
+	return string(migrated)
+}
+
+// decodeObject decodes a JSON object, keeping numbers exact
+func decodeObject(data string) (map[string]interface{}, error) {
+	var obj map[string]interface{}
+	d := json.NewDecoder(strings.NewReader(data))
+	d.UseNumber()
+	if err := d.Decode(&obj); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+// legacyJoinRelationships maps the deprecated join relationship names to
+// their current equivalents
+var legacyJoinRelationships = map[string]string{
+	"hasMany":    "one_to_many",
+	"has_many":   "one_to_many",
+	"belongsTo":  "many_to_one",
+	"belongs_to": "many_to_one",
+	"hasOne":     "one_to_one",
+	"has_one":    "one_to_one",
+}
+
+// migrateJoinRelationships renames the deprecated hasMany, belongsTo and
+// hasOne join relationships of each cube to one_to_many, many_to_one and
+// one_to_one. Joins may be an object keyed by the joined cube or a list.
+func migrateJoinRelationships(data map[string]interface{}) map[string]interface{} {
+	cubes, ok := data["cubes"].([]interface{})
+	if !ok {
+		return data
+	}
+
+	for _, c := range cubes {
+		cube, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		var joins []interface{}
+		switch j := cube["joins"].(type) {
+		case map[string]interface{}:
+			for _, join := range j {
+				joins = append(joins, join)
+			}
+		case []interface{}:
+			joins = j
+		}
+
+		for _, j := range joins {
+			join, ok := j.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			relationship, _ := join["relationship"].(string)
+			if current, ok := legacyJoinRelationships[relationship]; ok {
+				join["relationship"] = current
+			}
+		}
+	}
+	return data
+}
@@ -0,0 +1,122 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+func TestMigrateJoinRelationships(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		expected string
+	}{
+		{
+			name:     "joins object",
+			data:     `{"cubes":[{"name":"orders","joins":{"users":{"sql":"x","relationship":"belongsTo"},"items":{"relationship":"hasMany"}}}]}`,
+			expected: `{"cubes":[{"name":"orders","joins":{"users":{"sql":"x","relationship":"many_to_one"},"items":{"relationship":"one_to_many"}}}]}`,
+		},
+		{
+			name:     "joins list",
+			data:     `{"cubes":[{"name":"users","joins":[{"name":"profile","relationship":"hasOne"},{"name":"orgs","relationship":"belongs_to"}]}]}`,
+			expected: `{"cubes":[{"name":"users","joins":[{"name":"profile","relationship":"one_to_one"},{"name":"orgs","relationship":"many_to_one"}]}]}`,
+		},
+		{
+			name:     "current layout",
+			data:     `{"cubes":[{"name":"orders","joins":{"users":{"relationship":"many_to_one"}}}]}`,
+			expected: `{"cubes":[{"name":"orders","joins":{"users":{"relationship":"many_to_one"}}}]}`,
+		},
+		{
+			name:     "no cubes",
+			data:     `{"views":[{"name":"v"}]}`,
+			expected: `{"views":[{"name":"v"}]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := decodeObject(tt.data)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			expected, err := decodeObject(tt.expected)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if got := migrateJoinRelationships(data); !deepEqual(got, expected) {
+				t.Errorf("migrateJoinRelationships() = %v, want %v", got, expected)
+			}
+		})
+	}
+}
+
+func TestMigrateData(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		expected string
+	}{
+		{
+			name:     "legacy layout",
+			data:     `{"cubes":[{"joins":{"users":{"relationship":"belongsTo"}}}]}`,
+			expected: `{"cubes":[{"joins":{"users":{"relationship":"many_to_one"}}}]}`,
+		},
+		{
+			name:     "unchanged data keeps its formatting and precision",
+			data:     `{ "cubes": [], "limit": 12345678901234567890 }`,
+			expected: `{ "cubes": [], "limit": 12345678901234567890 }`,
+		},
+		{
+			name:     "not an object",
+			data:     `[1,2]`,
+			expected: `[1,2]`,
+		},
+		{
+			name:     "invalid JSON",
+			data:     `{invalid`,
+			expected: `{invalid`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := migrateData(tt.data); got != tt.expected {
+				t.Errorf("migrateData() = %s, want %s", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestOverlayResource_LegacyDataLayout(t *testing.T) {
+	m := newMockRevosServer(t)
+	h := newMockHarness(t, m)
+
+	config := map[string]interface{}{
+		"name": "legacy",
+		"data": `{"cubes":[{"name":"orders","joins":{"users":{"relationship":"many_to_one"}}}]}`,
+	}
+	null := tftypes.NewValue(h.resourceSchema("revos_overlay").ValueType(), nil)
+	state, diags := h.apply("revos_overlay", null, config)
+	requireNoErrors(t, "create", diags)
+
+	// The backend still stores the deprecated relationship name
+	m.setOverlayField("ov-1", "data", map[string]interface{}{
+		"cubes": []interface{}{map[string]interface{}{
+			"name":  "orders",
+			"joins": map[string]interface{}{"users": map[string]interface{}{"relationship": "belongsTo"}},
+		}},
+	})
+
+	state, diags = h.read("revos_overlay", state)
+	requireNoErrors(t, "refresh", diags)
+	if got := attrString(t, state, "data"); got != config["data"] {
+		t.Errorf("data = %s, want the configured data", got)
+	}
+
+	planned, diags := h.plan("revos_overlay", state, config)
+	requireNoErrors(t, "plan", diags)
+	if !planned.Equal(state) {
+		t.Errorf("expected an empty plan, got %s", planned)
+	}
+}
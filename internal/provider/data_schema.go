@@ -0,0 +1,72 @@
+package provider
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// compileDataSchema compiles the JSON Schema document in file, which
+// data_schema_file names, with the draft it declares in $schema
+func compileDataSchema(file string) (*jsonschema.Schema, error) {
+	return jsonschema.NewCompiler().Compile(file)
+}
+
+// validateDataSchema validates rendered overlay data against the schema of
+// data_schema_file, adding an error on data for every violation
+func validateDataSchema(schema *jsonschema.Schema, data string, diags *diag.Diagnostics) {
+	if schema == nil {
+		return
+	}
+
+	v, err := decodeJSON(data)
+	if err != nil {
+		// Invalid JSON is reported by the validation of data itself
+		return
+	}
+
+	err = schema.Validate(v)
+	var validationErr *jsonschema.ValidationError
+	if err == nil || !errors.As(err, &validationErr) {
+		if err != nil {
+			diags.AddAttributeError(path.Root("data"), "Data Schema Validation Failed",
+				fmt.Sprintf("Unable to validate data against data_schema_file: %s", err))
+		}
+		return
+	}
+
+	for _, violation := range schemaViolations(validationErr) {
+		location := violation.InstanceLocation
+		if location == "" {
+			location = "/"
+		}
+		diags.AddAttributeError(path.Root("data"), "Data Does Not Match Schema",
+			fmt.Sprintf("%s: %s (schema %s)", location, violation.Message, violation.KeywordLocation))
+	}
+}
+
+// schemaViolations returns the innermost errors of a validation error, those
+// naming the keywords that failed, ordered by instance location
+func schemaViolations(err *jsonschema.ValidationError) []*jsonschema.ValidationError {
+	var violations []*jsonschema.ValidationError
+	var collect func(err *jsonschema.ValidationError)
+	collect = func(err *jsonschema.ValidationError) {
+		if len(err.Causes) == 0 {
+			violations = append(violations, err)
+			return
+		}
+		for _, cause := range err.Causes {
+			collect(cause)
+		}
+	}
+	collect(err)
+
+	sort.SliceStable(violations, func(i, j int) bool {
+		return violations[i].InstanceLocation < violations[j].InstanceLocation
+	})
+	return violations
+}
@@ -0,0 +1,149 @@
+package provider
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// testDataSchema requires every cube to have sql and a snake_case name
+const testDataSchema = `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "type": "object",
+  "required": ["cubes"],
+  "properties": {
+    "cubes": {
+      "type": "object",
+      "propertyNames": {"pattern": "^[a-z][a-z0-9_]*$"},
+      "additionalProperties": {
+        "type": "object",
+        "required": ["sql"]
+      }
+    }
+  }
+}`
+
+func writeDataSchema(t *testing.T, content string) string {
+	t.Helper()
+
+	name := filepath.Join(t.TempDir(), "overlay.schema.json")
+	if err := os.WriteFile(name, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return name
+}
+
+func TestValidateDataSchema(t *testing.T) {
+	schema, err := compileDataSchema(writeDataSchema(t, testDataSchema))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		data     string
+		expected []string
+	}{
+		{
+			name: "valid",
+			data: `{"cubes":{"orders":{"sql":"SELECT 1"},"order_items":{"sql":"SELECT 2"}}}`,
+		},
+		{
+			name:     "missing cubes",
+			data:     `{"views":{}}`,
+			expected: []string{"/: missing properties: 'cubes' (schema /required)"},
+		},
+		{
+			name: "several violations",
+			data: `{"cubes":{"Orders":{"sql":"SELECT 1"},"users":{"title":"Users"}}}`,
+			expected: []string{
+				"/cubes/Orders: does not match pattern",
+				"/cubes/users: missing properties: 'sql'",
+			},
+		},
+		{
+			name: "invalid JSON",
+			data: `{`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var diags diag.Diagnostics
+			validateDataSchema(schema, tt.data, &diags)
+
+			if len(diags) != len(tt.expected) {
+				t.Fatalf("got %d diagnostics, want %d: %v", len(diags), len(tt.expected), diags)
+			}
+			for i, d := range diags {
+				if d.Summary() != "Data Does Not Match Schema" {
+					t.Errorf("summary = %q", d.Summary())
+				}
+				if !strings.HasPrefix(d.Detail(), tt.expected[i]) {
+					t.Errorf("detail = %q, want prefix %q", d.Detail(), tt.expected[i])
+				}
+			}
+		})
+	}
+
+	t.Run("no schema", func(t *testing.T) {
+		var diags diag.Diagnostics
+		validateDataSchema(nil, `{"views":{}}`, &diags)
+		if diags.HasError() {
+			t.Errorf("unexpected errors: %v", diags)
+		}
+	})
+}
+
+func TestOverlayResource_DataSchemaFile(t *testing.T) {
+	m := newMockRevosServer(t)
+	h := newTestHarness(t, map[string]interface{}{
+		"api_url":          m.URL,
+		"token":            "test-token",
+		"data_schema_file": writeDataSchema(t, testDataSchema),
+	})
+	null := tftypes.NewValue(h.resourceSchema("revos_overlay").ValueType(), nil)
+
+	_, diags := h.plan("revos_overlay", null, map[string]interface{}{
+		"name": "orders",
+		"data": `{"cubes":{"orders":{"sql":"SELECT 1"}}}`,
+	})
+	requireNoErrors(t, "plan matching data", diags)
+
+	// Violations are checked on the data after data_vars are substituted
+	_, diags = h.plan("revos_overlay", null, map[string]interface{}{
+		"name":      "orders",
+		"data":      `{"cubes":{"${cube}":{"sql":"SELECT 1"}}}`,
+		"data_vars": map[string]interface{}{"cube": "Orders"},
+	})
+	requireError(t, diags, "/cubes/Orders: does not match pattern")
+	for _, d := range diags {
+		if diagAttribute(d) != "data" {
+			t.Errorf("diagnostic %q on %q, want data", d.Summary, diagAttribute(d))
+		}
+	}
+	if n := m.requestCount("POST", "/cube-overlays"); n != 0 {
+		t.Errorf("%d create requests, want none", n)
+	}
+
+	t.Run("invalid schema file", func(t *testing.T) {
+		for name, file := range map[string]string{
+			"missing":    filepath.Join(t.TempDir(), "missing.json"),
+			"not JSON":   writeDataSchema(t, `{`),
+			"not schema": writeDataSchema(t, `{"type":"table"}`),
+		} {
+			t.Run(name, func(t *testing.T) {
+				diags := configureProvider(t, map[string]interface{}{
+					"api_url":          m.URL,
+					"token":            "test-token",
+					"data_schema_file": file,
+				})
+				requireError(t, diags, "Invalid Data Schema File")
+			})
+		}
+	})
+}
@@ -0,0 +1,140 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/revosai/terraform-provider-revos/internal/client"
+)
+
+// Ensure implementation satisfies interfaces.
+var _ datasource.DataSource = &OverlayDataDataSource{}
+
+func NewOverlayDataDataSource() datasource.DataSource {
+	return &OverlayDataDataSource{}
+}
+
+// OverlayDataDataSource exposes values from an overlay's definition by JSON
+// Pointer, so configurations don't have to decode the data themselves
+type OverlayDataDataSource struct {
+	client *client.Client
+}
+
+type OverlayDataDataSourceModel struct {
+	ID     types.String `tfsdk:"id"`
+	Paths  types.List   `tfsdk:"paths"`
+	Values types.Map    `tfsdk:"values"`
+}
+
+func (d *OverlayDataDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_overlay_data"
+}
+
+func (d *OverlayDataDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Reads values from the definition of a Revos Cube Overlay by JSON Pointer.",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Required:    true,
+				Description: "The ID of the overlay.",
+			},
+			"paths": schema.ListAttribute{
+				Required:    true,
+				ElementType: types.StringType,
+				Description: "JSON Pointers (RFC 6901) to values in the overlay data, such as \"/cubes/0/name\". The empty pointer \"\" refers to the whole definition.",
+			},
+			"values": schema.MapAttribute{
+				Computed:    true,
+				ElementType: types.StringType,
+				Description: "The JSON-encoded value at each of paths, keyed by the pointer. Decode them with jsondecode().",
+			},
+		},
+	}
+}
+
+func (d *OverlayDataDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	providerData, ok := req.ProviderData.(*RevosProviderData)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *RevosProviderData, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+		return
+	}
+
+	d.client = providerData.Client
+}
+
+func (d *OverlayDataDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data OverlayDataDataSourceModel
+
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	overlay, err := d.client.GetOverlay(ctx, data.ID.ValueString())
+	if err != nil {
+		if client.IsNotFound(err) {
+			resp.Diagnostics.AddAttributeError(path.Root("id"), "Overlay Not Found",
+				fmt.Sprintf("No overlay has the ID %q.", data.ID.ValueString()))
+			return
+		}
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to read overlay, got error: %s", err))
+		return
+	}
+
+	// Resolve against the data as the overlay resource would show it
+	definition, err := decodeJSON(migrateData(string(overlay.Data)))
+	if err != nil {
+		resp.Diagnostics.AddError("Invalid Overlay Data", fmt.Sprintf("The API returned overlay data that isn't valid JSON: %s", err))
+		return
+	}
+
+	values := map[string]string{}
+	for i, pointer := range stringElements(data.Paths) {
+		var tokens []string
+		if pointer != "" {
+			tokens, err = parseJSONPointer(pointer)
+			if err != nil {
+				resp.Diagnostics.AddAttributeError(path.Root("paths").AtListIndex(i), "Invalid JSON Pointer",
+					fmt.Sprintf("%q is not a valid JSON Pointer: %s.", pointer, err))
+				continue
+			}
+		}
+
+		value, err := resolveJSONPointer(definition, tokens)
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("paths").AtListIndex(i), "Unresolved JSON Pointer",
+				fmt.Sprintf("%q doesn't point at a value in the data of overlay %s: %s.", pointer, overlay.ID, err))
+			continue
+		}
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			resp.Diagnostics.AddError("Encoding Error", fmt.Sprintf("Unable to encode the value at %q, got error: %s", pointer, err))
+			continue
+		}
+		values[pointer] = string(encoded)
+	}
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	valuesMap, diags := types.MapValueFrom(ctx, types.StringType, values)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	data.Values = valuesMap
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
@@ -0,0 +1,57 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+func TestOverlayDataDataSource(t *testing.T) {
+	m := newMockRevosServer(t)
+	h := newMockHarness(t, m)
+
+	null := tftypes.NewValue(h.resourceSchema("revos_overlay").ValueType(), nil)
+	state, diags := h.apply("revos_overlay", null, map[string]interface{}{
+		"name": "outputs",
+		"data": `{"cubes":[{"name":"orders","sql_table":"public.orders"},{"name":"users"}]}`,
+	})
+	requireNoErrors(t, "create", diags)
+	id := attrString(t, state, "id")
+
+	result, diags := h.readDataSource("revos_overlay_data", map[string]interface{}{
+		"id":    id,
+		"paths": []interface{}{"/cubes/0", "/cubes/1/name", "/cubes", ""},
+	})
+	requireNoErrors(t, "read", diags)
+
+	values := attrMap(t, result, "values")
+	expected := map[string]string{
+		"/cubes/0":      `{"name":"orders","sql_table":"public.orders"}`,
+		"/cubes/1/name": `"users"`,
+		"/cubes":        `[{"name":"orders","sql_table":"public.orders"},{"name":"users"}]`,
+		"":              `{"cubes":[{"name":"orders","sql_table":"public.orders"},{"name":"users"}]}`,
+	}
+	for pointer, want := range expected {
+		if got := values[pointer]; got != want {
+			t.Errorf("values[%q] = %s, want %s", pointer, got, want)
+		}
+	}
+
+	_, diags = h.readDataSource("revos_overlay_data", map[string]interface{}{
+		"id":    id,
+		"paths": []interface{}{"/cubes/0/name", "/cubes/2"},
+	})
+	requireError(t, diags, "Unresolved JSON Pointer")
+
+	_, diags = h.readDataSource("revos_overlay_data", map[string]interface{}{
+		"id":    id,
+		"paths": []interface{}{"cubes"},
+	})
+	requireError(t, diags, "Invalid JSON Pointer")
+
+	_, diags = h.readDataSource("revos_overlay_data", map[string]interface{}{
+		"id":    "missing",
+		"paths": []interface{}{"/cubes"},
+	})
+	requireError(t, diags, "Overlay Not Found")
+}
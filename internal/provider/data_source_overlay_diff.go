@@ -0,0 +1,153 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/revosai/terraform-provider-revos/internal/client"
+)
+
+// Ensure implementation satisfies interfaces.
+var _ datasource.DataSource = &OverlayDiffDataSource{}
+
+func NewOverlayDiffDataSource() datasource.DataSource {
+	return &OverlayDiffDataSource{}
+}
+
+// OverlayDiffDataSource compares two overlay definitions, e.g. so a pipeline
+// can check that a proposed overlay matches an approved baseline
+type OverlayDiffDataSource struct {
+	client *client.Client
+}
+
+type OverlayDiffDataSourceModel struct {
+	LeftData       types.String `tfsdk:"left_data"`
+	LeftOverlayID  types.String `tfsdk:"left_overlay_id"`
+	RightData      types.String `tfsdk:"right_data"`
+	RightOverlayID types.String `tfsdk:"right_overlay_id"`
+	Equal          types.Bool   `tfsdk:"equal"`
+	Differences    types.List   `tfsdk:"differences"`
+}
+
+func (d *OverlayDiffDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_overlay_diff"
+}
+
+func (d *OverlayDiffDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Compares two Revos Cube Overlay definitions. Each side is given either as a JSON string or as the ID of an existing overlay. " +
+			"Key order and formatting are ignored.",
+		Attributes: map[string]schema.Attribute{
+			"left_data": schema.StringAttribute{
+				Optional:    true,
+				Description: "The JSON string representation of the first Cube definition. Exactly one of left_data and left_overlay_id must be set.",
+			},
+			"left_overlay_id": schema.StringAttribute{
+				Optional:    true,
+				Description: "The ID of the overlay whose definition is compared as the first one.",
+			},
+			"right_data": schema.StringAttribute{
+				Optional:    true,
+				Description: "The JSON string representation of the second Cube definition. Exactly one of right_data and right_overlay_id must be set.",
+			},
+			"right_overlay_id": schema.StringAttribute{
+				Optional:    true,
+				Description: "The ID of the overlay whose definition is compared as the second one.",
+			},
+			"equal": schema.BoolAttribute{
+				Computed:    true,
+				Description: "Whether both definitions encode the same JSON.",
+			},
+			"differences": schema.ListAttribute{
+				Computed:    true,
+				ElementType: types.StringType,
+				Description: "The sorted JSON Pointers of the values that differ. Keys present on only one side are included.",
+			},
+		},
+	}
+}
+
+func (d *OverlayDiffDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	providerData, ok := req.ProviderData.(*RevosProviderData)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *RevosProviderData, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+		return
+	}
+
+	d.client = providerData.Client
+}
+
+func (d *OverlayDiffDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data OverlayDiffDataSourceModel
+
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	left := d.definition(ctx, data.LeftData, data.LeftOverlayID, "left", &resp.Diagnostics)
+	right := d.definition(ctx, data.RightData, data.RightOverlayID, "right", &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	differences := jsonDiffPaths(left, right)
+	data.Equal = types.BoolValue(len(differences) == 0)
+
+	list, diags := types.ListValueFrom(ctx, types.StringType, append([]string{}, differences...))
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	data.Differences = list
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+// definition returns the decoded definition of one side of the comparison,
+// read from its data attribute or fetched by its overlay ID
+func (d *OverlayDiffDataSource) definition(ctx context.Context, data, overlayID types.String, side string, diags *diag.Diagnostics) interface{} {
+	dataAttribute, idAttribute := side+"_data", side+"_overlay_id"
+
+	var raw string
+	switch {
+	case !data.IsNull() && !overlayID.IsNull():
+		diags.AddAttributeError(path.Root(idAttribute), "Conflicting Definition Attributes",
+			fmt.Sprintf("Only one of %s and %s can be set.", dataAttribute, idAttribute))
+		return nil
+	case !data.IsNull():
+		raw = data.ValueString()
+	case !overlayID.IsNull():
+		overlay, err := d.client.GetOverlay(ctx, overlayID.ValueString())
+		if err != nil {
+			diags.AddAttributeError(path.Root(idAttribute), "Client Error",
+				fmt.Sprintf("Unable to read overlay %s, got error: %s", overlayID.ValueString(), err))
+			return nil
+		}
+		raw = string(overlay.Data)
+	default:
+		diags.AddAttributeError(path.Root(dataAttribute), "Missing Definition",
+			fmt.Sprintf("One of %s and %s must be set.", dataAttribute, idAttribute))
+		return nil
+	}
+
+	v, err := decodeJSON(raw)
+	if err != nil {
+		diags.AddAttributeError(path.Root(dataAttribute), "Invalid JSON",
+			fmt.Sprintf("Unable to parse the %s definition as JSON: %s", side, err))
+		return nil
+	}
+	return v
+}
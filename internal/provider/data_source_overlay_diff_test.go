@@ -0,0 +1,119 @@
+package provider
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+func TestOverlayDiffDataSource(t *testing.T) {
+	m := newMockRevosServer(t)
+	h := newMockHarness(t, m)
+
+	null := tftypes.NewValue(h.resourceSchema("revos_overlay").ValueType(), nil)
+	state, diags := h.apply("revos_overlay", null, map[string]interface{}{
+		"name": "baseline",
+		"data": `{"cubes":[{"name":"orders","sql_table":"orders"}]}`,
+	})
+	requireNoErrors(t, "create", diags)
+	baselineID := attrString(t, state, "id")
+
+	tests := []struct {
+		name        string
+		config      map[string]interface{}
+		equal       bool
+		differences []string
+	}{
+		{
+			name: "identical",
+			config: map[string]interface{}{
+				"left_data":  `{"a":1}`,
+				"right_data": `{"a":1}`,
+			},
+			equal:       true,
+			differences: []string{},
+		},
+		{
+			name: "reordered",
+			config: map[string]interface{}{
+				"left_data":  `{"a":1,"b":[1,2]}`,
+				"right_data": "{\n  \"b\": [1, 2],\n  \"a\": 1\n}",
+			},
+			equal:       true,
+			differences: []string{},
+		},
+		{
+			name: "different",
+			config: map[string]interface{}{
+				"left_data":  `{"b":1,"a":1}`,
+				"right_data": `{"b":2,"c":1}`,
+			},
+			differences: []string{"/a", "/b", "/c"},
+		},
+		{
+			name: "overlay ID",
+			config: map[string]interface{}{
+				"left_overlay_id": baselineID,
+				"right_data":      `{"cubes":[{"sql_table":"orders","name":"orders"}]}`,
+			},
+			equal:       true,
+			differences: []string{},
+		},
+		{
+			name: "overlay ID differs",
+			config: map[string]interface{}{
+				"left_overlay_id": baselineID,
+				"right_data":      `{"cubes":[{"name":"orders","sql_table":"orders_v2"}]}`,
+			},
+			differences: []string{"/cubes/0/sql_table"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state, diags := h.readDataSource("revos_overlay_diff", tt.config)
+			requireNoErrors(t, "read", diags)
+
+			if got := attrBool(t, state, "equal"); got != tt.equal {
+				t.Errorf("equal = %t, want %t", got, tt.equal)
+			}
+			if got := attrList(t, state, "differences"); fmt.Sprint(got) != fmt.Sprint(tt.differences) {
+				t.Errorf("differences = %v, want %v", got, tt.differences)
+			}
+		})
+	}
+
+	errorTests := []struct {
+		name     string
+		config   map[string]interface{}
+		expected string
+	}{
+		{
+			name:     "missing side",
+			config:   map[string]interface{}{"left_data": `{}`},
+			expected: "Missing Definition",
+		},
+		{
+			name: "conflicting attributes",
+			config: map[string]interface{}{
+				"left_data":       `{}`,
+				"left_overlay_id": baselineID,
+				"right_data":      `{}`,
+			},
+			expected: "Conflicting Definition Attributes",
+		},
+		{
+			name:     "invalid JSON",
+			config:   map[string]interface{}{"left_data": `{`, "right_data": `{}`},
+			expected: "Invalid JSON",
+		},
+	}
+
+	for _, tt := range errorTests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, diags := h.readDataSource("revos_overlay_diff", tt.config)
+			requireError(t, diags, tt.expected)
+		})
+	}
+}
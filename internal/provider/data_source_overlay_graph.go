@@ -0,0 +1,304 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/revosai/terraform-provider-revos/internal/client"
+)
+
+// Ensure implementation satisfies interfaces.
+var _ datasource.DataSource = &OverlayGraphDataSource{}
+
+func NewOverlayGraphDataSource() datasource.DataSource {
+	return &OverlayGraphDataSource{}
+}
+
+// OverlayGraphDataSource analyzes how the joins of the active overlays
+// reference cubes defined in other overlays, to find cycles and references
+// to cubes no overlay defines before they break queries
+type OverlayGraphDataSource struct {
+	client *client.Client
+}
+
+type OverlayGraphDataSourceModel struct {
+	Dependencies     types.List `tfsdk:"dependencies"`
+	Cycles           types.List `tfsdk:"cycles"`
+	OrphanReferences types.List `tfsdk:"orphan_references"`
+}
+
+// overlayDependencyAttrTypes are the attributes of an element of
+// dependencies
+var overlayDependencyAttrTypes = map[string]attr.Type{
+	"overlay_id":        types.StringType,
+	"cube":              types.StringType,
+	"join":              types.StringType,
+	"target_cube":       types.StringType,
+	"target_overlay_id": types.StringType,
+}
+
+// orphanReferenceAttrTypes are the attributes of an element of
+// orphan_references
+var orphanReferenceAttrTypes = map[string]attr.Type{
+	"overlay_id":  types.StringType,
+	"cube":        types.StringType,
+	"join":        types.StringType,
+	"target_cube": types.StringType,
+}
+
+func (d *OverlayGraphDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_overlay_graph"
+}
+
+func (d *OverlayGraphDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	joinAttributes := map[string]schema.Attribute{
+		"overlay_id": schema.StringAttribute{
+			Computed:    true,
+			Description: "The ID of the overlay with the join.",
+		},
+		"cube": schema.StringAttribute{
+			Computed:    true,
+			Description: "The cube the join belongs to.",
+		},
+		"join": schema.StringAttribute{
+			Computed:    true,
+			Description: "The name of the join.",
+		},
+		"target_cube": schema.StringAttribute{
+			Computed:    true,
+			Description: "The cube the join references by name or in its SQL.",
+		},
+	}
+	dependencyAttributes := map[string]schema.Attribute{
+		"target_overlay_id": schema.StringAttribute{
+			Computed:    true,
+			Description: "The ID of the overlay defining target_cube.",
+		},
+	}
+	for name, attribute := range joinAttributes {
+		dependencyAttributes[name] = attribute
+	}
+
+	resp.Schema = schema.Schema{
+		Description: "Builds the dependency graph of the active Revos Cube Overlays from joins referencing cubes defined in other overlays, " +
+			"and reports cycles and references to cubes no overlay defines.",
+		Attributes: map[string]schema.Attribute{
+			"dependencies": schema.ListNestedAttribute{
+				Computed:     true,
+				Description:  "The joins referencing a cube defined in another overlay. A cube defined in several overlays gives a dependency on each.",
+				NestedObject: schema.NestedAttributeObject{Attributes: dependencyAttributes},
+			},
+			"cycles": schema.ListAttribute{
+				Computed:    true,
+				ElementType: types.ListType{ElemType: types.StringType},
+				Description: "The groups of overlays that depend on each other in a cycle, each as its sorted overlay IDs.",
+			},
+			"orphan_references": schema.ListNestedAttribute{
+				Computed:     true,
+				Description:  "The joins referencing a cube that no overlay defines.",
+				NestedObject: schema.NestedAttributeObject{Attributes: joinAttributes},
+			},
+		},
+	}
+}
+
+func (d *OverlayGraphDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	providerData, ok := req.ProviderData.(*RevosProviderData)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *RevosProviderData, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+		return
+	}
+
+	d.client = providerData.Client
+}
+
+func (d *OverlayGraphDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data OverlayGraphDataSourceModel
+
+	overlays, err := d.client.ListOverlays(ctx)
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to list overlays, got error: %s", err))
+		return
+	}
+	graph := buildOverlayGraph(overlays)
+
+	dependencies := make([]attr.Value, 0, len(graph.dependencies))
+	for _, dep := range graph.dependencies {
+		element, diags := types.ObjectValue(overlayDependencyAttrTypes, map[string]attr.Value{
+			"overlay_id":        types.StringValue(dep.overlayID),
+			"cube":              types.StringValue(dep.ref.cube),
+			"join":              types.StringValue(dep.ref.name),
+			"target_cube":       types.StringValue(dep.ref.target),
+			"target_overlay_id": types.StringValue(dep.targetOverlayID),
+		})
+		resp.Diagnostics.Append(diags...)
+		dependencies = append(dependencies, element)
+	}
+	orphans := make([]attr.Value, 0, len(graph.orphans))
+	for _, orphan := range graph.orphans {
+		element, diags := types.ObjectValue(orphanReferenceAttrTypes, map[string]attr.Value{
+			"overlay_id":  types.StringValue(orphan.overlayID),
+			"cube":        types.StringValue(orphan.ref.cube),
+			"join":        types.StringValue(orphan.ref.name),
+			"target_cube": types.StringValue(orphan.ref.target),
+		})
+		resp.Diagnostics.Append(diags...)
+		orphans = append(orphans, element)
+	}
+
+	var diags diag.Diagnostics
+	data.Dependencies, diags = types.ListValue(types.ObjectType{AttrTypes: overlayDependencyAttrTypes}, dependencies)
+	resp.Diagnostics.Append(diags...)
+	data.OrphanReferences, diags = types.ListValue(types.ObjectType{AttrTypes: orphanReferenceAttrTypes}, orphans)
+	resp.Diagnostics.Append(diags...)
+	data.Cycles, diags = types.ListValueFrom(ctx, types.ListType{ElemType: types.StringType}, graph.cycles)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+// overlayDependency is a join of an overlay referencing a cube defined in
+// another overlay
+type overlayDependency struct {
+	overlayID       string
+	ref             joinReference
+	targetOverlayID string
+}
+
+// orphanReference is a join of an overlay referencing a cube no overlay
+// defines
+type orphanReference struct {
+	overlayID string
+	ref       joinReference
+}
+
+type overlayGraph struct {
+	dependencies []overlayDependency
+	orphans      []orphanReference
+	// cycles are the strongly connected components of more than one
+	// overlay, each sorted, ordered by their first overlay ID
+	cycles [][]string
+}
+
+// buildOverlayGraph builds the graph of the dependencies between overlays
+// through their joins. Joins referencing a cube of their own overlay are not
+// dependencies.
+func buildOverlayGraph(overlays []client.CubeOverlay) overlayGraph {
+	defined := make([]map[string]bool, len(overlays))
+	references := make([][]joinReference, len(overlays))
+	owners := map[string][]string{}
+	for i, overlay := range overlays {
+		defined[i], references[i] = joinReferences(migrateData(string(overlay.Data)))
+		cubes := make([]string, 0, len(defined[i]))
+		for cube := range defined[i] {
+			cubes = append(cubes, cube)
+		}
+		sort.Strings(cubes)
+		for _, cube := range cubes {
+			owners[cube] = append(owners[cube], overlay.ID)
+		}
+	}
+
+	var graph overlayGraph
+	edges := map[string]map[string]bool{}
+	for i, overlay := range overlays {
+		for _, ref := range references[i] {
+			if defined[i][ref.target] {
+				continue
+			}
+			if len(owners[ref.target]) == 0 {
+				graph.orphans = append(graph.orphans, orphanReference{overlayID: overlay.ID, ref: ref})
+				continue
+			}
+			for _, owner := range owners[ref.target] {
+				graph.dependencies = append(graph.dependencies, overlayDependency{overlayID: overlay.ID, ref: ref, targetOverlayID: owner})
+				if edges[overlay.ID] == nil {
+					edges[overlay.ID] = map[string]bool{}
+				}
+				edges[overlay.ID][owner] = true
+			}
+		}
+	}
+
+	ids := make([]string, 0, len(overlays))
+	for _, overlay := range overlays {
+		ids = append(ids, overlay.ID)
+	}
+	graph.cycles = stronglyConnected(ids, edges)
+	return graph
+}
+
+// stronglyConnected returns the strongly connected components of more than
+// one node of a directed graph, using Tarjan's algorithm. Each component is
+// sorted, and the components are ordered by their first node.
+func stronglyConnected(nodes []string, edges map[string]map[string]bool) [][]string {
+	index := map[string]int{}
+	lowlink := map[string]int{}
+	onStack := map[string]bool{}
+	var stack []string
+	components := [][]string{}
+
+	var visit func(node string)
+	visit = func(node string) {
+		index[node] = len(index)
+		lowlink[node] = index[node]
+		stack = append(stack, node)
+		onStack[node] = true
+
+		targets := make([]string, 0, len(edges[node]))
+		for target := range edges[node] {
+			targets = append(targets, target)
+		}
+		sort.Strings(targets)
+		for _, target := range targets {
+			if _, visited := index[target]; !visited {
+				visit(target)
+				lowlink[node] = min(lowlink[node], lowlink[target])
+			} else if onStack[target] {
+				lowlink[node] = min(lowlink[node], index[target])
+			}
+		}
+
+		if lowlink[node] != index[node] {
+			return
+		}
+		var component []string
+		for {
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[top] = false
+			component = append(component, top)
+			if top == node {
+				break
+			}
+		}
+		if len(component) > 1 {
+			sort.Strings(component)
+			components = append(components, component)
+		}
+	}
+
+	for _, node := range nodes {
+		if _, visited := index[node]; !visited {
+			visit(node)
+		}
+	}
+	sort.Slice(components, func(i, j int) bool { return components[i][0] < components[j][0] })
+	return components
+}
@@ -0,0 +1,152 @@
+package provider
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/revosai/terraform-provider-revos/internal/client"
+)
+
+func TestBuildOverlayGraph(t *testing.T) {
+	overlay := func(id, data string) client.CubeOverlay {
+		return client.CubeOverlay{ID: id, Data: []byte(data)}
+	}
+
+	tests := []struct {
+		name         string
+		overlays     []client.CubeOverlay
+		dependencies []string
+		orphans      []string
+		cycles       string
+	}{
+		{
+			name: "acyclic",
+			overlays: []client.CubeOverlay{
+				overlay("ov-1", `{"cubes":[{"name":"orders","joins":[{"name":"users","sql":"{CUBE}.user_id = {users}.id"},{"name":"line_items","sql":"{CUBE}.id = {line_items.order_id}"}]},{"name":"line_items"}]}`),
+				overlay("ov-2", `{"cubes":[{"name":"users","joins":{"accounts":{"sql":"{CUBE}.account_id = {accounts}.id"}}}]}`),
+				overlay("ov-3", `{"cubes":[{"name":"accounts"}]}`),
+			},
+			dependencies: []string{"ov-1 orders.users -> users (ov-2)", "ov-2 users.accounts -> accounts (ov-3)"},
+			cycles:       "[]",
+		},
+		{
+			name: "cyclic",
+			overlays: []client.CubeOverlay{
+				overlay("ov-1", `{"cubes":[{"name":"orders","joins":[{"name":"users"}]}]}`),
+				overlay("ov-2", `{"cubes":[{"name":"users","joins":[{"name":"accounts"}]}]}`),
+				overlay("ov-3", `{"cubes":[{"name":"accounts","joins":[{"name":"orders"}]}]}`),
+				overlay("ov-4", `{"cubes":[{"name":"payments","joins":[{"name":"refunds"}]}]}`),
+				overlay("ov-5", `{"cubes":[{"name":"refunds","joins":[{"name":"payments"},{"name":"orders"}]}]}`),
+			},
+			dependencies: []string{
+				"ov-1 orders.users -> users (ov-2)",
+				"ov-2 users.accounts -> accounts (ov-3)",
+				"ov-3 accounts.orders -> orders (ov-1)",
+				"ov-4 payments.refunds -> refunds (ov-5)",
+				"ov-5 refunds.payments -> payments (ov-4)",
+				"ov-5 refunds.orders -> orders (ov-1)",
+			},
+			cycles: "[[ov-1 ov-2 ov-3] [ov-4 ov-5]]",
+		},
+		{
+			name: "orphans and shared cubes",
+			overlays: []client.CubeOverlay{
+				overlay("ov-1", `{"cubes":[{"name":"orders","joins":[{"name":"customers"},{"name":"users","sql":"${schema}.users"}]}]}`),
+				overlay("ov-2", `{"cubes":[{"name":"users"}]}`),
+				overlay("ov-3", `{"cubes":[{"name":"users"}]}`),
+				overlay("ov-4", `not json`),
+			},
+			dependencies: []string{"ov-1 orders.users -> users (ov-2)", "ov-1 orders.users -> users (ov-3)"},
+			orphans:      []string{"ov-1 orders.customers -> customers"},
+			cycles:       "[]",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			graph := buildOverlayGraph(tt.overlays)
+
+			var dependencies []string
+			for _, dep := range graph.dependencies {
+				dependencies = append(dependencies, fmt.Sprintf("%s %s.%s -> %s (%s)", dep.overlayID, dep.ref.cube, dep.ref.name, dep.ref.target, dep.targetOverlayID))
+			}
+			if fmt.Sprint(dependencies) != fmt.Sprint(tt.dependencies) {
+				t.Errorf("dependencies = %v, want %v", dependencies, tt.dependencies)
+			}
+			var orphans []string
+			for _, orphan := range graph.orphans {
+				orphans = append(orphans, fmt.Sprintf("%s %s.%s -> %s", orphan.overlayID, orphan.ref.cube, orphan.ref.name, orphan.ref.target))
+			}
+			if fmt.Sprint(orphans) != fmt.Sprint(tt.orphans) {
+				t.Errorf("orphans = %v, want %v", orphans, tt.orphans)
+			}
+			if got := fmt.Sprint(graph.cycles); got != tt.cycles {
+				t.Errorf("cycles = %s, want %s", got, tt.cycles)
+			}
+		})
+	}
+}
+
+func TestOverlayGraphDataSource(t *testing.T) {
+	m := newMockRevosServer(t)
+	h := newMockHarness(t, m)
+
+	null := tftypes.NewValue(h.resourceSchema("revos_overlay").ValueType(), nil)
+	for _, overlay := range []struct{ name, data string }{
+		{"orders", `{"cubes":[{"name":"orders","joins":[{"name":"users"},{"name":"customers"}]}]}`},
+		{"users", `{"cubes":[{"name":"users","joins":[{"name":"orders"}]}]}`},
+	} {
+		_, diags := h.apply("revos_overlay", null, map[string]interface{}{
+			"name": overlay.name,
+			"data": overlay.data,
+		})
+		requireNoErrors(t, "create "+overlay.name, diags)
+	}
+
+	state, diags := h.readDataSource("revos_overlay_graph", map[string]interface{}{})
+	requireNoErrors(t, "read", diags)
+
+	var dependencies []tftypes.Value
+	if err := attrValue(t, state, "dependencies").As(&dependencies); err != nil {
+		t.Fatalf("dependencies: %s", err)
+	}
+	var got []string
+	for _, dep := range dependencies {
+		got = append(got, attrString(t, dep, "overlay_id")+" -> "+attrString(t, dep, "target_overlay_id"))
+	}
+	if fmt.Sprint(got) != "[ov-1 -> ov-2 ov-2 -> ov-1]" {
+		t.Errorf("dependencies = %v", got)
+	}
+
+	var orphans []tftypes.Value
+	if err := attrValue(t, state, "orphan_references").As(&orphans); err != nil {
+		t.Fatalf("orphan_references: %s", err)
+	}
+	if len(orphans) != 1 || attrString(t, orphans[0], "target_cube") != "customers" || attrString(t, orphans[0], "join") != "customers" {
+		t.Errorf("orphan_references = %v", orphans)
+	}
+
+	var cycles []tftypes.Value
+	if err := attrValue(t, state, "cycles").As(&cycles); err != nil {
+		t.Fatalf("cycles: %s", err)
+	}
+	if len(cycles) != 1 {
+		t.Fatalf("cycles = %v, want one", cycles)
+	}
+	var cycle []string
+	var members []tftypes.Value
+	if err := cycles[0].As(&members); err != nil {
+		t.Fatal(err)
+	}
+	for _, member := range members {
+		var id string
+		if err := member.As(&id); err != nil {
+			t.Fatal(err)
+		}
+		cycle = append(cycle, id)
+	}
+	if fmt.Sprint(cycle) != "[ov-1 ov-2]" {
+		t.Errorf("cycle = %v, want [ov-1 ov-2]", cycle)
+	}
+}
@@ -0,0 +1,126 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/revosai/terraform-provider-revos/internal/client"
+)
+
+// Ensure implementation satisfies interfaces.
+var _ datasource.DataSource = &OverlaySearchDataSource{}
+
+func NewOverlaySearchDataSource() datasource.DataSource {
+	return &OverlaySearchDataSource{}
+}
+
+// OverlaySearchDataSource searches the active overlays with the API's search
+// endpoint, so large organizations don't have to list every overlay
+type OverlaySearchDataSource struct {
+	client *client.Client
+}
+
+type OverlaySearchDataSourceModel struct {
+	NameContains types.String `tfsdk:"name_contains"`
+	CreatedBy    types.String `tfsdk:"created_by"`
+	CreatedAfter types.String `tfsdk:"created_after"`
+	Overlays     types.List   `tfsdk:"overlays"`
+}
+
+func (d *OverlaySearchDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_overlay_search"
+}
+
+func (d *OverlaySearchDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Searches Revos Cube Overlays. Archived overlays are not returned. Filters are combined, so an overlay must match all of them. " +
+			"If the API has no search endpoint, all overlays are listed and filtered by the provider.",
+		Attributes: map[string]schema.Attribute{
+			"name_contains": schema.StringAttribute{
+				Optional:    true,
+				Description: "Only return overlays whose name contains this string, ignoring case.",
+			},
+			"created_by": schema.StringAttribute{
+				Optional:    true,
+				Description: "Only return overlays created by this user.",
+			},
+			"created_after": schema.StringAttribute{
+				Optional:    true,
+				Description: "Only return overlays created after this time, in RFC 3339 format.",
+			},
+			"overlays": schema.ListNestedAttribute{
+				Computed:     true,
+				Description:  "The matching overlays, in the order the API returns them.",
+				NestedObject: overlaySummarySchema(),
+			},
+		},
+	}
+}
+
+func (d *OverlaySearchDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	providerData, ok := req.ProviderData.(*RevosProviderData)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *RevosProviderData, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+		return
+	}
+
+	d.client = providerData.Client
+}
+
+func (d *OverlaySearchDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data OverlaySearchDataSourceModel
+
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	params := client.OverlaySearchParams{
+		NameContains: data.NameContains.ValueString(),
+		CreatedBy:    data.CreatedBy.ValueString(),
+	}
+	if !data.CreatedAfter.IsNull() {
+		createdAfter, err := time.Parse(time.RFC3339, data.CreatedAfter.ValueString())
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("created_after"), "Invalid Created After",
+				fmt.Sprintf("created_after must be an RFC 3339 timestamp, e.g. 2024-01-02T15:04:05Z: %s", err))
+			return
+		}
+		params.CreatedAfter = createdAfter
+	}
+
+	overlays, err := d.client.SearchOverlays(ctx, params)
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to search overlays, got error: %s", err))
+		return
+	}
+
+	elements := make([]attr.Value, 0, len(overlays))
+	for _, overlay := range overlays {
+		element, diags := overlaySummaryValue(ctx, overlay)
+		resp.Diagnostics.Append(diags...)
+		elements = append(elements, element)
+	}
+
+	list, diags := types.ListValue(types.ObjectType{AttrTypes: overlaySummaryAttrTypes}, elements)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	data.Overlays = list
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
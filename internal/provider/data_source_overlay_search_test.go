@@ -0,0 +1,111 @@
+package provider
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+func TestOverlaySearchDataSource(t *testing.T) {
+	for _, search := range []bool{true, false} {
+		t.Run(fmt.Sprintf("search endpoint %t", search), func(t *testing.T) {
+			m := newMockRevosServer(t)
+			m.search = search
+			h := newMockHarness(t, m)
+
+			null := tftypes.NewValue(h.resourceSchema("revos_overlay").ValueType(), nil)
+			ids := map[string]string{}
+			for _, name := range []string{"Sales-Prod", "sales-dev", "finance-prod", "archived-sales"} {
+				state, diags := h.apply("revos_overlay", null, map[string]interface{}{
+					"name": name,
+					"data": `{"cubes":[]}`,
+				})
+				requireNoErrors(t, "create "+name, diags)
+				ids[name] = attrString(t, state, "id")
+			}
+			m.setOverlayField(ids["sales-dev"], "createdBy", "user-2")
+			m.setOverlayField(ids["finance-prod"], "createdAt", "2020-01-01T00:00:00Z")
+			m.setOverlayField(ids["archived-sales"], "archived", true)
+
+			tests := []struct {
+				name     string
+				config   map[string]interface{}
+				expected []string
+			}{
+				{
+					name:     "no filters",
+					config:   map[string]interface{}{},
+					expected: []string{"Sales-Prod", "sales-dev", "finance-prod"},
+				},
+				{
+					name:     "name contains ignores case",
+					config:   map[string]interface{}{"name_contains": "SALES"},
+					expected: []string{"Sales-Prod", "sales-dev"},
+				},
+				{
+					name:     "created by",
+					config:   map[string]interface{}{"created_by": "user-1"},
+					expected: []string{"Sales-Prod", "finance-prod"},
+				},
+				{
+					name:     "created after",
+					config:   map[string]interface{}{"created_after": "2021-01-01T00:00:00Z"},
+					expected: []string{"Sales-Prod", "sales-dev"},
+				},
+				{
+					name:     "all filters must match",
+					config:   map[string]interface{}{"name_contains": "prod", "created_by": "user-1", "created_after": "2021-01-01T00:00:00Z"},
+					expected: []string{"Sales-Prod"},
+				},
+				{
+					name:     "no match",
+					config:   map[string]interface{}{"name_contains": "marketing"},
+					expected: []string{},
+				},
+			}
+
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					state, diags := h.readDataSource("revos_overlay_search", tt.config)
+					requireNoErrors(t, "read", diags)
+
+					var elems []tftypes.Value
+					if err := attrValue(t, state, "overlays").As(&elems); err != nil {
+						t.Fatalf("overlays: %s", err)
+					}
+					names := []string{}
+					for _, elem := range elems {
+						names = append(names, attrString(t, elem, "name"))
+					}
+					if fmt.Sprint(names) != fmt.Sprint(tt.expected) {
+						t.Errorf("overlays = %v, want %v", names, tt.expected)
+					}
+				})
+			}
+
+			searches := m.requestCount("GET", "/cube-overlays/search")
+			lists := m.requestCount("GET", "/cube-overlays")
+			if searches != len(tests) {
+				t.Errorf("search requests = %d, want %d", searches, len(tests))
+			}
+			if search && lists != 0 {
+				t.Errorf("list requests = %d, want none when the API searches", lists)
+			}
+			if !search && lists == 0 {
+				t.Error("no list requests, want the overlays listed when falling back")
+			}
+		})
+	}
+}
+
+func TestOverlaySearchDataSource_InvalidCreatedAfter(t *testing.T) {
+	m := newMockRevosServer(t)
+	h := newMockHarness(t, m)
+
+	_, diags := h.readDataSource("revos_overlay_search", map[string]interface{}{"created_after": "yesterday"})
+	requireError(t, diags, "Invalid Created After")
+	if count := m.requestCount("GET", "/cube-overlays/search"); count != 0 {
+		t.Errorf("search requests = %d, want none", count)
+	}
+}
@@ -0,0 +1,120 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/revosai/terraform-provider-revos/internal/client"
+)
+
+// Ensure implementation satisfies interfaces.
+var _ datasource.DataSource = &OverlayValidationDataSource{}
+
+func NewOverlayValidationDataSource() datasource.DataSource {
+	return &OverlayValidationDataSource{}
+}
+
+// OverlayValidationDataSource validates an overlay definition against the
+// API without creating an overlay
+type OverlayValidationDataSource struct {
+	client *client.Client
+}
+
+type OverlayValidationDataSourceModel struct {
+	Data     types.String `tfsdk:"data"`
+	Valid    types.Bool   `tfsdk:"valid"`
+	Errors   types.List   `tfsdk:"errors"`
+	Warnings types.List   `tfsdk:"warnings"`
+}
+
+func (d *OverlayValidationDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_overlay_validation"
+}
+
+func (d *OverlayValidationDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Validates a Revos Cube Overlay definition without creating an overlay.",
+		Attributes: map[string]schema.Attribute{
+			"data": schema.StringAttribute{
+				Required:    true,
+				Description: "The JSON string representation of the Cube definition to validate.",
+			},
+			"valid": schema.BoolAttribute{
+				Computed:    true,
+				Description: "Whether the definition is valid.",
+			},
+			"errors": schema.ListAttribute{
+				Computed:    true,
+				ElementType: types.StringType,
+				Description: "The problems that make the definition invalid.",
+			},
+			"warnings": schema.ListAttribute{
+				Computed:    true,
+				ElementType: types.StringType,
+				Description: "Problems that don't make the definition invalid.",
+			},
+		},
+	}
+}
+
+func (d *OverlayValidationDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	providerData, ok := req.ProviderData.(*RevosProviderData)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *RevosProviderData, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+		return
+	}
+
+	d.client = providerData.Client
+}
+
+func (d *OverlayValidationDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data OverlayValidationDataSourceModel
+
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	rawData, err := parseOverlayData(data.Data.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("data"), "Invalid JSON in data", err.Error())
+		return
+	}
+
+	validation, err := d.client.ValidateOverlay(ctx, rawData)
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to validate overlay, got error: %s", err))
+		return
+	}
+
+	data.Valid = types.BoolValue(validation.Valid)
+
+	var diags diag.Diagnostics
+	data.Errors, diags = types.ListValueFrom(ctx, types.StringType, nonNil(validation.Errors))
+	resp.Diagnostics.Append(diags...)
+	data.Warnings, diags = types.ListValueFrom(ctx, types.StringType, nonNil(validation.Warnings))
+	resp.Diagnostics.Append(diags...)
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+// nonNil returns an empty slice for nil, so the list attribute is empty rather
+// than null
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
@@ -0,0 +1,85 @@
+package provider
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestOverlayValidationDataSource(t *testing.T) {
+	m := newMockRevosServer(t)
+	h := newMockHarness(t, m)
+
+	tests := []struct {
+		name             string
+		data             string
+		expectedValid    bool
+		expectedErrors   []string
+		expectedWarnings []string
+	}{
+		{
+			name:             "valid",
+			data:             `{"cubes":[{"name":"orders"}]}`,
+			expectedValid:    true,
+			expectedErrors:   []string{},
+			expectedWarnings: []string{},
+		},
+		{
+			name:             "valid with warnings",
+			data:             `{"cubes":[],"extra":true}`,
+			expectedValid:    true,
+			expectedErrors:   []string{},
+			expectedWarnings: []string{"extra: unknown key"},
+		},
+		{
+			name:             "invalid",
+			data:             `{"cubes":"orders"}`,
+			expectedValid:    false,
+			expectedErrors:   []string{"cubes: must be an array"},
+			expectedWarnings: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state, diags := h.readDataSource("revos_overlay_validation", map[string]interface{}{
+				"data": tt.data,
+			})
+			requireNoErrors(t, "read", diags)
+
+			var valid bool
+			if err := attrValue(t, state, "valid").As(&valid); err != nil {
+				t.Fatalf("valid: %s", err)
+			}
+			if valid != tt.expectedValid {
+				t.Errorf("valid = %v, want %v", valid, tt.expectedValid)
+			}
+			if got := attrList(t, state, "errors"); !reflect.DeepEqual(got, tt.expectedErrors) {
+				t.Errorf("errors = %v, want %v", got, tt.expectedErrors)
+			}
+			if got := attrList(t, state, "warnings"); !reflect.DeepEqual(got, tt.expectedWarnings) {
+				t.Errorf("warnings = %v, want %v", got, tt.expectedWarnings)
+			}
+		})
+	}
+
+	// Nothing is cached: every read validates again
+	if got := m.requestCount("POST", "/cube-overlays/validate"); got != len(tests) {
+		t.Errorf("validate requests = %d, want %d", got, len(tests))
+	}
+	if got := m.overlayCount(); got != 0 {
+		t.Errorf("overlay count = %d, want 0", got)
+	}
+}
+
+func TestOverlayValidationDataSource_InvalidJSON(t *testing.T) {
+	m := newMockRevosServer(t)
+	h := newMockHarness(t, m)
+
+	_, diags := h.readDataSource("revos_overlay_validation", map[string]interface{}{
+		"data": `{"cubes":`,
+	})
+	requireError(t, diags, "Invalid JSON in data")
+	if got := m.requestCount("POST", "/cube-overlays/validate"); got != 0 {
+		t.Errorf("validate requests = %d, want 0", got)
+	}
+}
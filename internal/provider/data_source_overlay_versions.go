@@ -0,0 +1,140 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/revosai/terraform-provider-revos/internal/client"
+)
+
+// Ensure implementation satisfies interfaces.
+var _ datasource.DataSource = &OverlayVersionsDataSource{}
+
+func NewOverlayVersionsDataSource() datasource.DataSource {
+	return &OverlayVersionsDataSource{}
+}
+
+// OverlayVersionsDataSource lists the revisions of an overlay, for auditing
+// who changed it and when
+type OverlayVersionsDataSource struct {
+	client *client.Client
+}
+
+type OverlayVersionsDataSourceModel struct {
+	OverlayID types.String `tfsdk:"overlay_id"`
+	Versions  types.List   `tfsdk:"versions"`
+}
+
+// overlayVersionAttrTypes are the attributes of an element of versions
+var overlayVersionAttrTypes = map[string]attr.Type{
+	"version_id": types.StringType,
+	"created_at": types.StringType,
+	"created_by": types.StringType,
+}
+
+func (d *OverlayVersionsDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_overlay_versions"
+}
+
+func (d *OverlayVersionsDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Lists the revisions of a Revos Cube Overlay. The list is empty if the API doesn't track revisions.",
+		Attributes: map[string]schema.Attribute{
+			"overlay_id": schema.StringAttribute{
+				Required:    true,
+				Description: "The ID of the overlay.",
+			},
+			"versions": schema.ListNestedAttribute{
+				Computed:    true,
+				Description: "The revisions of the overlay, as returned by the API.",
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"version_id": schema.StringAttribute{
+							Computed:    true,
+							Description: "The ID of the revision.",
+						},
+						"created_at": schema.StringAttribute{
+							Computed:    true,
+							Description: "When the revision was made.",
+						},
+						"created_by": schema.StringAttribute{
+							Computed:    true,
+							Description: "The user who made the revision.",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func (d *OverlayVersionsDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	providerData, ok := req.ProviderData.(*RevosProviderData)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *RevosProviderData, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+		return
+	}
+
+	d.client = providerData.Client
+}
+
+func (d *OverlayVersionsDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data OverlayVersionsDataSourceModel
+
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	overlayID := data.OverlayID.ValueString()
+	versions, err := d.client.ListOverlayVersions(ctx, overlayID)
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to list overlay versions, got error: %s", err))
+		return
+	}
+
+	// No versions may also mean there is no such overlay
+	if len(versions) == 0 {
+		if _, err := d.client.GetOverlay(ctx, overlayID); err != nil {
+			if client.IsNotFound(err) {
+				resp.Diagnostics.AddAttributeError(path.Root("overlay_id"), "Overlay Not Found",
+					fmt.Sprintf("No overlay has the ID %q.", overlayID))
+				return
+			}
+			resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to read overlay, got error: %s", err))
+			return
+		}
+	}
+
+	elements := make([]attr.Value, 0, len(versions))
+	for _, version := range versions {
+		element, diags := types.ObjectValue(overlayVersionAttrTypes, map[string]attr.Value{
+			"version_id": types.StringValue(version.VersionID),
+			"created_at": types.StringValue(version.CreatedAt),
+			"created_by": types.StringValue(version.CreatedBy),
+		})
+		resp.Diagnostics.Append(diags...)
+		elements = append(elements, element)
+	}
+
+	list, diags := types.ListValue(types.ObjectType{AttrTypes: overlayVersionAttrTypes}, elements)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	data.Versions = list
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
@@ -0,0 +1,74 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+func TestOverlayVersionsDataSource(t *testing.T) {
+	for _, versioning := range []bool{true, false} {
+		name := "versioning disabled"
+		if versioning {
+			name = "versioning enabled"
+		}
+		t.Run(name, func(t *testing.T) {
+			m := newMockRevosServer(t)
+			m.versioning = versioning
+			h := newMockHarness(t, m)
+
+			null := tftypes.NewValue(h.resourceSchema("revos_overlay").ValueType(), nil)
+			config := map[string]interface{}{
+				"name": "audited",
+				"data": `{"a":1}`,
+			}
+			state, diags := h.apply("revos_overlay", null, config)
+			requireNoErrors(t, "create", diags)
+			config["data"] = `{"a":2}`
+			_, diags = h.apply("revos_overlay", state, config)
+			requireNoErrors(t, "update", diags)
+
+			id := attrString(t, state, "id")
+			versions, diags := h.readDataSource("revos_overlay_versions", map[string]interface{}{
+				"overlay_id": id,
+			})
+			requireNoErrors(t, "read", diags)
+
+			var elems []tftypes.Value
+			if err := attrValue(t, versions, "versions").As(&elems); err != nil {
+				t.Fatalf("versions: %s", err)
+			}
+			if !versioning {
+				if len(elems) != 0 {
+					t.Errorf("got %d versions, want none", len(elems))
+				}
+				return
+			}
+
+			if len(elems) != 2 {
+				t.Fatalf("got %d versions, want 2", len(elems))
+			}
+			for i, want := range []string{id + "-v1", id + "-v2"} {
+				if got := attrString(t, elems[i], "version_id"); got != want {
+					t.Errorf("versions[%d].version_id = %q, want %q", i, got, want)
+				}
+				if got := attrString(t, elems[i], "created_by"); got != "user-1" {
+					t.Errorf("versions[%d].created_by = %q, want user-1", i, got)
+				}
+				if attrString(t, elems[i], "created_at") == "" {
+					t.Errorf("versions[%d].created_at is empty", i)
+				}
+			}
+		})
+	}
+
+	t.Run("missing overlay", func(t *testing.T) {
+		m := newMockRevosServer(t)
+		h := newMockHarness(t, m)
+
+		_, diags := h.readDataSource("revos_overlay_versions", map[string]interface{}{
+			"overlay_id": "ov-404",
+		})
+		requireError(t, diags, "Overlay Not Found")
+	})
+}
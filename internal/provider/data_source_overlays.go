@@ -0,0 +1,199 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/revosai/terraform-provider-revos/internal/client"
+)
+
+// Ensure implementation satisfies interfaces.
+var _ datasource.DataSource = &OverlaysDataSource{}
+
+func NewOverlaysDataSource() datasource.DataSource {
+	return &OverlaysDataSource{}
+}
+
+// OverlaysDataSource lists the active overlays, optionally filtered, e.g. to
+// generate import blocks for existing overlays
+type OverlaysDataSource struct {
+	client *client.Client
+}
+
+type OverlaysDataSourceModel struct {
+	NameRegex types.String `tfsdk:"name_regex"`
+	Tags      types.Map    `tfsdk:"tags"`
+	Overlays  types.List   `tfsdk:"overlays"`
+}
+
+// overlaySummaryAttrTypes are the attributes of an element of overlays
+var overlaySummaryAttrTypes = map[string]attr.Type{
+	"id":              types.StringType,
+	"name":            types.StringType,
+	"description":     types.StringType,
+	"organization_id": types.StringType,
+	"tags":            types.MapType{ElemType: types.StringType},
+}
+
+func (d *OverlaysDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_overlays"
+}
+
+func (d *OverlaysDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Lists Revos Cube Overlays. Archived overlays are not listed. Filters are combined, so an overlay must match all of them.",
+		Attributes: map[string]schema.Attribute{
+			"name_regex": schema.StringAttribute{
+				Optional:    true,
+				Description: "Only list overlays whose name matches this regular expression (RE2 syntax).",
+			},
+			"tags": schema.MapAttribute{
+				Optional:    true,
+				ElementType: types.StringType,
+				Description: "Only list overlays that have all of these tags with these values.",
+			},
+			"overlays": schema.ListNestedAttribute{
+				Computed:     true,
+				Description:  "The matching overlays, in the order the API returns them.",
+				NestedObject: overlaySummarySchema(),
+			},
+		},
+	}
+}
+
+func (d *OverlaysDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	providerData, ok := req.ProviderData.(*RevosProviderData)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *RevosProviderData, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+		return
+	}
+
+	d.client = providerData.Client
+}
+
+func (d *OverlaysDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data OverlaysDataSourceModel
+
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var nameRegex *regexp.Regexp
+	if !data.NameRegex.IsNull() {
+		var err error
+		nameRegex, err = regexp.Compile(data.NameRegex.ValueString())
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("name_regex"), "Invalid Name Regex",
+				fmt.Sprintf("Unable to compile name_regex: %s", err))
+			return
+		}
+	}
+
+	var tags map[string]string
+	if !data.Tags.IsNull() {
+		resp.Diagnostics.Append(data.Tags.ElementsAs(ctx, &tags, false)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	overlays, err := d.client.ListOverlays(ctx)
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to list overlays, got error: %s", err))
+		return
+	}
+
+	elements := make([]attr.Value, 0, len(overlays))
+	for _, overlay := range overlays {
+		if nameRegex != nil && !nameRegex.MatchString(overlay.Name) {
+			continue
+		}
+		if !hasTags(overlay.Tags, tags) {
+			continue
+		}
+
+		element, diags := overlaySummaryValue(ctx, overlay)
+		resp.Diagnostics.Append(diags...)
+		elements = append(elements, element)
+	}
+
+	list, diags := types.ListValue(types.ObjectType{AttrTypes: overlaySummaryAttrTypes}, elements)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	data.Overlays = list
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+// overlaySummarySchema is the schema of an element of overlays
+func overlaySummarySchema() schema.NestedAttributeObject {
+	return schema.NestedAttributeObject{
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed:    true,
+				Description: "The ID of the overlay.",
+			},
+			"name": schema.StringAttribute{
+				Computed:    true,
+				Description: "The name of the overlay.",
+			},
+			"description": schema.StringAttribute{
+				Computed:    true,
+				Description: "The description of the overlay.",
+			},
+			"organization_id": schema.StringAttribute{
+				Computed:    true,
+				Description: "The organization the overlay belongs to.",
+			},
+			"tags": schema.MapAttribute{
+				Computed:    true,
+				ElementType: types.StringType,
+				Description: "The tags of the overlay.",
+			},
+		},
+	}
+}
+
+// overlaySummaryValue returns the element of overlays for an overlay
+func overlaySummaryValue(ctx context.Context, overlay client.CubeOverlay) (attr.Value, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	overlayTags, d := types.MapValueFrom(ctx, types.StringType, overlay.Tags)
+	diags.Append(d...)
+	element, d := types.ObjectValue(overlaySummaryAttrTypes, map[string]attr.Value{
+		"id":              types.StringValue(overlay.ID),
+		"name":            types.StringValue(overlay.Name),
+		"description":     types.StringValue(overlay.Description),
+		"organization_id": types.StringValue(overlay.OrganizationID),
+		"tags":            overlayTags,
+	})
+	diags.Append(d...)
+	return element, diags
+}
+
+// hasTags reports whether tags contains every key of want with the same value
+func hasTags(tags, want map[string]string) bool {
+	for k, v := range want {
+		if got, ok := tags[k]; !ok || got != v {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,92 @@
+package provider
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+func TestOverlaysDataSource(t *testing.T) {
+	m := newMockRevosServer(t)
+	h := newMockHarness(t, m)
+
+	null := tftypes.NewValue(h.resourceSchema("revos_overlay").ValueType(), nil)
+	for _, overlay := range []struct {
+		name string
+		tags map[string]interface{}
+	}{
+		{name: "sales-prod", tags: map[string]interface{}{"team": "sales", "env": "prod"}},
+		{name: "sales-dev", tags: map[string]interface{}{"team": "sales", "env": "dev"}},
+		{name: "finance-prod", tags: map[string]interface{}{"team": "finance", "env": "prod"}},
+		{name: "untagged"},
+	} {
+		_, diags := h.apply("revos_overlay", null, map[string]interface{}{
+			"name": overlay.name,
+			"data": `{"cubes":[]}`,
+			"tags": overlay.tags,
+		})
+		requireNoErrors(t, "create "+overlay.name, diags)
+	}
+
+	tests := []struct {
+		name     string
+		config   map[string]interface{}
+		expected []string
+	}{
+		{
+			name:     "no filters",
+			config:   map[string]interface{}{},
+			expected: []string{"sales-prod", "sales-dev", "finance-prod", "untagged"},
+		},
+		{
+			name:     "name regex",
+			config:   map[string]interface{}{"name_regex": "^sales-"},
+			expected: []string{"sales-prod", "sales-dev"},
+		},
+		{
+			name:     "single tag",
+			config:   map[string]interface{}{"tags": map[string]interface{}{"env": "prod"}},
+			expected: []string{"sales-prod", "finance-prod"},
+		},
+		{
+			name:     "all tags must match",
+			config:   map[string]interface{}{"tags": map[string]interface{}{"team": "sales", "env": "prod"}},
+			expected: []string{"sales-prod"},
+		},
+		{
+			name:     "name regex and tags",
+			config:   map[string]interface{}{"name_regex": "prod$", "tags": map[string]interface{}{"team": "finance"}},
+			expected: []string{"finance-prod"},
+		},
+		{
+			name:     "no match",
+			config:   map[string]interface{}{"name_regex": "^sales-", "tags": map[string]interface{}{"team": "finance"}},
+			expected: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state, diags := h.readDataSource("revos_overlays", tt.config)
+			requireNoErrors(t, "read", diags)
+
+			var elems []tftypes.Value
+			if err := attrValue(t, state, "overlays").As(&elems); err != nil {
+				t.Fatalf("overlays: %s", err)
+			}
+			names := []string{}
+			for _, elem := range elems {
+				names = append(names, attrString(t, elem, "name"))
+			}
+			if fmt.Sprint(names) != fmt.Sprint(tt.expected) {
+				t.Errorf("overlays = %v, want %v", names, tt.expected)
+			}
+		})
+	}
+
+	t.Run("invalid regex", func(t *testing.T) {
+		_, diags := h.readDataSource("revos_overlays", map[string]interface{}{"name_regex": "("})
+		requireError(t, diags, "Invalid Name Regex")
+	})
+}
@@ -0,0 +1,252 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/revosai/terraform-provider-revos/internal/client"
+)
+
+// fakeOverlayAPI is an in-memory client.OverlayAPI for testing resource
+// logic without HTTP. Setting err makes every call fail with it.
+type fakeOverlayAPI struct {
+	overlays map[string]*client.CubeOverlay
+	err      error
+	calls    []string
+}
+
+var _ client.OverlayAPI = (*fakeOverlayAPI)(nil)
+
+func newFakeOverlayAPI(overlays ...client.CubeOverlay) *fakeOverlayAPI {
+	f := &fakeOverlayAPI{overlays: map[string]*client.CubeOverlay{}}
+	for i := range overlays {
+		f.overlays[overlays[i].ID] = &overlays[i]
+	}
+	return f
+}
+
+func (f *fakeOverlayAPI) CreateOverlay(ctx context.Context, payload client.OverlayPayload) (*client.CubeOverlay, error) {
+	f.calls = append(f.calls, "CreateOverlay")
+	if f.err != nil {
+		return nil, f.err
+	}
+	overlay := &client.CubeOverlay{
+		ID:             fmt.Sprintf("ov-%d", len(f.overlays)+1),
+		Name:           payload.Name,
+		Description:    payload.Description,
+		OrganizationID: "org-1",
+		Data:           payload.Data,
+		Tags:           payload.Tags,
+		Enabled:        payload.Enabled,
+		Version:        "1",
+	}
+	f.overlays[overlay.ID] = overlay
+	return overlay, nil
+}
+
+func (f *fakeOverlayAPI) GetOverlay(ctx context.Context, id string) (*client.CubeOverlay, error) {
+	f.calls = append(f.calls, "GetOverlay")
+	if f.err != nil {
+		return nil, f.err
+	}
+	overlay, ok := f.overlays[id]
+	if !ok {
+		return nil, &client.APIError{StatusCode: 404, Body: "Not Found"}
+	}
+	return overlay, nil
+}
+
+func (f *fakeOverlayAPI) GetCreatedOverlay(ctx context.Context, id string) (*client.CubeOverlay, error) {
+	f.calls = append(f.calls, "GetCreatedOverlay")
+	if f.err != nil {
+		return nil, f.err
+	}
+	overlay, ok := f.overlays[id]
+	if !ok {
+		return nil, &client.APIError{StatusCode: 404, Body: "Not Found"}
+	}
+	return overlay, nil
+}
+
+func (f *fakeOverlayAPI) GetOverlayIfModified(ctx context.Context, id string, version client.Version) (*client.CubeOverlay, bool, error) {
+	overlay, err := f.GetOverlay(ctx, id)
+	return overlay, true, err
+}
+
+func (f *fakeOverlayAPI) GetOverlayByName(ctx context.Context, name string) (*client.CubeOverlay, error) {
+	f.calls = append(f.calls, "GetOverlayByName")
+	if f.err != nil {
+		return nil, f.err
+	}
+	for _, overlay := range f.overlays {
+		if overlay.Name == name {
+			return overlay, nil
+		}
+	}
+	return nil, &client.NotFoundError{Name: name}
+}
+
+func (f *fakeOverlayAPI) GetCreatedOverlayByName(ctx context.Context, name string) (*client.CubeOverlay, error) {
+	f.calls = append(f.calls, "GetCreatedOverlayByName")
+	if f.err != nil {
+		return nil, f.err
+	}
+	for _, overlay := range f.overlays {
+		if overlay.Name == name && !overlay.Archived {
+			return overlay, nil
+		}
+	}
+	return nil, &client.NotFoundError{Name: name}
+}
+
+func (f *fakeOverlayAPI) GetOverlayByOrganizationAndName(ctx context.Context, organizationID, name string) (*client.CubeOverlay, error) {
+	f.calls = append(f.calls, "GetOverlayByOrganizationAndName")
+	if f.err != nil {
+		return nil, f.err
+	}
+	for _, overlay := range f.overlays {
+		if overlay.OrganizationID == organizationID && overlay.Name == name {
+			return overlay, nil
+		}
+	}
+	return nil, &client.NotFoundError{Name: name, OrganizationID: organizationID}
+}
+
+func (f *fakeOverlayAPI) UpdateOverlay(ctx context.Context, id string, payload client.OverlayPayload, precondition client.Precondition) (*client.CubeOverlay, error) {
+	f.calls = append(f.calls, "UpdateOverlay")
+	if f.err != nil {
+		return nil, f.err
+	}
+	overlay, ok := f.overlays[id]
+	if !ok {
+		return nil, &client.APIError{StatusCode: 404, Body: "Not Found"}
+	}
+	overlay.Name = payload.Name
+	overlay.Description = payload.Description
+	overlay.Data = payload.Data
+	overlay.Tags = payload.Tags
+	if payload.Enabled != nil {
+		overlay.Enabled = payload.Enabled
+	}
+	return overlay, nil
+}
+
+func (f *fakeOverlayAPI) DeleteOverlay(ctx context.Context, id string, precondition client.Precondition) error {
+	f.calls = append(f.calls, "DeleteOverlay")
+	if f.err != nil {
+		return f.err
+	}
+	if _, ok := f.overlays[id]; !ok {
+		return &client.APIError{StatusCode: 404, Body: "Not Found"}
+	}
+	delete(f.overlays, id)
+	return nil
+}
+
+func (f *fakeOverlayAPI) ArchiveOverlay(ctx context.Context, id string, precondition client.Precondition) error {
+	f.calls = append(f.calls, "ArchiveOverlay")
+	if f.err != nil {
+		return f.err
+	}
+	overlay, ok := f.overlays[id]
+	if !ok {
+		return &client.APIError{StatusCode: 404, Body: "Not Found"}
+	}
+	overlay.Archived = true
+	return nil
+}
+
+func (f *fakeOverlayAPI) LockOverlay(ctx context.Context, id string, precondition client.Precondition) (*client.CubeOverlay, error) {
+	f.calls = append(f.calls, "LockOverlay")
+	return f.setLocked(id, true)
+}
+
+func (f *fakeOverlayAPI) UnlockOverlay(ctx context.Context, id string, precondition client.Precondition) (*client.CubeOverlay, error) {
+	f.calls = append(f.calls, "UnlockOverlay")
+	return f.setLocked(id, false)
+}
+
+func (f *fakeOverlayAPI) setLocked(id string, locked bool) (*client.CubeOverlay, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	overlay, ok := f.overlays[id]
+	if !ok {
+		return nil, &client.APIError{StatusCode: 404, Body: "Not Found"}
+	}
+	overlay.Locked = locked
+	result := *overlay
+	return &result, nil
+}
+
+func (f *fakeOverlayAPI) ListOverlays(ctx context.Context) ([]client.CubeOverlay, error) {
+	f.calls = append(f.calls, "ListOverlays")
+	if f.err != nil {
+		return nil, f.err
+	}
+	list := make([]client.CubeOverlay, 0, len(f.overlays))
+	for _, overlay := range f.overlays {
+		list = append(list, *overlay)
+	}
+	return list, nil
+}
+
+// overlayState builds resource state holding the given model.
+func overlayState(t *testing.T, r *OverlayResource, model *OverlayResourceModel) tfsdk.State {
+	t.Helper()
+
+	ctx := context.Background()
+	var schemaResp resource.SchemaResponse
+	r.Schema(ctx, resource.SchemaRequest{}, &schemaResp)
+
+	state := tfsdk.State{
+		Schema: schemaResp.Schema,
+		Raw:    tftypes.NewValue(schemaResp.Schema.Type().TerraformType(ctx), nil),
+	}
+	if model != nil {
+		if diags := state.Set(ctx, model); diags.HasError() {
+			t.Fatalf("setting state: %v", diags)
+		}
+	}
+	return state
+}
+
+func testOverlayModel(id string) *OverlayResourceModel {
+	return &OverlayResourceModel{
+		ID:              types.StringValue(id),
+		Name:            types.StringValue("sales"),
+		NamePrefix:      types.StringNull(),
+		Enabled:         types.BoolValue(true),
+		Description:     types.StringNull(),
+		OrganizationID:  types.StringValue("org-1"),
+		Data:            NewNormalizedJSONValue(`{"a":1}`),
+		DataVars:        types.MapNull(types.StringType),
+		DataFormat:      types.StringValue(dataFormatJSON),
+		DeletionMode:    types.StringValue(deletionModeDelete),
+		DependsOn:       types.ListNull(types.StringType),
+		IgnoreDataPaths: types.ListNull(types.StringType),
+		CreatedBy:       types.StringValue("user-1"),
+		CreatedAt:       types.StringValue("2024-01-01T00:00:00Z"),
+		UpdatedAt:       types.StringValue("2024-01-01T00:00:00Z"),
+		Version:         types.StringValue("1"),
+		Tags:            types.MapNull(types.StringType),
+		TagsAll:         types.MapNull(types.StringType),
+		DataHash:        types.StringNull(),
+		CubeNames:       types.ListNull(types.StringType),
+		Timeouts: timeouts.Value{
+			Object: types.ObjectNull(map[string]attr.Type{
+				"create": types.StringType,
+				"read":   types.StringType,
+				"update": types.StringType,
+				"delete": types.StringType,
+			}),
+		},
+	}
+}
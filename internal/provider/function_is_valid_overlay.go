@@ -0,0 +1,75 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/function"
+)
+
+// Ensure implementation satisfies interfaces.
+var _ function.Function = &IsValidOverlayFunction{}
+
+func NewIsValidOverlayFunction() function.Function {
+	return &IsValidOverlayFunction{}
+}
+
+// IsValidOverlayFunction checks an overlay definition locally, so modules can
+// reject bad inputs in preconditions before any resource is planned
+type IsValidOverlayFunction struct{}
+
+func (f *IsValidOverlayFunction) Metadata(ctx context.Context, req function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "is_valid_overlay"
+}
+
+func (f *IsValidOverlayFunction) Definition(ctx context.Context, req function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary: "Checks the structure of an overlay definition",
+		Description: "Returns whether the JSON string is an object the revos_overlay resource accepts as data, with a cubes list " +
+			"whose entries are objects with a name. It doesn't contact the API; use the revos_overlay_validation data source for a full check.",
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:        "input",
+				Description: "The JSON string representation of the Cube definition.",
+			},
+		},
+		Return: function.BoolReturn{},
+	}
+}
+
+func (f *IsValidOverlayFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var input string
+
+	resp.Error = function.ConcatFuncErrors(resp.Error, req.Arguments.Get(ctx, &input))
+	if resp.Error != nil {
+		return
+	}
+
+	resp.Error = function.ConcatFuncErrors(resp.Error, resp.Result.Set(ctx, checkOverlayShape(input) == nil))
+}
+
+// checkOverlayShape checks that s is overlay data the resource accepts and
+// that it has the minimal Cube structure: a cubes list of named objects
+func checkOverlayShape(s string) error {
+	rawData, err := parseOverlayData(s)
+	if err != nil {
+		return err
+	}
+
+	var overlay struct {
+		Cubes *[]map[string]interface{} `json:"cubes"`
+	}
+	if err := json.Unmarshal(rawData, &overlay); err != nil {
+		return fmt.Errorf("cubes must be a list of objects: %w", err)
+	}
+	if overlay.Cubes == nil {
+		return fmt.Errorf("data has no cubes list")
+	}
+	for i, cube := range *overlay.Cubes {
+		if name, _ := cube["name"].(string); name == "" {
+			return fmt.Errorf("cube %d has no name", i)
+		}
+	}
+	return nil
+}
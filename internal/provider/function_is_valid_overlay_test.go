@@ -0,0 +1,50 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+func TestIsValidOverlayFunction(t *testing.T) {
+	h := newTestHarness(t, map[string]interface{}{
+		"api_url": "https://api.revos.io",
+		"token":   "secret",
+	})
+
+	tests := []struct {
+		name     string
+		input    string
+		expected bool
+	}{
+		{name: "cubes", input: `{"cubes":[{"name":"orders","sql_table":"orders"}]}`, expected: true},
+		{name: "no cubes yet", input: `{"cubes":[]}`, expected: true},
+		{name: "other keys", input: `{"cubes":[{"name":"orders"}],"views":[]}`, expected: true},
+		{name: "invalid JSON", input: `{"cubes":`, expected: false},
+		{name: "empty", input: ``, expected: false},
+		{name: "array", input: `[{"name":"orders"}]`, expected: false},
+		{name: "null", input: `null`, expected: false},
+		{name: "missing cubes", input: `{"views":[]}`, expected: false},
+		{name: "cubes not a list", input: `{"cubes":{"name":"orders"}}`, expected: false},
+		{name: "cube not an object", input: `{"cubes":["orders"]}`, expected: false},
+		{name: "unnamed cube", input: `{"cubes":[{"sql_table":"orders"}]}`, expected: false},
+		{name: "empty name", input: `{"cubes":[{"name":""}]}`, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, funcErr := h.callFunction("is_valid_overlay", tftypes.NewValue(tftypes.String, tt.input))
+			if funcErr != nil {
+				t.Fatalf("unexpected error: %s", funcErr.Text)
+			}
+
+			var got bool
+			if err := result.As(&got); err != nil {
+				t.Fatalf("As: %s", err)
+			}
+			if got != tt.expected {
+				t.Errorf("is_valid_overlay = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
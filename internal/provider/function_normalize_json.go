@@ -0,0 +1,55 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/function"
+)
+
+// Ensure implementation satisfies interfaces.
+var _ function.Function = &NormalizeJSONFunction{}
+
+func NewNormalizeJSONFunction() function.Function {
+	return &NormalizeJSONFunction{}
+}
+
+// NormalizeJSONFunction returns the canonical form of an overlay definition,
+// the same one data_hash is computed from
+type NormalizeJSONFunction struct{}
+
+func (f *NormalizeJSONFunction) Metadata(ctx context.Context, req function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "normalize_json"
+}
+
+func (f *NormalizeJSONFunction) Definition(ctx context.Context, req function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary: "Normalizes an overlay definition",
+		Description: "Returns the JSON string with object keys sorted and insignificant whitespace removed. " +
+			"Numbers are kept as written. Use it to keep definitions in locals consistent with what the provider stores.",
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:        "input",
+				Description: "The JSON string representation of the Cube definition.",
+			},
+		},
+		Return: function.StringReturn{},
+	}
+}
+
+func (f *NormalizeJSONFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var input string
+
+	resp.Error = function.ConcatFuncErrors(resp.Error, req.Arguments.Get(ctx, &input))
+	if resp.Error != nil {
+		return
+	}
+
+	canonical, err := canonicalJSON(input)
+	if err != nil {
+		resp.Error = function.NewArgumentFuncError(0, fmt.Sprintf("Unable to parse input as JSON: %s", err))
+		return
+	}
+
+	resp.Error = function.ConcatFuncErrors(resp.Error, resp.Result.Set(ctx, string(canonical)))
+}
@@ -0,0 +1,56 @@
+package provider
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+func TestNormalizeJSONFunction(t *testing.T) {
+	h := newTestHarness(t, map[string]interface{}{
+		"api_url": "https://api.revos.io",
+		"token":   "secret",
+	})
+
+	tests := []struct {
+		name          string
+		input         string
+		expected      string
+		expectedError string
+	}{
+		{name: "sorted keys", input: `{"b":1,"a":{"d":2,"c":3}}`, expected: `{"a":{"c":3,"d":2},"b":1}`},
+		{name: "whitespace", input: "{\n  \"cubes\": [\n    {\"name\": \"orders\"}\n  ]\n}\n", expected: `{"cubes":[{"name":"orders"}]}`},
+		{name: "SQL operators", input: `{"sql": "a > 1 AND b < 2 OR c <> d & e"}`, expected: `{"sql":"a > 1 AND b < 2 OR c <> d & e"}`},
+		{name: "numbers kept as written", input: `{"a":1.50,"b":12345678901234567890}`, expected: `{"a":1.50,"b":12345678901234567890}`},
+		{name: "invalid", input: `{"a":`, expectedError: "Unable to parse input as JSON"},
+		{name: "trailing data", input: `{} {}`, expectedError: "unexpected data after top-level value"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, funcErr := h.callFunction("normalize_json", tftypes.NewValue(tftypes.String, tt.input))
+
+			if tt.expectedError != "" {
+				if funcErr == nil || !strings.Contains(funcErr.Text, tt.expectedError) {
+					t.Fatalf("expected error containing %q, got %v", tt.expectedError, funcErr)
+				}
+				if funcErr.FunctionArgument == nil || *funcErr.FunctionArgument != 0 {
+					t.Errorf("expected the error to point at the input argument, got %v", funcErr.FunctionArgument)
+				}
+				return
+			}
+			if funcErr != nil {
+				t.Fatalf("unexpected error: %s", funcErr.Text)
+			}
+
+			var got string
+			if err := result.As(&got); err != nil {
+				t.Fatalf("As: %s", err)
+			}
+			if got != tt.expected {
+				t.Errorf("normalize_json = %s, want %s", got, tt.expected)
+			}
+		})
+	}
+}
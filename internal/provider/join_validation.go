@@ -0,0 +1,143 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// joinReferenceValidator warns about joins in overlay data that reference a
+// cube the overlay doesn't define, usually a typo in the join name or its
+// SQL. It only runs if validate_joins is set, as an overlay may join cubes
+// defined elsewhere.
+type joinReferenceValidator struct{}
+
+func (v joinReferenceValidator) Description(ctx context.Context) string {
+	return "Warns about joins referencing cubes the overlay doesn't define, if validate_joins is set"
+}
+
+func (v joinReferenceValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v joinReferenceValidator) ValidateString(ctx context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+
+	var enabled types.Bool
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("validate_joins"), &enabled)...)
+	if resp.Diagnostics.HasError() || !enabled.ValueBool() {
+		return
+	}
+
+	for _, join := range danglingJoins(req.ConfigValue.ValueString()) {
+		resp.Diagnostics.AddAttributeWarning(req.Path, "Dangling Join Reference",
+			fmt.Sprintf("Join %q of cube %q references cube %q, which this overlay doesn't define.", join.name, join.cube, join.target))
+	}
+}
+
+// joinReference is a reference of a join to a cube
+type joinReference struct {
+	cube   string
+	name   string
+	target string
+}
+
+// joinCubeReferenceRegexp matches the {cube} and {cube.member} references in
+// join SQL. ${...} data_vars placeholders are not references.
+var joinCubeReferenceRegexp = regexp.MustCompile(`\$?\{([A-Za-z_][A-Za-z0-9_]*)(?:\.[^}]*)?\}`)
+
+// danglingJoins returns the joins in overlay data whose name or SQL
+// references a cube the data doesn't define, in the order they appear
+func danglingJoins(data string) []joinReference {
+	defined, references := joinReferences(data)
+
+	var dangling []joinReference
+	for _, ref := range references {
+		if !defined[ref.target] {
+			dangling = append(dangling, ref)
+		}
+	}
+	return dangling
+}
+
+// joinReferences returns the cubes overlay data defines and the cubes its
+// joins reference by name or in their SQL, other than the cube the join
+// belongs to, in the order they appear. Joins may be a list of objects with
+// a name, or an object keyed by name. Data that isn't JSON or JSON5 has no
+// cubes.
+func joinReferences(data string) (map[string]bool, []joinReference) {
+	var overlay struct {
+		Cubes []struct {
+			Name  string          `json:"name"`
+			Joins json.RawMessage `json:"joins"`
+		} `json:"cubes"`
+	}
+	if err := json.Unmarshal([]byte(data), &overlay); err != nil {
+		normalized, err := normalizeJSON5(data)
+		if err != nil || json.Unmarshal([]byte(normalized), &overlay) != nil {
+			return nil, nil
+		}
+	}
+
+	defined := map[string]bool{}
+	for _, cube := range overlay.Cubes {
+		defined[cube.Name] = true
+	}
+
+	var references []joinReference
+	for _, cube := range overlay.Cubes {
+		for _, join := range cubeJoins(cube.Joins) {
+			targets := []string{join.Name}
+			for _, m := range joinCubeReferenceRegexp.FindAllStringSubmatch(join.SQL, -1) {
+				if !strings.HasPrefix(m[0], "$") {
+					targets = append(targets, m[1])
+				}
+			}
+
+			seen := map[string]bool{}
+			for _, target := range targets {
+				// CUBE refers to the cube the join belongs to
+				if target == "" || target == "CUBE" || target == cube.Name || seen[target] {
+					continue
+				}
+				seen[target] = true
+				references = append(references, joinReference{cube: cube.Name, name: join.Name, target: target})
+			}
+		}
+	}
+	return defined, references
+}
+
+type cubeJoin struct {
+	Name string `json:"name"`
+	SQL  string `json:"sql"`
+}
+
+// cubeJoins decodes the joins of a cube, sorting joins given as an object by
+// name
+func cubeJoins(raw json.RawMessage) []cubeJoin {
+	var joins []cubeJoin
+	if err := json.Unmarshal(raw, &joins); err == nil {
+		return joins
+	}
+
+	var byName map[string]cubeJoin
+	if err := json.Unmarshal(raw, &byName); err != nil {
+		return nil
+	}
+	for name, join := range byName {
+		join.Name = name
+		joins = append(joins, join)
+	}
+	sort.Slice(joins, func(i, j int) bool { return joins[i].Name < joins[j].Name })
+	return joins
+}
@@ -0,0 +1,112 @@
+package provider
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+func TestDanglingJoins(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		expected []string
+	}{
+		{
+			name: "valid joins",
+			data: `{"cubes":[
+				{"name":"orders","joins":[{"name":"users","sql":"{CUBE}.user_id = {users}.id","relationship":"many_to_one"}]},
+				{"name":"users","joins":[{"name":"orders","sql":"{users.id} = {orders.user_id}"}]}
+			]}`,
+		},
+		{
+			name:     "dangling join name",
+			data:     `{"cubes":[{"name":"orders","joins":[{"name":"customers","sql":"{CUBE}.customer_id = {customers}.id"}]}]}`,
+			expected: []string{"orders.customers -> customers"},
+		},
+		{
+			name: "dangling SQL reference",
+			data: `{"cubes":[
+				{"name":"orders","joins":[{"name":"users","sql":"{CUBE}.user_id = {user}.id"}]},
+				{"name":"users"}
+			]}`,
+			expected: []string{"orders.users -> user"},
+		},
+		{
+			name:     "adjacent references",
+			data:     `{"cubes":[{"name":"a","joins":[{"name":"a","sql":"{b}{c.id}"}]}]}`,
+			expected: []string{"a.a -> b", "a.a -> c"},
+		},
+		{
+			name:     "joins keyed by name",
+			data:     `{"cubes":[{"name":"orders","joins":{"users":{"sql":"{CUBE}.user_id = {users}.id"},"accounts":{"sql":"{accounts}.id = 1"}}},{"name":"users"}]}`,
+			expected: []string{"orders.accounts -> accounts"},
+		},
+		{
+			name: "data_vars placeholders are ignored",
+			data: `{"cubes":[{"name":"orders","joins":[{"name":"orders","sql":"{CUBE}.region = '${region}'"}]}]}`,
+		},
+		{
+			name: "JSON5",
+			data: `{"cubes": [{"name": "orders", "joins": [{"name": "users", "sql": "{users}.id = 1"},],},], // no users cube
+}`,
+			expected: []string{"orders.users -> users"},
+		},
+		{
+			name: "invalid JSON",
+			data: `{"cubes":`,
+		},
+		{
+			name: "no cubes",
+			data: `{"views":[]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, join := range danglingJoins(tt.data) {
+				got = append(got, fmt.Sprintf("%s.%s -> %s", join.cube, join.name, join.target))
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.expected) {
+				t.Errorf("danglingJoins() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestOverlayResource_ValidateJoins(t *testing.T) {
+	m := newMockRevosServer(t)
+	h := newMockHarness(t, m)
+
+	null := tftypes.NewValue(h.resourceSchema("revos_overlay").ValueType(), nil)
+	data := `{"cubes":[{"name":"orders","joins":[{"name":"customers","sql":"{CUBE}.customer_id = {customers}.id"}]}]}`
+
+	tests := []struct {
+		name          string
+		validateJoins interface{}
+		expectWarning bool
+	}{
+		{name: "unset", validateJoins: nil},
+		{name: "disabled", validateJoins: false},
+		{name: "enabled", validateJoins: true, expectWarning: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, diags := h.plan("revos_overlay", null, map[string]interface{}{
+				"name":           "orders",
+				"data":           data,
+				"validate_joins": tt.validateJoins,
+			})
+			requireNoErrors(t, "plan", diags)
+
+			warned := strings.Contains(formatDiags(diags), `Join "customers" of cube "orders" references cube "customers"`)
+			if warned != tt.expectWarning {
+				t.Errorf("warning = %t, want %t:\n%s", warned, tt.expectWarning, formatDiags(diags))
+			}
+		})
+	}
+}
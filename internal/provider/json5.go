@@ -0,0 +1,122 @@
+package provider
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Supported values for the data_format attribute
+const (
+	dataFormatJSON  = "json"
+	dataFormatJSON5 = "json5"
+)
+
+// normalizeJSON5 converts JSON with comments and trailing commas, the parts
+// of JSON5 people use to annotate large definitions, to strict JSON
+func normalizeJSON5(s string) (string, error) {
+	stripped, err := stripJSONComments(s)
+	if err != nil {
+		return "", err
+	}
+	normalized := stripTrailingCommas(stripped)
+
+	var v interface{}
+	if err := json.Unmarshal([]byte(normalized), &v); err != nil {
+		return "", err
+	}
+	return normalized, nil
+}
+
+// stripJSONComments removes // line comments and /* */ block comments outside
+// of strings. Comments are replaced by whitespace, keeping line breaks, so
+// parse errors still point at the right line.
+func stripJSONComments(s string) (string, error) {
+	var b strings.Builder
+	b.Grow(len(s))
+
+	inString := false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+
+		if inString {
+			b.WriteByte(c)
+			switch c {
+			case '\\':
+				if i+1 < len(s) {
+					i++
+					b.WriteByte(s[i])
+				}
+			case '"':
+				inString = false
+			}
+			continue
+		}
+
+		switch {
+		case c == '"':
+			inString = true
+			b.WriteByte(c)
+		case c == '/' && i+1 < len(s) && s[i+1] == '/':
+			for i < len(s) && s[i] != '\n' {
+				i++
+			}
+			if i < len(s) {
+				b.WriteByte('\n')
+			}
+		case c == '/' && i+1 < len(s) && s[i+1] == '*':
+			end := strings.Index(s[i+2:], "*/")
+			if end < 0 {
+				return "", fmt.Errorf("unterminated block comment")
+			}
+			comment := s[i : i+2+end+2]
+			b.WriteString(strings.Repeat("\n", strings.Count(comment, "\n")))
+			b.WriteByte(' ')
+			i += len(comment) - 1
+		default:
+			b.WriteByte(c)
+		}
+	}
+
+	return b.String(), nil
+}
+
+// stripTrailingCommas removes commas directly followed, ignoring whitespace,
+// by the closing bracket of an object or array. The input must be free of
+// comments.
+func stripTrailingCommas(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+
+	inString := false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+
+		if inString {
+			b.WriteByte(c)
+			switch c {
+			case '\\':
+				if i+1 < len(s) {
+					i++
+					b.WriteByte(s[i])
+				}
+			case '"':
+				inString = false
+			}
+			continue
+		}
+
+		switch c {
+		case '"':
+			inString = true
+		case ',':
+			rest := strings.TrimLeft(s[i+1:], " \t\r\n")
+			if strings.HasPrefix(rest, "}") || strings.HasPrefix(rest, "]") {
+				continue
+			}
+		}
+		b.WriteByte(c)
+	}
+
+	return b.String()
+}
@@ -0,0 +1,84 @@
+package provider
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNormalizeJSON5(t *testing.T) {
+	tests := []struct {
+		name          string
+		input         string
+		expected      string
+		expectedError string
+	}{
+		{
+			name:     "strict JSON is unchanged",
+			input:    `{"a":1,"b":[1,2]}`,
+			expected: `{"a":1,"b":[1,2]}`,
+		},
+		{
+			name: "line comments",
+			input: `{
+  // The number of orders
+  "a": 1 // trailing
+}`,
+			expected: "{\n  \n  \"a\": 1 \n}",
+		},
+		{
+			name:     "block comments",
+			input:    `{/* first */"a":/* inline */1}`,
+			expected: `{ "a": 1}`,
+		},
+		{
+			name:     "multi-line block comments keep line numbers",
+			input:    "{\n/* one\ntwo */\"a\":1}",
+			expected: "{\n\n \"a\":1}",
+		},
+		{
+			name:     "trailing commas",
+			input:    `{"a":[1,2,],"b":{"c":3,},}`,
+			expected: `{"a":[1,2],"b":{"c":3}}`,
+		},
+		{
+			name: "trailing comma before a comment",
+			input: `{
+  "a": 1, // last
+}`,
+			expected: "{\n  \"a\": 1 \n}",
+		},
+		{
+			name:     "comment markers and commas inside strings are kept",
+			input:    `{"url":"https://example.com/*x*/","s":"a,}","q":"\"//\""}`,
+			expected: `{"url":"https://example.com/*x*/","s":"a,}","q":"\"//\""}`,
+		},
+		{
+			name:          "unterminated block comment",
+			input:         `{"a":1 /* oops`,
+			expectedError: "unterminated block comment",
+		},
+		{
+			name:          "still invalid after normalization",
+			input:         `{a: 1}`,
+			expectedError: "invalid character",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := normalizeJSON5(tt.input)
+			if tt.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+					t.Errorf("error = %v, want it to contain %q", err, tt.expectedError)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if got != tt.expected {
+				t.Errorf("normalizeJSON5() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,229 @@
+package provider
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// parseJSONPointer splits an RFC 6901 JSON Pointer such as "/cubes/0/name"
+// into its unescaped reference tokens
+func parseJSONPointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, fmt.Errorf("pointer to the whole document can't be ignored")
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("pointer must start with \"/\"")
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		// Order matters: "~01" is "~1", not "/"
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+// removeJSONPointer removes the value the tokens point at from v, returning
+// the modified value. Paths that don't exist are ignored.
+func removeJSONPointer(v interface{}, tokens []string) interface{} {
+	if len(tokens) == 0 {
+		return v
+	}
+	token, rest := tokens[0], tokens[1:]
+
+	switch val := v.(type) {
+	case map[string]interface{}:
+		child, ok := val[token]
+		if !ok {
+			return v
+		}
+		if len(rest) == 0 {
+			delete(val, token)
+		} else {
+			val[token] = removeJSONPointer(child, rest)
+		}
+		return val
+	case []interface{}:
+		i, err := strconv.Atoi(token)
+		if err != nil || i < 0 || i >= len(val) || token != strconv.Itoa(i) {
+			return v
+		}
+		if len(rest) == 0 {
+			return append(val[:i:i], val[i+1:]...)
+		}
+		val[i] = removeJSONPointer(val[i], rest)
+		return val
+	default:
+		return v
+	}
+}
+
+// resolveJSONPointer returns the value the tokens point at in v
+func resolveJSONPointer(v interface{}, tokens []string) (interface{}, error) {
+	for i, token := range tokens {
+		switch val := v.(type) {
+		case map[string]interface{}:
+			child, ok := val[token]
+			if !ok {
+				return nil, fmt.Errorf("%s has no key %q", describePointer(tokens[:i]), token)
+			}
+			v = child
+		case []interface{}:
+			index, err := strconv.Atoi(token)
+			if err != nil || index < 0 || token != strconv.Itoa(index) {
+				return nil, fmt.Errorf("%s is an array, and %q is not an index", describePointer(tokens[:i]), token)
+			}
+			if index >= len(val) {
+				return nil, fmt.Errorf("%s has %d elements, so index %d is out of range", describePointer(tokens[:i]), len(val), index)
+			}
+			v = val[index]
+		default:
+			raw, _ := json.Marshal(val)
+			return nil, fmt.Errorf("%s is %s, which has no %q", describePointer(tokens[:i]), jsonKind(raw), token)
+		}
+	}
+	return v, nil
+}
+
+// describePointer names the value a pointer refers to in error messages
+func describePointer(tokens []string) string {
+	if len(tokens) == 0 {
+		return "the document"
+	}
+	return jsonPointer(tokens)
+}
+
+// stripDataPaths removes the values at the given JSON Pointers from a JSON
+// document. The data is returned unchanged if there are no pointers or it
+// isn't valid JSON; invalid pointers are skipped.
+func stripDataPaths(data string, pointers []string) string {
+	if len(pointers) == 0 {
+		return data
+	}
+
+	var v interface{}
+	d := json.NewDecoder(strings.NewReader(data))
+	d.UseNumber()
+	if err := d.Decode(&v); err != nil {
+		return data
+	}
+
+	for _, pointer := range pointers {
+		tokens, err := parseJSONPointer(pointer)
+		if err != nil {
+			continue
+		}
+		v = removeJSONPointer(v, tokens)
+	}
+
+	stripped, err := json.Marshal(v)
+	if err != nil {
+		return data
+	}
+	return string(stripped)
+}
+
+// jsonEqualIgnoring compares two JSON strings like jsonEqual, disregarding
+// the values at the given JSON Pointers. With foldCase, string values that
+// only differ in case are equal.
+func jsonEqualIgnoring(a, b string, pointers []string, foldCase bool) bool {
+	objA, err := decodeJSON(stripDataPaths(a, pointers))
+	if err != nil {
+		return false
+	}
+	objB, err := decodeJSON(stripDataPaths(b, pointers))
+	if err != nil {
+		return false
+	}
+	return deepEqualFold(objA, objB, foldCase)
+}
+
+// escapeJSONPointerToken escapes a reference token for use in a JSON Pointer
+func escapeJSONPointerToken(token string) string {
+	// Order matters: "~" must be escaped before "/" introduces new ones
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+}
+
+// jsonPointer builds a JSON Pointer from unescaped reference tokens
+func jsonPointer(tokens []string) string {
+	var b strings.Builder
+	for _, token := range tokens {
+		b.WriteString("/")
+		b.WriteString(escapeJSONPointerToken(token))
+	}
+	return b.String()
+}
+
+// fieldPathTokens splits a field path as the API reports it, such as
+// "cubes.0.name", "cubes[0].name" or the JSON Pointer "/cubes/0/name", into
+// reference tokens
+func fieldPathTokens(p string) []string {
+	if strings.HasPrefix(p, "/") {
+		tokens, _ := parseJSONPointer(p)
+		return tokens
+	}
+
+	var tokens []string
+	for _, token := range strings.Split(strings.NewReplacer("[", ".", "]", "").Replace(p), ".") {
+		if token != "" {
+			tokens = append(tokens, token)
+		}
+	}
+	return tokens
+}
+
+// jsonDiffPaths returns the sorted JSON Pointers of the values that differ
+// between a and b. Objects are compared key by key and arrays index by index,
+// so a value is reported at the deepest path where the two disagree. An empty
+// pointer means the whole documents differ, e.g. an object and an array.
+func jsonDiffPaths(a, b interface{}) []string {
+	var paths []string
+	collectJSONDiffPaths("", a, b, &paths)
+	sort.Strings(paths)
+	return paths
+}
+
+func collectJSONDiffPaths(pointer string, a, b interface{}, paths *[]string) {
+	switch va := a.(type) {
+	case map[string]interface{}:
+		vb, ok := b.(map[string]interface{})
+		if !ok {
+			break
+		}
+		for k, valA := range va {
+			valB, exists := vb[k]
+			if !exists {
+				*paths = append(*paths, pointer+"/"+escapeJSONPointerToken(k))
+				continue
+			}
+			collectJSONDiffPaths(pointer+"/"+escapeJSONPointerToken(k), valA, valB, paths)
+		}
+		for k := range vb {
+			if _, exists := va[k]; !exists {
+				*paths = append(*paths, pointer+"/"+escapeJSONPointerToken(k))
+			}
+		}
+		return
+	case []interface{}:
+		vb, ok := b.([]interface{})
+		if !ok {
+			break
+		}
+		for i := 0; i < len(va) || i < len(vb); i++ {
+			elem := pointer + "/" + strconv.Itoa(i)
+			if i >= len(va) || i >= len(vb) {
+				*paths = append(*paths, elem)
+				continue
+			}
+			collectJSONDiffPaths(elem, va[i], vb[i], paths)
+		}
+		return
+	}
+
+	if !deepEqual(a, b) {
+		*paths = append(*paths, pointer)
+	}
+}
@@ -0,0 +1,274 @@
+package provider
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+func TestParseJSONPointer(t *testing.T) {
+	tests := []struct {
+		pointer       string
+		expected      []string
+		expectedError string
+	}{
+		{pointer: "/a", expected: []string{"a"}},
+		{pointer: "/cubes/0/lastRefreshed", expected: []string{"cubes", "0", "lastRefreshed"}},
+		{pointer: "/a~1b/m~0n", expected: []string{"a/b", "m~n"}},
+		{pointer: "/~01", expected: []string{"~1"}},
+		{pointer: "/", expected: []string{""}},
+		{pointer: "", expectedError: "whole document"},
+		{pointer: "cubes/0", expectedError: "must start with"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pointer, func(t *testing.T) {
+			got, err := parseJSONPointer(tt.pointer)
+			if tt.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+					t.Errorf("error = %v, want it to contain %q", err, tt.expectedError)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("parseJSONPointer(%q) = %q, want %q", tt.pointer, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestResolveJSONPointer(t *testing.T) {
+	doc, err := decodeJSON(`{"cubes":[{"name":"orders","measures":{"count":{"type":"count"}}}],"a/b":1.50}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		pointer       string
+		expected      string
+		expectedError string
+	}{
+		{pointer: "/cubes/0/name", expected: `"orders"`},
+		{pointer: "/cubes/0/measures", expected: `{"count":{"type":"count"}}`},
+		{pointer: "/cubes", expected: `[{"measures":{"count":{"type":"count"}},"name":"orders"}]`},
+		{pointer: "/a~1b", expected: `1.50`},
+		{pointer: "/missing", expectedError: `the document has no key "missing"`},
+		{pointer: "/cubes/1", expectedError: "/cubes has 1 elements"},
+		{pointer: "/cubes/first", expectedError: `"first" is not an index`},
+		{pointer: "/cubes/01", expectedError: "not an index"},
+		{pointer: "/cubes/0/name/x", expectedError: "/cubes/0/name is a string"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pointer, func(t *testing.T) {
+			tokens, err := parseJSONPointer(tt.pointer)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := resolveJSONPointer(doc, tokens)
+			if tt.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+					t.Errorf("error = %v, want it to contain %q", err, tt.expectedError)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			encoded, _ := json.Marshal(got)
+			if string(encoded) != tt.expected {
+				t.Errorf("resolveJSONPointer(%q) = %s, want %s", tt.pointer, encoded, tt.expected)
+			}
+		})
+	}
+}
+
+func TestStripDataPaths(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		pointers []string
+		expected string
+	}{
+		{
+			name:     "top-level key",
+			data:     `{"a":1,"lastRefreshed":"2024-01-01"}`,
+			pointers: []string{"/lastRefreshed"},
+			expected: `{"a":1}`,
+		},
+		{
+			name:     "nested key",
+			data:     `{"meta":{"lastRefreshed":"2024-01-01","owner":"me"}}`,
+			pointers: []string{"/meta/lastRefreshed"},
+			expected: `{"meta":{"owner":"me"}}`,
+		},
+		{
+			name:     "key inside an array element",
+			data:     `{"cubes":[{"name":"a","lastRefreshed":1},{"name":"b","lastRefreshed":2}]}`,
+			pointers: []string{"/cubes/1/lastRefreshed"},
+			expected: `{"cubes":[{"lastRefreshed":1,"name":"a"},{"name":"b"}]}`,
+		},
+		{
+			name:     "array element",
+			data:     `{"ids":[1,2,3]}`,
+			pointers: []string{"/ids/1"},
+			expected: `{"ids":[1,3]}`,
+		},
+		{
+			name:     "escaped key",
+			data:     `{"a/b":1,"c":2}`,
+			pointers: []string{"/a~1b"},
+			expected: `{"c":2}`,
+		},
+		{
+			name:     "missing paths and invalid pointers are skipped",
+			data:     `{"a":[1],"b":2}`,
+			pointers: []string{"/missing", "/a/5", "/a/01", "/b/c", "no-slash"},
+			expected: `{"a":[1],"b":2}`,
+		},
+		{
+			name:     "large numbers are kept",
+			data:     `{"id":12345678901234567890,"x":1}`,
+			pointers: []string{"/x"},
+			expected: `{"id":12345678901234567890}`,
+		},
+		{
+			name:     "no pointers",
+			data:     `{ "a": 1 }`,
+			expected: `{ "a": 1 }`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := stripDataPaths(tt.data, tt.pointers); got != tt.expected {
+				t.Errorf("stripDataPaths() = %s, want %s", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestOverlayResource_IgnoreDataPaths(t *testing.T) {
+	for _, ignore := range []bool{false, true} {
+		t.Run(map[bool]string{false: "not ignored", true: "ignored"}[ignore], func(t *testing.T) {
+			m := newMockRevosServer(t)
+			h := newMockHarness(t, m)
+
+			config := map[string]interface{}{
+				"name": "refreshed",
+				"data": `{"cubes":[{"name":"orders"}]}`,
+			}
+			if ignore {
+				config["ignore_data_paths"] = []interface{}{"/lastRefreshed", "/cubes/0/lastRefreshed"}
+			}
+			null := tftypes.NewValue(h.resourceSchema("revos_overlay").ValueType(), nil)
+			state, diags := h.apply("revos_overlay", null, config)
+			requireNoErrors(t, "create", diags)
+
+			// The API injects timestamps of its own
+			m.setOverlayField(attrString(t, state, "id"), "data", map[string]interface{}{
+				"lastRefreshed": "2024-01-01T00:00:00Z",
+				"cubes": []interface{}{map[string]interface{}{
+					"name":          "orders",
+					"lastRefreshed": "2024-01-01T00:00:00Z",
+				}},
+			})
+
+			state, diags = h.read("revos_overlay", state)
+			requireNoErrors(t, "refresh", diags)
+			planned, diags := h.plan("revos_overlay", state, config)
+			requireNoErrors(t, "plan", diags)
+
+			if ignore && !planned.Equal(state) {
+				t.Errorf("expected an empty plan, got %s", planned)
+			}
+			if !ignore && planned.Equal(state) {
+				t.Error("expected the injected timestamps to show as a change")
+			}
+		})
+	}
+
+	t.Run("invalid pointer", func(t *testing.T) {
+		m := newMockRevosServer(t)
+		h := newMockHarness(t, m)
+
+		null := tftypes.NewValue(h.resourceSchema("revos_overlay").ValueType(), nil)
+		_, diags := h.plan("revos_overlay", null, map[string]interface{}{
+			"name":              "refreshed",
+			"data":              `{}`,
+			"ignore_data_paths": []interface{}{"/ok", "lastRefreshed"},
+		})
+		requireError(t, diags, "Invalid JSON Pointer")
+	})
+}
+
+func TestJSONDiffPaths(t *testing.T) {
+	tests := []struct {
+		name     string
+		a, b     string
+		expected []string
+	}{
+		{
+			name: "identical",
+			a:    `{"cubes":[{"name":"orders","measures":["count"]}]}`,
+			b:    `{"cubes":[{"name":"orders","measures":["count"]}]}`,
+		},
+		{
+			name: "reordered keys",
+			a:    `{"a":1,"b":{"c":true,"d":null}}`,
+			b:    `{"b":{"d":null,"c":true},"a":1}`,
+		},
+		{
+			name:     "changed, added and removed keys",
+			a:        `{"z":1,"a":{"b":2,"c":3}}`,
+			b:        `{"z":2,"a":{"b":2,"d":4}}`,
+			expected: []string{"/a/c", "/a/d", "/z"},
+		},
+		{
+			name:     "array elements",
+			a:        `{"cubes":[{"name":"orders"},{"name":"users"}]}`,
+			b:        `{"cubes":[{"name":"orders"},{"name":"accounts"},{"name":"users"}]}`,
+			expected: []string{"/cubes/1/name", "/cubes/2"},
+		},
+		{
+			name:     "type change",
+			a:        `{"a":[1]}`,
+			b:        `{"a":{"0":1}}`,
+			expected: []string{"/a"},
+		},
+		{
+			name:     "escaped keys",
+			a:        `{"a/b":1,"m~n":1}`,
+			b:        `{"a/b":2,"m~n":2}`,
+			expected: []string{"/a~1b", "/m~0n"},
+		},
+		{
+			name:     "whole document",
+			a:        `{}`,
+			b:        `[]`,
+			expected: []string{""},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var a, b interface{}
+			if err := json.Unmarshal([]byte(tt.a), &a); err != nil {
+				t.Fatal(err)
+			}
+			if err := json.Unmarshal([]byte(tt.b), &b); err != nil {
+				t.Fatal(err)
+			}
+			if got := jsonDiffPaths(a, b); fmt.Sprint(got) != fmt.Sprint(tt.expected) {
+				t.Errorf("jsonDiffPaths() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,811 @@
+package provider
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// mockRevosServer is an in-process stand-in for the Revos API. It stores
+// overlays in memory and, like the real API, returns the overlay data with
+// a different key ordering than it was submitted with.
+type mockRevosServer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	overlays map[string]map[string]interface{}
+	versions map[string]int
+	shares   map[string]map[string]map[string]interface{}
+	bindings map[string]map[string]map[string]interface{}
+	// templates holds overlay templates, which share the overlay ID sequence
+	templates map[string]map[string]interface{}
+	history   map[string][]map[string]interface{}
+	nextID    int
+	requests  []string
+
+	// truncateCreateResponse stores created overlays but cuts the response
+	// short, as if the connection dropped mid-body
+	truncateCreateResponse bool
+	// slowCreateResponse stores created overlays right away but holds the
+	// response back for it, like a create that times out on the client
+	slowCreateResponse time.Duration
+	// createStatus, if set, fails creates with it before anything is
+	// stored, like an API erroring out
+	createStatus int
+	// delay is added before each response, to simulate a slow API
+	delay time.Duration
+	// versioning enables the revision history endpoint
+	versioning bool
+	// search enables the overlay search endpoint
+	search bool
+	// unversioned omits the ETag header, like API versions that don't
+	// version overlays
+	unversioned bool
+	// omitOrganizationID leaves organizationId out of overlay responses,
+	// like some older endpoints
+	omitOrganizationID bool
+	// warnings are returned with every create and update
+	warnings []string
+	// validationErrors, if set, reject every create and update with a 422
+	// listing them, like an API rejecting the definition
+	validationErrors []map[string]string
+	// processingReads is how many reads a created overlay stays in the
+	// "processing" status for, like an API compiling overlays asynchronously
+	processingReads int
+	processing      map[string]int
+	// notFoundReads is how many reads by ID a created overlay answers 404
+	// for, like an eventually consistent API
+	notFoundReads int
+	notFound      map[string]int
+	// notModified counts the 304 responses to conditional reads
+	notModified int
+	// signingKey, if set, rejects requests without a valid X-Signature,
+	// like a gateway requiring signed requests
+	signingKey string
+}
+
+func newMockRevosServer(t *testing.T) *mockRevosServer {
+	t.Helper()
+
+	m := &mockRevosServer{
+		overlays:  map[string]map[string]interface{}{},
+		versions:  map[string]int{},
+		shares:    map[string]map[string]map[string]interface{}{},
+		bindings:  map[string]map[string]map[string]interface{}{},
+		templates: map[string]map[string]interface{}{},
+		history:   map[string][]map[string]interface{}{},
+	}
+	m.Server = httptest.NewServer(http.HandlerFunc(m.handle))
+	t.Cleanup(m.Close)
+
+	return m
+}
+
+// requestCount returns how many requests matching "METHOD /path" were served.
+func (m *mockRevosServer) requestCount(method, path string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	count := 0
+	for _, r := range m.requests {
+		if r == method+" "+path {
+			count++
+		}
+	}
+	return count
+}
+
+// setOverlayField changes a stored overlay behind Terraform's back.
+func (m *mockRevosServer) setOverlayField(id, key string, value interface{}) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.overlays[id][key] = value
+	m.versions[id]++
+}
+
+// overlayField returns a field of a stored overlay as the API would see it.
+func (m *mockRevosServer) overlayField(id, key string) interface{} {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.overlays[id][key]
+}
+
+func (m *mockRevosServer) overlayCount() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return len(m.overlays)
+}
+
+// setDelay sets the delay added before each response, or removes it with 0.
+func (m *mockRevosServer) setDelay(delay time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.delay = delay
+}
+
+func (m *mockRevosServer) handle(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	m.requests = append(m.requests, r.Method+" "+r.URL.Path)
+	delay := m.delay
+	slowCreate := m.slowCreateResponse
+	m.mu.Unlock()
+
+	// Deferred before the lock below, so that it runs once the lock is
+	// released, and holds back the already written response
+	if slowCreate > 0 && r.Method == http.MethodPost && r.URL.Path == "/cube-overlays" {
+		defer func() {
+			select {
+			case <-time.After(slowCreate):
+			case <-r.Context().Done():
+			}
+		}()
+	}
+
+	// Sleep without the lock, so a slow request doesn't hold up others
+	if delay > 0 {
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if r.Header.Get("Authorization") != "Bearer test-token" {
+		m.writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	if m.signingKey != "" {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			m.writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		mac := hmac.New(sha256.New, []byte(m.signingKey))
+		mac.Write(body)
+		if !hmac.Equal([]byte(r.Header.Get("X-Signature")), []byte(hex.EncodeToString(mac.Sum(nil)))) {
+			m.writeError(w, http.StatusForbidden, "Invalid Signature")
+			return
+		}
+	}
+
+	if rest, ok := strings.CutPrefix(r.URL.Path, "/cube-overlay-templates"); ok {
+		m.handleTemplates(w, r, strings.TrimPrefix(rest, "/"))
+		return
+	}
+
+	id := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, "/cube-overlays"), "/")
+	if overlayID, rest, ok := strings.Cut(id, "/"); ok {
+		if _, exists := m.overlays[overlayID]; !exists {
+			m.writeError(w, http.StatusNotFound, "Not Found")
+			return
+		}
+		sub, subID, _ := strings.Cut(rest, "/")
+		switch sub {
+		case "shares":
+			m.handleShares(w, r, overlayID, subID)
+		case "bindings":
+			m.handleBindings(w, r, overlayID, subID)
+		case "archive":
+			m.handleArchive(w, r, overlayID)
+		case "lock", "unlock":
+			m.handleLock(w, r, overlayID, sub == "lock")
+		case "versions":
+			if !m.versioning || r.Method != http.MethodGet {
+				m.writeError(w, http.StatusNotFound, "Not Found")
+				return
+			}
+			m.writeData(w, http.StatusOK, m.history[overlayID])
+		default:
+			m.writeError(w, http.StatusNotFound, "Not Found")
+		}
+		return
+	}
+
+	switch {
+	case id == "" && r.Method == http.MethodGet:
+		includeArchived := r.URL.Query().Get("includeArchived") == "true"
+		list := make([]interface{}, 0, len(m.overlays))
+		for _, k := range m.sortedIDs() {
+			if m.overlays[k]["archived"] == true && !includeArchived {
+				continue
+			}
+			list = append(list, m.overlays[k])
+		}
+		m.writeData(w, http.StatusOK, list)
+	case id == "" && r.Method == http.MethodPost:
+		var payload map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			m.writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if m.validationErrors != nil {
+			m.writeValidationErrors(w)
+			return
+		}
+		if m.createStatus != 0 {
+			m.writeError(w, m.createStatus, http.StatusText(m.createStatus))
+			return
+		}
+		for _, o := range m.overlays {
+			if o["name"] == payload["name"] {
+				m.writeError(w, http.StatusConflict, "name already exists")
+				return
+			}
+		}
+		m.nextID++
+		now := time.Now().UTC().Format(time.RFC3339)
+		overlay := map[string]interface{}{
+			"id":             fmt.Sprintf("ov-%d", m.nextID),
+			"organizationId": "org-1",
+			"createdBy":      "user-1",
+			"createdAt":      now,
+			"updatedAt":      now,
+		}
+		for k, v := range payload {
+			overlay[k] = v
+		}
+		m.overlays[overlay["id"].(string)] = overlay
+		m.versions[overlay["id"].(string)] = 1
+		m.recordVersion(overlay["id"].(string))
+		if m.processingReads > 0 {
+			if m.processing == nil {
+				m.processing = map[string]int{}
+			}
+			m.processing[overlay["id"].(string)] = m.processingReads
+			overlay["status"] = "processing"
+		}
+		if m.notFoundReads > 0 {
+			if m.notFound == nil {
+				m.notFound = map[string]int{}
+			}
+			m.notFound[overlay["id"].(string)] = m.notFoundReads
+		}
+		if m.truncateCreateResponse {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"data":{"id":`))
+			return
+		}
+		m.writeOverlay(w, http.StatusCreated, m.withWarnings(overlay))
+	case id == "validate" && r.Method == http.MethodPost:
+		m.handleValidate(w, r)
+	case id == "search" && m.search && r.Method == http.MethodGet:
+		m.handleSearch(w, r)
+	default:
+		overlay, ok := m.overlays[id]
+		if !ok {
+			m.writeError(w, http.StatusNotFound, "Not Found")
+			return
+		}
+
+		if r.Method != http.MethodGet && !m.matchesVersion(r, id) {
+			m.writeError(w, http.StatusPreconditionFailed, "Precondition Failed")
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			if m.notFound[id] > 0 {
+				m.notFound[id]--
+				m.writeError(w, http.StatusNotFound, "Not Found")
+				return
+			}
+			if ifNoneMatch := r.Header.Get("If-None-Match"); !m.unversioned && ifNoneMatch == m.etag(id) {
+				m.notModified++
+				w.Header().Set("ETag", m.etag(id))
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			if m.processing[id] > 0 {
+				m.processing[id]--
+				if m.processing[id] == 0 {
+					overlay["status"] = "ready"
+				}
+			}
+			m.writeOverlay(w, http.StatusOK, overlay)
+		case http.MethodPatch, http.MethodPut:
+			var payload map[string]interface{}
+			if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+				m.writeError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			if m.validationErrors != nil {
+				m.writeValidationErrors(w)
+				return
+			}
+			if r.Method == http.MethodPut {
+				// PUT replaces every field the client can set
+				for _, k := range []string{"name", "description", "data", "tags", "enabled"} {
+					delete(overlay, k)
+				}
+			}
+			for k, v := range payload {
+				overlay[k] = v
+			}
+			overlay["updatedAt"] = time.Now().UTC().Add(time.Second).Format(time.RFC3339)
+			m.versions[id]++
+			m.recordVersion(id)
+			m.writeOverlay(w, http.StatusOK, m.withWarnings(overlay))
+		case http.MethodDelete:
+			delete(m.overlays, id)
+			delete(m.versions, id)
+			delete(m.shares, id)
+			delete(m.bindings, id)
+			delete(m.history, id)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			m.writeError(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+		}
+	}
+}
+
+// recordVersion adds the current state of an overlay to its history.
+func (m *mockRevosServer) recordVersion(id string) {
+	m.history[id] = append(m.history[id], map[string]interface{}{
+		"versionId": fmt.Sprintf("%s-v%d", id, m.versions[id]),
+		"createdAt": m.overlays[id]["updatedAt"],
+		"createdBy": "user-1",
+	})
+}
+
+func (m *mockRevosServer) handleArchive(w http.ResponseWriter, r *http.Request, id string) {
+	if r.Method != http.MethodPost {
+		m.writeError(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+		return
+	}
+	if !m.matchesVersion(r, id) {
+		m.writeError(w, http.StatusPreconditionFailed, "Precondition Failed")
+		return
+	}
+
+	overlay := m.overlays[id]
+	overlay["archived"] = true
+	m.versions[id]++
+	m.writeOverlay(w, http.StatusOK, overlay)
+}
+
+func (m *mockRevosServer) handleLock(w http.ResponseWriter, r *http.Request, id string, locked bool) {
+	if r.Method != http.MethodPost {
+		m.writeError(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+		return
+	}
+	if !m.matchesVersion(r, id) {
+		m.writeError(w, http.StatusPreconditionFailed, "Precondition Failed")
+		return
+	}
+
+	overlay := m.overlays[id]
+	overlay["locked"] = locked
+	overlay["updatedAt"] = time.Now().UTC().Add(time.Second).Format(time.RFC3339)
+	m.versions[id]++
+	m.writeOverlay(w, http.StatusOK, overlay)
+}
+
+func (m *mockRevosServer) handleShares(w http.ResponseWriter, r *http.Request, overlayID, shareID string) {
+	shares := m.shares[overlayID]
+	if shares == nil {
+		shares = map[string]map[string]interface{}{}
+		m.shares[overlayID] = shares
+	}
+
+	if shareID == "" {
+		switch r.Method {
+		case http.MethodGet:
+			list := make([]interface{}, 0, len(shares))
+			for _, share := range shares {
+				list = append(list, share)
+			}
+			m.writeData(w, http.StatusOK, list)
+		case http.MethodPost:
+			var payload map[string]interface{}
+			if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+				m.writeError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			m.nextID++
+			share := map[string]interface{}{
+				"id":        fmt.Sprintf("sh-%d", m.nextID),
+				"overlayId": overlayID,
+				"createdAt": time.Now().UTC().Format(time.RFC3339),
+			}
+			for k, v := range payload {
+				share[k] = v
+			}
+			shares[share["id"].(string)] = share
+			m.writeData(w, http.StatusCreated, share)
+		default:
+			m.writeError(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+		}
+		return
+	}
+
+	share, ok := shares[shareID]
+	if !ok {
+		m.writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		m.writeData(w, http.StatusOK, share)
+	case http.MethodPatch:
+		var payload map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			m.writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if _, ok := payload["principalId"]; ok {
+			m.writeError(w, http.StatusBadRequest, "principalId is immutable")
+			return
+		}
+		for k, v := range payload {
+			share[k] = v
+		}
+		m.writeData(w, http.StatusOK, share)
+	case http.MethodDelete:
+		delete(shares, shareID)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		m.writeError(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+	}
+}
+
+func (m *mockRevosServer) handleBindings(w http.ResponseWriter, r *http.Request, overlayID, bindingID string) {
+	bindings := m.bindings[overlayID]
+	if bindings == nil {
+		bindings = map[string]map[string]interface{}{}
+		m.bindings[overlayID] = bindings
+	}
+
+	if bindingID == "" {
+		switch r.Method {
+		case http.MethodGet:
+			list := make([]interface{}, 0, len(bindings))
+			for _, binding := range bindings {
+				list = append(list, binding)
+			}
+			m.writeData(w, http.StatusOK, list)
+		case http.MethodPost:
+			var payload map[string]interface{}
+			if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+				m.writeError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			m.nextID++
+			binding := map[string]interface{}{
+				"id":        fmt.Sprintf("bd-%d", m.nextID),
+				"overlayId": overlayID,
+				"createdAt": time.Now().UTC().Format(time.RFC3339),
+			}
+			for k, v := range payload {
+				binding[k] = v
+			}
+			bindings[binding["id"].(string)] = binding
+			m.writeData(w, http.StatusCreated, binding)
+		default:
+			m.writeError(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+		}
+		return
+	}
+
+	binding, ok := bindings[bindingID]
+	if !ok {
+		m.writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		m.writeData(w, http.StatusOK, binding)
+	case http.MethodPatch:
+		var payload map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			m.writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		for k, v := range payload {
+			binding[k] = v
+		}
+		m.writeData(w, http.StatusOK, binding)
+	case http.MethodDelete:
+		delete(bindings, bindingID)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		m.writeError(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+	}
+}
+
+func (m *mockRevosServer) handleTemplates(w http.ResponseWriter, r *http.Request, id string) {
+	if id == "" {
+		if r.Method != http.MethodPost {
+			m.writeError(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+			return
+		}
+		var payload map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			m.writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		m.nextID++
+		now := time.Now().UTC().Format(time.RFC3339)
+		template := map[string]interface{}{
+			"id":        fmt.Sprintf("tpl-%d", m.nextID),
+			"createdAt": now,
+			"updatedAt": now,
+		}
+		for k, v := range payload {
+			template[k] = v
+		}
+		m.templates[template["id"].(string)] = template
+		m.writeData(w, http.StatusCreated, template)
+		return
+	}
+
+	template, ok := m.templates[id]
+	if !ok {
+		m.writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		m.writeData(w, http.StatusOK, template)
+	case http.MethodPatch:
+		var payload map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			m.writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		for k, v := range payload {
+			template[k] = v
+		}
+		template["updatedAt"] = time.Now().UTC().Format(time.RFC3339Nano)
+		m.writeData(w, http.StatusOK, template)
+	case http.MethodDelete:
+		delete(m.templates, id)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		m.writeError(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+	}
+}
+
+func (m *mockRevosServer) templateCount() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return len(m.templates)
+}
+
+// handleValidate validates an overlay definition: it must have a "cubes"
+// array, and top-level keys other than "cubes" and "views" are warned about.
+func (m *mockRevosServer) handleValidate(w http.ResponseWriter, r *http.Request) {
+	var payload struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		m.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	errs := []interface{}{}
+	warnings := []interface{}{}
+	if _, ok := payload.Data["cubes"].([]interface{}); !ok {
+		errs = append(errs, "cubes: must be an array")
+	}
+	keys := make([]string, 0, len(payload.Data))
+	for k := range payload.Data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if k != "cubes" && k != "views" {
+			warnings = append(warnings, fmt.Sprintf("%s: unknown key", k))
+		}
+	}
+
+	m.writeData(w, http.StatusOK, map[string]interface{}{
+		"valid":    len(errs) == 0,
+		"errors":   errs,
+		"warnings": warnings,
+	})
+}
+
+// handleSearch lists the active overlays matching the nameContains,
+// createdBy and createdAfter query parameters.
+func (m *mockRevosServer) handleSearch(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	var createdAfter time.Time
+	if v := query.Get("createdAfter"); v != "" {
+		var err error
+		if createdAfter, err = time.Parse(time.RFC3339, v); err != nil {
+			m.writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	list := []interface{}{}
+	for _, k := range m.sortedIDs() {
+		overlay := m.overlays[k]
+		if overlay["archived"] == true {
+			continue
+		}
+		name, _ := overlay["name"].(string)
+		if !strings.Contains(strings.ToLower(name), strings.ToLower(query.Get("nameContains"))) {
+			continue
+		}
+		if v := query.Get("createdBy"); v != "" && overlay["createdBy"] != v {
+			continue
+		}
+		if !createdAfter.IsZero() {
+			createdAt, _ := time.Parse(time.RFC3339, overlay["createdAt"].(string))
+			if !createdAt.After(createdAfter) {
+				continue
+			}
+		}
+		list = append(list, overlay)
+	}
+	m.writeData(w, http.StatusOK, list)
+}
+
+func (m *mockRevosServer) shareCount(overlayID string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return len(m.shares[overlayID])
+}
+
+// binding returns a copy of a binding stored on the server, or nil.
+func (m *mockRevosServer) binding(overlayID, bindingID string) map[string]interface{} {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	binding, ok := m.bindings[overlayID][bindingID]
+	if !ok {
+		return nil
+	}
+	out := map[string]interface{}{}
+	for k, v := range binding {
+		out[k] = v
+	}
+	return out
+}
+
+func (m *mockRevosServer) sortedIDs() []string {
+	ids := make([]string, 0, len(m.overlays))
+	for id := range m.overlays {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// matchesVersion checks the If-Match and If-Unmodified-Since headers, if
+// any, against the overlay's current version and updatedAt.
+func (m *mockRevosServer) matchesVersion(r *http.Request, id string) bool {
+	if ifMatch := r.Header.Get("If-Match"); ifMatch != "" && ifMatch != m.etag(id) {
+		return false
+	}
+	if since := r.Header.Get("If-Unmodified-Since"); since != "" {
+		unmodifiedSince, err := http.ParseTime(since)
+		if err != nil {
+			return false
+		}
+		updatedAt, _ := time.Parse(time.RFC3339, m.overlays[id]["updatedAt"].(string))
+		if updatedAt.After(unmodifiedSince) {
+			return false
+		}
+	}
+	return true
+}
+
+func (m *mockRevosServer) etag(id string) string {
+	return fmt.Sprintf(`"%d"`, m.versions[id])
+}
+
+func (m *mockRevosServer) writeOverlay(w http.ResponseWriter, status int, overlay map[string]interface{}) {
+	if !m.unversioned {
+		w.Header().Set("ETag", m.etag(overlay["id"].(string)))
+	}
+	if m.omitOrganizationID {
+		trimmed := map[string]interface{}{}
+		for k, v := range overlay {
+			if k != "organizationId" {
+				trimmed[k] = v
+			}
+		}
+		overlay = trimmed
+	}
+	m.writeData(w, status, overlay)
+}
+
+// withWarnings adds the configured warnings to a create or update response.
+func (m *mockRevosServer) withWarnings(overlay map[string]interface{}) map[string]interface{} {
+	if len(m.warnings) == 0 {
+		return overlay
+	}
+	response := map[string]interface{}{"warnings": m.warnings}
+	for k, v := range overlay {
+		response[k] = v
+	}
+	return response
+}
+
+func (m *mockRevosServer) writeData(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write([]byte(`{"data":`))
+	writeReordered(w, v)
+	w.Write([]byte(`}`))
+}
+
+func (m *mockRevosServer) writeError(w http.ResponseWriter, status int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	fmt.Fprintf(w, `{"message":%q}`, msg)
+}
+
+func (m *mockRevosServer) writeValidationErrors(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusUnprocessableEntity)
+	body, _ := json.Marshal(map[string]interface{}{"errors": m.validationErrors})
+	w.Write(body)
+}
+
+// writeReordered encodes v as JSON with object keys in reverse order, so
+// responses never match the key ordering Terraform submitted.
+func writeReordered(w io.Writer, v interface{}) {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Sort(sort.Reverse(sort.StringSlice(keys)))
+
+		var buf bytes.Buffer
+		buf.WriteByte('{')
+		for i, k := range keys {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			kb, _ := json.Marshal(k)
+			buf.Write(kb)
+			buf.WriteByte(':')
+			writeReordered(&buf, val[k])
+		}
+		buf.WriteByte('}')
+		w.Write(buf.Bytes())
+	case []interface{}:
+		w.Write([]byte{'['})
+		for i, item := range val {
+			if i > 0 {
+				w.Write([]byte{','})
+			}
+			writeReordered(w, item)
+		}
+		w.Write([]byte{']'})
+	default:
+		b, _ := json.Marshal(val)
+		w.Write(b)
+	}
+}
@@ -0,0 +1,115 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+var (
+	_ basetypes.StringTypable                    = NormalizedJSONType{}
+	_ basetypes.StringValuableWithSemanticEquals = NormalizedJSON{}
+)
+
+// NormalizedJSONType is a string type holding a JSON document. Its values
+// are semantically equal if they encode the same JSON, so the framework
+// keeps the prior value when the API returns the data reformatted or with
+// its keys reordered.
+type NormalizedJSONType struct {
+	basetypes.StringType
+}
+
+func (t NormalizedJSONType) String() string {
+	return "NormalizedJSONType"
+}
+
+func (t NormalizedJSONType) ValueType(ctx context.Context) attr.Value {
+	return NormalizedJSON{}
+}
+
+func (t NormalizedJSONType) Equal(o attr.Type) bool {
+	other, ok := o.(NormalizedJSONType)
+	if !ok {
+		return false
+	}
+	return t.StringType.Equal(other.StringType)
+}
+
+func (t NormalizedJSONType) ValueFromString(ctx context.Context, in basetypes.StringValue) (basetypes.StringValuable, diag.Diagnostics) {
+	return NormalizedJSON{StringValue: in}, nil
+}
+
+func (t NormalizedJSONType) ValueFromTerraform(ctx context.Context, in tftypes.Value) (attr.Value, error) {
+	attrValue, err := t.StringType.ValueFromTerraform(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+
+	stringValue, ok := attrValue.(basetypes.StringValue)
+	if !ok {
+		return nil, fmt.Errorf("unexpected value type of %T", attrValue)
+	}
+
+	stringValuable, diags := t.ValueFromString(ctx, stringValue)
+	if diags.HasError() {
+		return nil, fmt.Errorf("unexpected error converting StringValue to StringValuable: %v", diags)
+	}
+	return stringValuable, nil
+}
+
+// NormalizedJSON is a value of NormalizedJSONType
+type NormalizedJSON struct {
+	basetypes.StringValue
+}
+
+// NewNormalizedJSONValue returns a known NormalizedJSON holding s
+func NewNormalizedJSONValue(s string) NormalizedJSON {
+	return NormalizedJSON{StringValue: basetypes.NewStringValue(s)}
+}
+
+// NewNormalizedJSONNull returns a null NormalizedJSON
+func NewNormalizedJSONNull() NormalizedJSON {
+	return NormalizedJSON{StringValue: basetypes.NewStringNull()}
+}
+
+// NewNormalizedJSONUnknown returns an unknown NormalizedJSON
+func NewNormalizedJSONUnknown() NormalizedJSON {
+	return NormalizedJSON{StringValue: basetypes.NewStringUnknown()}
+}
+
+func (v NormalizedJSON) Type(ctx context.Context) attr.Type {
+	return NormalizedJSONType{}
+}
+
+func (v NormalizedJSON) Equal(o attr.Value) bool {
+	other, ok := o.(NormalizedJSON)
+	if !ok {
+		return false
+	}
+	return v.StringValue.Equal(other.StringValue)
+}
+
+// StringSemanticEquals reports whether both values encode the same JSON.
+// Values that aren't valid JSON, such as JSON5 data, are only equal to
+// themselves; reporting invalid data is left to validation.
+func (v NormalizedJSON) StringSemanticEquals(ctx context.Context, newValuable basetypes.StringValuable) (bool, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	newValue, ok := newValuable.(NormalizedJSON)
+	if !ok {
+		diags.AddError(
+			"Semantic Equality Check Error",
+			fmt.Sprintf("Expected value type %T, got %T. Please report this issue to the provider developers.", v, newValuable),
+		)
+		return false, diags
+	}
+
+	if v.StringValue.Equal(newValue.StringValue) {
+		return true, diags
+	}
+	return jsonEqual(v.ValueString(), newValue.ValueString()), diags
+}
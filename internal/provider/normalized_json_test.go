@@ -0,0 +1,62 @@
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+func TestNormalizedJSON_StringSemanticEquals(t *testing.T) {
+	tests := []struct {
+		name     string
+		prior    NormalizedJSON
+		new      NormalizedJSON
+		expected bool
+	}{
+		{name: "identical", prior: NewNormalizedJSONValue(`{"a":1}`), new: NewNormalizedJSONValue(`{"a":1}`), expected: true},
+		{name: "reordered keys", prior: NewNormalizedJSONValue(`{"a":1,"b":2}`), new: NewNormalizedJSONValue(`{"b":2,"a":1}`), expected: true},
+		{name: "whitespace", prior: NewNormalizedJSONValue("{\n  \"a\": [1, 2]\n}"), new: NewNormalizedJSONValue(`{"a":[1,2]}`), expected: true},
+		{name: "different value", prior: NewNormalizedJSONValue(`{"a":1}`), new: NewNormalizedJSONValue(`{"a":2}`), expected: false},
+		{name: "reordered array", prior: NewNormalizedJSONValue(`[1,2]`), new: NewNormalizedJSONValue(`[2,1]`), expected: false},
+		{name: "invalid prior", prior: NewNormalizedJSONValue(`{"a":`), new: NewNormalizedJSONValue(`{"a":1}`), expected: false},
+		{name: "invalid new", prior: NewNormalizedJSONValue(`{"a":1}`), new: NewNormalizedJSONValue(`{a:1}`), expected: false},
+		{name: "identical invalid", prior: NewNormalizedJSONValue(`{a:1}`), new: NewNormalizedJSONValue(`{a:1}`), expected: true},
+		{name: "null", prior: NewNormalizedJSONNull(), new: NewNormalizedJSONValue(`{}`), expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			equal, diags := tt.prior.StringSemanticEquals(context.Background(), tt.new)
+			if diags.HasError() {
+				t.Fatalf("unexpected diagnostics: %v", diags)
+			}
+			if equal != tt.expected {
+				t.Errorf("StringSemanticEquals = %v, want %v", equal, tt.expected)
+			}
+		})
+	}
+
+	t.Run("other value type", func(t *testing.T) {
+		_, diags := NewNormalizedJSONValue(`{}`).StringSemanticEquals(context.Background(), types.StringValue(`{}`))
+		if !diags.HasError() {
+			t.Error("expected an error comparing with a plain string")
+		}
+	})
+}
+
+func TestNormalizedJSONType_ValueFromTerraform(t *testing.T) {
+	ctx := context.Background()
+
+	v, err := NormalizedJSONType{}.ValueFromTerraform(ctx, tftypes.NewValue(tftypes.String, `{"a":1}`))
+	if err != nil {
+		t.Fatalf("ValueFromTerraform: %s", err)
+	}
+	if !v.Equal(NewNormalizedJSONValue(`{"a":1}`)) {
+		t.Errorf("got %s, want a NormalizedJSON holding the string", v)
+	}
+	if !v.Type(ctx).Equal(NormalizedJSONType{}) {
+		t.Errorf("type = %s, want NormalizedJSONType", v.Type(ctx))
+	}
+}
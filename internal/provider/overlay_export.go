@@ -0,0 +1,82 @@
+package provider
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/revosai/terraform-provider-revos/internal/client"
+)
+
+// exportOverlayData writes overlay data to export_path, if set, as
+// canonical JSON: sorted keys, indented, with a trailing newline, so that
+// exports of equal data are identical and diff well in review. Failing to
+// write is only a warning, as the overlay itself was applied.
+func exportOverlayData(exportPath types.String, data string, diags *diag.Diagnostics) {
+	if exportPath.IsNull() || exportPath.IsUnknown() || exportPath.ValueString() == "" {
+		return
+	}
+
+	content, err := exportJSON(data)
+	if err == nil {
+		err = writeFileAtomic(exportPath.ValueString(), content)
+	}
+	if err != nil {
+		diags.AddAttributeWarning(path.Root("export_path"), "Overlay Export Failed",
+			fmt.Sprintf("Unable to export the overlay data to %s: %s", exportPath.ValueString(), err))
+	}
+}
+
+// exportJSON returns data as canonical indented JSON
+func exportJSON(data string) ([]byte, error) {
+	v, err := decodeJSON(data)
+	if err != nil {
+		return nil, err
+	}
+	// Keep <, > and & as they are, as in SQL
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// exportedData returns the data of an overlay as returned by the API, or
+// fallback if the response had none
+func exportedData(overlay *client.CubeOverlay, fallback types.String) string {
+	if len(overlay.Data) == 0 {
+		return fallback.ValueString()
+	}
+	return string(overlay.Data)
+}
+
+// writeFileAtomic writes a file through a temporary file in the same
+// directory, renamed over it, so readers never see a partial file
+func writeFileAtomic(name string, content []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(name), "."+filepath.Base(name)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0o644); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), name)
+}
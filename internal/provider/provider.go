@@ -2,18 +2,30 @@ package provider
 
 import (
 	"context"
+	"fmt"
+	"net/url"
 	"os"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/provider"
 	"github.com/hashicorp/terraform-plugin-framework/provider/schema"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
 	"github.com/revosai/terraform-provider-revos/internal/client"
+	"github.com/santhosh-tekuri/jsonschema/v5"
 )
 
 // Ensure RevosProvider satisfies various provider interfaces.
 var _ provider.Provider = &RevosProvider{}
+var _ provider.ProviderWithValidateConfig = &RevosProvider{}
+var _ provider.ProviderWithFunctions = &RevosProvider{}
 
 // RevosProvider defines the provider implementation.
 type RevosProvider struct {
@@ -25,8 +37,79 @@ type RevosProvider struct {
 
 // RevosProviderModel describes the provider data model.
 type RevosProviderModel struct {
-	APIURL types.String `tfsdk:"api_url"`
-	Token  types.String `tfsdk:"token"`
+	APIURL           types.String `tfsdk:"api_url"`
+	Token            types.String `tfsdk:"token"`
+	TokenFile        types.String `tfsdk:"token_file"`
+	Environment      types.String `tfsdk:"environment"`
+	AuthScheme       types.String `tfsdk:"auth_scheme"`
+	MaxResponseBytes types.Int64  `tfsdk:"max_response_bytes"`
+	CompressRequests types.Bool   `tfsdk:"compress_requests"`
+	ConcurrencyCheck types.Bool   `tfsdk:"concurrency_check"`
+	ResponseEnvelope types.String `tfsdk:"response_envelope"`
+	UpdateMethod     types.String `tfsdk:"update_method"`
+	LogHeaders       types.Bool   `tfsdk:"log_headers"`
+	LogRequestBodies types.Bool   `tfsdk:"log_request_bodies"`
+	LogMaskedKeys    types.List   `tfsdk:"log_masked_keys"`
+	StrictDecode     types.Bool   `tfsdk:"strict_decode"`
+	ReadOnly         types.Bool   `tfsdk:"read_only"`
+	RequestTimeout   types.String `tfsdk:"request_timeout"`
+	MaxRetries       types.Int64  `tfsdk:"max_retries"`
+	RetryOnMessages  types.List   `tfsdk:"retry_on_messages"`
+	PollInterval     types.String `tfsdk:"poll_interval"`
+	PollTimeout      types.String `tfsdk:"poll_timeout"`
+	DialTimeout      types.String `tfsdk:"dial_timeout"`
+	DialKeepAlive    types.String `tfsdk:"dial_keep_alive"`
+	DNSCacheTTL      types.String `tfsdk:"dns_cache_ttl"`
+	DefaultTags      types.Map    `tfsdk:"default_tags"`
+	CustomHeaders    types.Map    `tfsdk:"custom_headers"`
+	SigningKey       types.String `tfsdk:"signing_key"`
+	SigningHeader    types.String `tfsdk:"signing_header"`
+	AdoptServerData  types.Bool   `tfsdk:"adopt_server_data"`
+	MaxDataBytes     types.Int64  `tfsdk:"max_data_bytes"`
+	MinifyData       types.Bool   `tfsdk:"minify_data"`
+	DataSchemaFile   types.String `tfsdk:"data_schema_file"`
+
+	RetryMaxElapsedTime      types.String `tfsdk:"retry_max_elapsed_time"`
+	ReadCacheTTL             types.String `tfsdk:"read_cache_ttl"`
+	CreateConsistencyRetries types.Int64  `tfsdk:"create_consistency_retries"`
+	AllowCrossHostRedirect   types.Bool   `tfsdk:"allow_cross_host_redirect"`
+	IgnoreEnvironment        types.Bool   `tfsdk:"ignore_environment"`
+	UseCLIConfig             types.Bool   `tfsdk:"use_cli_config"`
+}
+
+// environmentAPIURLs are the API URLs of the hosted Revos deployments, by
+// the name the environment attribute selects them with
+var environmentAPIURLs = map[string]string{
+	"production": "https://api.revos.io",
+	"staging":    "https://staging.revos.io",
+	"eu":         "https://api.eu.revos.io",
+}
+
+// environmentNames lists the environment attribute's values for messages
+func environmentNames() string {
+	names := make([]string, 0, len(environmentAPIURLs))
+	for name := range environmentAPIURLs {
+		names = append(names, fmt.Sprintf("%q", name))
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// RevosProviderData is passed from the provider to resources and data sources.
+type RevosProviderData struct {
+	Client *client.Client
+	// DefaultTags are merged into the tags of every overlay
+	DefaultTags map[string]string
+	// AdoptServerData treats values the API adds to overlay data as not
+	// conflicting with the configuration
+	AdoptServerData bool
+	// MaxDataBytes is the largest overlay data sent to the API, or 0 for no
+	// limit
+	MaxDataBytes int64
+	// MinifyData strips whitespace from overlay data before sending it
+	MinifyData bool
+	// DataSchema is the JSON Schema overlay data must match, or nil
+	DataSchema *jsonschema.Schema
 }
 
 func New() provider.Provider {
@@ -45,17 +128,194 @@ func (p *RevosProvider) Schema(ctx context.Context, req provider.SchemaRequest,
 		Attributes: map[string]schema.Attribute{
 			"api_url": schema.StringAttribute{
 				Optional:    true,
-				Description: "The URL of the Revos API. Defaults to REVOSAI_API_URL environment variable.",
+				Description: "The URL of the Revos API, without a resource path such as /cube-overlays. Defaults to REVOSAI_API_URL environment variable.",
+			},
+			"environment": schema.StringAttribute{
+				Optional:    true,
+				Description: fmt.Sprintf("The hosted Revos deployment to use, one of %s, instead of setting its URL in api_url. api_url takes precedence over it, and it over the REVOSAI_API_URL environment variable.", environmentNames()),
 			},
 			"token": schema.StringAttribute{
 				Optional:    true,
 				Sensitive:   true,
 				Description: "The authentication token. Defaults to REVOSAI_TOKEN environment variable.",
 			},
+			"token_file": schema.StringAttribute{
+				Optional:    true,
+				Description: "Path to a file containing the authentication token, e.g. a mounted Kubernetes secret. Surrounding whitespace is trimmed. Takes precedence over REVOSAI_TOKEN. Conflicts with token.",
+			},
+			"auth_scheme": schema.StringAttribute{
+				Optional:    true,
+				Description: "How the token is sent to the API: \"bearer\" (Authorization: Bearer header, the default) or \"api_key\" (X-API-Key header).",
+			},
+			"max_response_bytes": schema.Int64Attribute{
+				Optional:    true,
+				Description: fmt.Sprintf("The maximum size in bytes of an API response body. Defaults to %d (32 MiB).", client.DefaultMaxResponseBytes),
+			},
+			"compress_requests": schema.BoolAttribute{
+				Optional:    true,
+				Description: fmt.Sprintf("Gzip request bodies larger than %d bytes. Only enable this if the API accepts gzip-encoded requests. Defaults to false.", client.CompressionThreshold),
+			},
+			"concurrency_check": schema.BoolAttribute{
+				Optional:    true,
+				Description: "Send the updated_at last read into state as If-Unmodified-Since on updates and deletes, so they fail if the overlay was changed since. Use this with API versions that don't return an overlay version. Defaults to false.",
+			},
+			"response_envelope": schema.StringAttribute{
+				Optional:    true,
+				Description: "How API responses are unwrapped: \"auto\" (the default) detects a { \"data\": ... } envelope, \"wrapped\" expects every response to have one and \"none\" expects none. Set this if detection misreads your overlays.",
+			},
+			"update_method": schema.StringAttribute{
+				Optional:    true,
+				Description: "The HTTP method overlays are updated with: \"PATCH\" (the default) or \"PUT\", which sends the whole overlay. Set \"PUT\" behind proxies or gateways that block PATCH.",
+			},
+			"log_headers": schema.BoolAttribute{
+				Optional:    true,
+				Description: "Log the response headers of every API request, such as rate limit headers, at debug level (TF_LOG=DEBUG). Credentials and cookies are never logged. Defaults to false.",
+			},
+			"log_request_bodies": schema.BoolAttribute{
+				Optional:    true,
+				Description: "Log the JSON bodies sent to and received from the API at trace level (TF_LOG=TRACE), to diagnose rejected requests. The values of keys listed in log_masked_keys are masked, including inside overlay data, and headers such as Authorization are never logged. Defaults to false.",
+			},
+			"log_masked_keys": schema.ListAttribute{
+				Optional:    true,
+				ElementType: types.StringType,
+				Description: fmt.Sprintf("The keys whose values are masked in bodies logged by log_request_bodies: any key containing one of them, ignoring case. Defaults to %s.", strings.Join(client.DefaultMaskedKeys, ", ")),
+			},
+			"read_only": schema.BoolAttribute{
+				Optional:    true,
+				Description: "Only send read requests to the API, so plans and refreshes, e.g. to detect drift in production, are guaranteed not to modify anything. Creating, updating or deleting fails, as do data sources that send other requests, such as revos_overlay_validation. Defaults to false.",
+			},
+			"strict_decode": schema.BoolAttribute{
+				Optional:    true,
+				Description: "Warn when the API returns overlay fields this provider version doesn't know, which usually means the provider needs upgrading. Defaults to false, ignoring them.",
+			},
+			"request_timeout": schema.StringAttribute{
+				Optional: true,
+				Description: "How long each API request may take, including reading the response, as a duration such as \"1m\". " +
+					fmt.Sprintf("Applies on top of resource timeouts. Defaults to %s for requests not bounded by a resource timeout.", client.DefaultRequestTimeout),
+			},
+			"max_retries": schema.Int64Attribute{
+				Optional:    true,
+				Description: "How many times to retry a read, update or delete that failed with a network error or a 429, 502, 503 or 504 response. Creates are only retried if they failed to connect, so they can't create duplicates. Waits between retries start at 1s and double. Defaults to 0, not retrying.",
+			},
+			"retry_on_messages": schema.ListAttribute{
+				Optional:    true,
+				ElementType: types.StringType,
+				Description: "Substrings of a successful API response that signal a transient failure, such as \"database connection reset\", for backends that report some errors with a 2xx status. A response containing one is retried like a 503 response, within max_retries, and fails otherwise. Defaults to none.",
+			},
+			"retry_max_elapsed_time": schema.StringAttribute{
+				Optional:    true,
+				Description: "The most time a request may take across all its retries, as a duration such as \"2m\". Once the next wait would exceed it, the last error is returned. Defaults to no limit.",
+			},
+			"read_cache_ttl": schema.StringAttribute{
+				Optional:    true,
+				Description: "How long to reuse the response to a read of the same API path, as a duration such as \"10s\", to cut the requests made by large refreshes. Any write clears the cached responses. Defaults to not caching.",
+			},
+			"dial_timeout": schema.StringAttribute{
+				Optional:    true,
+				Description: "How long connecting to the API may take, including resolving its host name, as a duration such as \"10s\". Defaults to \"30s\".",
+			},
+			"dial_keep_alive": schema.StringAttribute{
+				Optional:    true,
+				Description: "The interval between TCP keep-alive probes on connections to the API, as a duration such as \"15s\". Defaults to \"30s\".",
+			},
+			"dns_cache_ttl": schema.StringAttribute{
+				Optional:    true,
+				Description: "How long to reuse the resolved addresses of the API host for new connections, as a duration such as \"1m\", for environments with slow or flaky DNS. If resolving fails, the previous addresses are used until it succeeds again. Defaults to resolving the host for every connection.",
+			},
+			"poll_interval": schema.StringAttribute{
+				Optional:    true,
+				Description: fmt.Sprintf("How often to check on an overlay the API is still processing after creation, as a duration such as \"5s\". Defaults to %s.", client.DefaultPollInterval),
+			},
+			"poll_timeout": schema.StringAttribute{
+				Optional:    true,
+				Description: fmt.Sprintf("How long to wait for an overlay the API is still processing after creation, as a duration such as \"10m\". Defaults to %s.", client.DefaultPollTimeout),
+			},
+			"create_consistency_retries": schema.Int64Attribute{
+				Optional: true,
+				Description: "How many times to retry reading an overlay that the API answers 404 for right after it was created or while importing it, " +
+					fmt.Sprintf("for APIs that take a moment to serve new overlays. Retries are %s apart. Defaults to %d; 0 disables them.", client.DefaultCreateConsistencyDelay, client.DefaultCreateConsistencyRetries),
+			},
+			"default_tags": schema.MapAttribute{
+				Optional:    true,
+				ElementType: types.StringType,
+				Description: "Tags applied to every overlay managed by this provider. Tags set on a resource override these on key conflicts.",
+			},
+			"allow_cross_host_redirect": schema.BoolAttribute{
+				Optional:    true,
+				Description: "Follow API redirects to a different host, sending the token to it. By default such redirects fail, to avoid leaking the token. Defaults to false.",
+			},
+			"custom_headers": schema.MapAttribute{
+				Optional:    true,
+				ElementType: types.StringType,
+				Description: "Headers added to every API request, e.g. for a gateway such as Cloudflare Access in front of the API. Headers the provider sets itself, such as Authorization and Content-Type, can't be replaced and are ignored with a warning.",
+			},
+			"signing_key": schema.StringAttribute{
+				Optional:    true,
+				Sensitive:   true,
+				Description: "A shared secret to sign every API request with, for gateways that require signed requests. The hex-encoded HMAC-SHA256 of the request body, or of an empty body for requests without one, is sent in signing_header.",
+			},
+			"signing_header": schema.StringAttribute{
+				Optional:    true,
+				Description: fmt.Sprintf("The header request signatures are sent in. Requires signing_key. Defaults to %s.", client.DefaultSigningHeader),
+			},
+			"adopt_server_data": schema.BoolAttribute{
+				Optional:    true,
+				Description: "Keep the data the API stores for an overlay, including keys it adds such as server defaults, instead of planning to remove them. The configuration then only determines the values of the keys it sets. Defaults to false.",
+			},
+			"max_data_bytes": schema.Int64Attribute{
+				Optional:    true,
+				Description: "The largest overlay data, in bytes as sent to the API, that creates and updates send. Larger data fails before any request is made, instead of with a late 413 from the API. Defaults to no limit.",
+			},
+			"minify_data": schema.BoolAttribute{
+				Optional:    true,
+				Description: "Strip insignificant whitespace from overlay data before sending it, e.g. so that data formatted with file() fits within max_data_bytes. Defaults to false.",
+			},
+			"data_schema_file": schema.StringAttribute{
+				Optional:    true,
+				Description: "A JSON Schema document that the data of every overlay must match, e.g. to enforce naming conventions for cubes. Data is validated at plan time, after data_vars are substituted, and every violation is an error on data.",
+			},
+			"use_cli_config": schema.BoolAttribute{
+				Optional:    true,
+				Description: "Fall back to the API URL and token the Revos CLI stores in ~/.revos/config.json when they are set neither in the provider block nor in the environment. Defaults to false.",
+			},
+			"ignore_environment": schema.BoolAttribute{
+				Optional:    true,
+				Description: "Ignore the REVOSAI_API_URL and REVOSAI_TOKEN environment variables, so that api_url and a token must be configured explicitly. Use this on aliased providers for other environments, so they can't pick up another environment's credentials. Defaults to false.",
+			},
 		},
 	}
 }
 
+// ValidateConfig rejects conflicting and incomplete attribute combinations
+// before Configure runs. Values that are unknown until apply are skipped.
+func (p *RevosProvider) ValidateConfig(ctx context.Context, req provider.ValidateConfigRequest, resp *provider.ValidateConfigResponse) {
+	var data RevosProviderModel
+
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if !data.Token.IsNull() && !data.TokenFile.IsNull() {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("token_file"),
+			"Conflicting Token Attributes",
+			"Only one of token and token_file can be set.",
+		)
+	}
+
+	// Without the environment, there is nothing to fall back on
+	if data.IgnoreEnvironment.ValueBool() {
+		if data.APIURL.IsNull() && data.Environment.IsNull() {
+			resp.Diagnostics.AddAttributeError(path.Root("api_url"), "Missing API URL", "api_url or environment must be set, as ignore_environment is set")
+		}
+		if data.Token.IsNull() && data.TokenFile.IsNull() {
+			resp.Diagnostics.AddAttributeError(path.Root("token"), "Missing Token", "One of token and token_file must be set, as ignore_environment is set")
+		}
+	}
+}
+
 func (p *RevosProvider) Configure(ctx context.Context, req provider.ConfigureRequest, resp *provider.ConfigureResponse) {
 	var data RevosProviderModel
 
@@ -65,15 +325,84 @@ func (p *RevosProvider) Configure(ctx context.Context, req provider.ConfigureReq
 		return
 	}
 
-	apiURL := os.Getenv("REVOSAI_API_URL")
-	token := os.Getenv("REVOSAI_TOKEN")
+	var apiURL, token string
+	if !data.IgnoreEnvironment.ValueBool() {
+		apiURL = os.Getenv("REVOSAI_API_URL")
+		token = os.Getenv("REVOSAI_TOKEN")
+	}
+	apiURLSource, tokenSource := "REVOSAI_API_URL", "REVOSAI_TOKEN"
 
 	if !data.APIURL.IsNull() {
+		if apiURL != "" {
+			warnEnvOverridden(ctx, resp, "api_url", "REVOSAI_API_URL")
+		}
 		apiURL = data.APIURL.ValueString()
+		apiURLSource = "api_url"
+	} else if !data.Environment.IsNull() {
+		environment := data.Environment.ValueString()
+		environmentURL, ok := environmentAPIURLs[environment]
+		if !ok {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("environment"),
+				"Invalid Environment",
+				fmt.Sprintf("environment must be one of %s, got %q. Set api_url instead for other deployments.", environmentNames(), environment),
+			)
+			return
+		}
+		if apiURL != "" {
+			warnEnvOverridden(ctx, resp, "environment", "REVOSAI_API_URL")
+		}
+		apiURL = environmentURL
+		apiURLSource = "environment"
 	}
 
+	// Token precedence: token attribute, then token_file, then REVOSAI_TOKEN
 	if !data.Token.IsNull() {
+		if token != "" {
+			warnEnvOverridden(ctx, resp, "token", "REVOSAI_TOKEN")
+		}
 		token = data.Token.ValueString()
+		tokenSource = "token"
+	} else if !data.TokenFile.IsNull() {
+		if token != "" {
+			warnEnvOverridden(ctx, resp, "token_file", "REVOSAI_TOKEN")
+		}
+		tokenFile := data.TokenFile.ValueString()
+		contents, err := os.ReadFile(tokenFile)
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("token_file"),
+				"Unable to Read Token File",
+				fmt.Sprintf("Unable to read token from %q: %s", tokenFile, err),
+			)
+			return
+		}
+		token = strings.TrimSpace(string(contents))
+		tokenSource = "token_file"
+	}
+
+	// The CLI config is part of the local environment, so ignore_environment
+	// disables it too
+	if data.UseCLIConfig.ValueBool() && !data.IgnoreEnvironment.ValueBool() && (apiURL == "" || token == "") {
+		configPath, err := defaultCLIConfigPath()
+		var config cliConfig
+		if err == nil {
+			config, err = loadCLIConfig(configPath)
+		}
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("use_cli_config"),
+				"Unable to Read CLI Config",
+				fmt.Sprintf("Unable to read the Revos CLI config: %s", err),
+			)
+			return
+		}
+		if apiURL == "" && config.APIURL != "" {
+			apiURL, apiURLSource = config.APIURL, "cli_config"
+		}
+		if token == "" && config.Token != "" {
+			token, tokenSource = config.Token, "cli_config"
+		}
 	}
 
 	if apiURL == "" {
@@ -83,11 +412,190 @@ func (p *RevosProvider) Configure(ctx context.Context, req provider.ConfigureReq
 		// Let's assume user provides it.
 		// For now, let's not force error, client might handle empty URL or we can set a default.
 		// But let's report error if empty.
-		resp.Diagnostics.AddError("Missing API URL", "API URL must be configured via provider block or REVOSAI_API_URL")
+		if data.IgnoreEnvironment.ValueBool() {
+			resp.Diagnostics.AddAttributeError(path.Root("api_url"), "Missing API URL", "API URL must be configured via api_url or environment, as ignore_environment is set")
+		} else {
+			resp.Diagnostics.AddError("Missing API URL", "API URL must be configured via api_url, environment or REVOSAI_API_URL")
+		}
+	} else if normalized, err := normalizeAPIURL(apiURL); err != nil {
+		source := apiURLSourceName(apiURLSource)
+		resp.Diagnostics.AddAttributeError(
+			path.Root("api_url"),
+			"Invalid API URL",
+			fmt.Sprintf("%s must be an absolute http or https URL such as https://api.revos.ai, got %q: %s", source, apiURL, err),
+		)
+	} else {
+		apiURL = normalized
+		if base, segment, ok := stripResourcePath(apiURL); ok {
+			source := apiURLSourceName(apiURLSource)
+			tflog.Warn(ctx, "Stripping resource path from the API URL", map[string]interface{}{"segment": segment})
+			resp.Diagnostics.AddAttributeWarning(
+				path.Root("api_url"),
+				"API URL Includes Resource Path",
+				fmt.Sprintf("%s ends with %s, which the provider appends to the API URL itself. Using %s instead; set %s to it to remove this warning.", source, segment, base, source),
+			)
+			apiURL = base
+		}
 	}
 
 	if token == "" {
-		resp.Diagnostics.AddError("Missing Token", "Token must be configured via provider block or REVOSAI_TOKEN")
+		if data.IgnoreEnvironment.ValueBool() {
+			resp.Diagnostics.AddAttributeError(path.Root("token"), "Missing Token", "Token must be configured via the token or token_file attribute, as ignore_environment is set")
+		} else {
+			resp.Diagnostics.AddError("Missing Token", "Token must be configured via provider block, token_file or REVOSAI_TOKEN")
+		}
+	}
+
+	authScheme := client.AuthSchemeBearer
+	if !data.AuthScheme.IsNull() {
+		authScheme = data.AuthScheme.ValueString()
+	}
+
+	if authScheme != client.AuthSchemeBearer && authScheme != client.AuthSchemeAPIKey {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("auth_scheme"),
+			"Invalid Auth Scheme",
+			fmt.Sprintf("auth_scheme must be %q or %q, got %q", client.AuthSchemeBearer, client.AuthSchemeAPIKey, authScheme),
+		)
+	}
+
+	responseEnvelope := client.ResponseEnvelopeAuto
+	if !data.ResponseEnvelope.IsNull() {
+		responseEnvelope = data.ResponseEnvelope.ValueString()
+	}
+
+	switch responseEnvelope {
+	case client.ResponseEnvelopeAuto, client.ResponseEnvelopeWrapped, client.ResponseEnvelopeNone:
+	default:
+		resp.Diagnostics.AddAttributeError(
+			path.Root("response_envelope"),
+			"Invalid Response Envelope",
+			fmt.Sprintf("response_envelope must be %q, %q or %q, got %q", client.ResponseEnvelopeAuto, client.ResponseEnvelopeWrapped, client.ResponseEnvelopeNone, responseEnvelope),
+		)
+	}
+
+	updateMethod := client.UpdateMethodPatch
+	if !data.UpdateMethod.IsNull() {
+		updateMethod = data.UpdateMethod.ValueString()
+	}
+
+	switch updateMethod {
+	case client.UpdateMethodPatch, client.UpdateMethodPut:
+	default:
+		resp.Diagnostics.AddAttributeError(
+			path.Root("update_method"),
+			"Invalid Update Method",
+			fmt.Sprintf("update_method must be %q or %q, got %q", client.UpdateMethodPatch, client.UpdateMethodPut, updateMethod),
+		)
+	}
+
+	maxResponseBytes := int64(client.DefaultMaxResponseBytes)
+	if !data.MaxResponseBytes.IsNull() {
+		maxResponseBytes = data.MaxResponseBytes.ValueInt64()
+	}
+
+	if maxResponseBytes <= 0 {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("max_response_bytes"),
+			"Invalid Maximum Response Size",
+			fmt.Sprintf("max_response_bytes must be positive, got %d", maxResponseBytes),
+		)
+	}
+
+	maxRetries := data.MaxRetries.ValueInt64()
+	if maxRetries < 0 {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("max_retries"),
+			"Invalid Maximum Retries",
+			fmt.Sprintf("max_retries can't be negative, got %d", maxRetries),
+		)
+	}
+
+	createConsistencyRetries := data.CreateConsistencyRetries.ValueInt64()
+	if createConsistencyRetries < 0 {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("create_consistency_retries"),
+			"Invalid Create Consistency Retries",
+			fmt.Sprintf("create_consistency_retries can't be negative, got %d", createConsistencyRetries),
+		)
+	}
+
+	maxDataBytes := data.MaxDataBytes.ValueInt64()
+	if maxDataBytes < 0 {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("max_data_bytes"),
+			"Invalid Maximum Data Size",
+			fmt.Sprintf("max_data_bytes can't be negative, got %d", maxDataBytes),
+		)
+	}
+
+	var dataSchema *jsonschema.Schema
+	if dataSchemaFile := data.DataSchemaFile.ValueString(); dataSchemaFile != "" {
+		var err error
+		dataSchema, err = compileDataSchema(dataSchemaFile)
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("data_schema_file"),
+				"Invalid Data Schema File",
+				fmt.Sprintf("Unable to load the JSON Schema in %q: %s", dataSchemaFile, err),
+			)
+		}
+	}
+
+	retryMaxElapsedTime := parseDurationAttribute(data.RetryMaxElapsedTime, "retry_max_elapsed_time", "Invalid Retry Budget", &resp.Diagnostics)
+	requestTimeout := parseDurationAttribute(data.RequestTimeout, "request_timeout", "Invalid Request Timeout", &resp.Diagnostics)
+	readCacheTTL := parseDurationAttribute(data.ReadCacheTTL, "read_cache_ttl", "Invalid Read Cache TTL", &resp.Diagnostics)
+	dialTimeout := parseDurationAttribute(data.DialTimeout, "dial_timeout", "Invalid Dial Timeout", &resp.Diagnostics)
+	dialKeepAlive := parseDurationAttribute(data.DialKeepAlive, "dial_keep_alive", "Invalid Dial Keep-Alive", &resp.Diagnostics)
+	dnsCacheTTL := parseDurationAttribute(data.DNSCacheTTL, "dns_cache_ttl", "Invalid DNS Cache TTL", &resp.Diagnostics)
+	pollInterval := parseDurationAttribute(data.PollInterval, "poll_interval", "Invalid Poll Interval", &resp.Diagnostics)
+	pollTimeout := parseDurationAttribute(data.PollTimeout, "poll_timeout", "Invalid Poll Timeout", &resp.Diagnostics)
+
+	var defaultTags map[string]string
+	if !data.DefaultTags.IsNull() {
+		resp.Diagnostics.Append(data.DefaultTags.ElementsAs(ctx, &defaultTags, false)...)
+	}
+
+	var retryOnMessages []string
+	if !data.RetryOnMessages.IsNull() {
+		resp.Diagnostics.Append(data.RetryOnMessages.ElementsAs(ctx, &retryOnMessages, false)...)
+	}
+
+	var maskedKeys []string
+	if !data.LogMaskedKeys.IsNull() {
+		maskedKeys = []string{}
+		resp.Diagnostics.Append(data.LogMaskedKeys.ElementsAs(ctx, &maskedKeys, false)...)
+	}
+
+	var customHeaders map[string]string
+	if !data.CustomHeaders.IsNull() {
+		resp.Diagnostics.Append(data.CustomHeaders.ElementsAs(ctx, &customHeaders, false)...)
+	}
+	for name := range customHeaders {
+		if client.IsReservedHeader(name) {
+			tflog.Warn(ctx, "Ignoring reserved custom header", map[string]interface{}{"header": name})
+			resp.Diagnostics.AddAttributeWarning(
+				path.Root("custom_headers").AtMapKey(name),
+				"Reserved Header Ignored",
+				fmt.Sprintf("The %s header is set by the provider and can't be replaced through custom_headers.", name),
+			)
+		}
+	}
+
+	signingHeader := data.SigningHeader.ValueString()
+	switch {
+	case !data.SigningHeader.IsNull() && data.SigningKey.ValueString() == "":
+		resp.Diagnostics.AddAttributeError(
+			path.Root("signing_header"),
+			"Missing Signing Key",
+			"signing_header only applies when signing_key is set.",
+		)
+	case !data.SigningHeader.IsNull() && (signingHeader == "" || client.IsReservedHeader(signingHeader)):
+		resp.Diagnostics.AddAttributeError(
+			path.Root("signing_header"),
+			"Invalid Signing Header",
+			fmt.Sprintf("signing_header must name a header the provider doesn't set itself, got %q", signingHeader),
+		)
 	}
 
 	if resp.Diagnostics.HasError() {
@@ -95,17 +603,221 @@ func (p *RevosProvider) Configure(ctx context.Context, req provider.ConfigureReq
 	}
 
 	c := client.NewClient(apiURL, token)
+	c.Headers = customHeaders
+	c.SigningKey = data.SigningKey.ValueString()
+	c.SigningHeader = signingHeader
+	c.AuthScheme = authScheme
+	c.MaxResponseBytes = maxResponseBytes
+	c.CompressRequests = data.CompressRequests.ValueBool()
+	c.ConcurrencyCheck = data.ConcurrencyCheck.ValueBool()
+	c.ResponseEnvelope = responseEnvelope
+	c.UpdateMethod = updateMethod
+	c.LogHeaders = data.LogHeaders.ValueBool()
+	c.LogBodies = data.LogRequestBodies.ValueBool()
+	c.MaskedKeys = maskedKeys
+	c.StrictDecode = data.StrictDecode.ValueBool()
+	c.ReadOnly = data.ReadOnly.ValueBool()
+	c.RequestTimeout = requestTimeout
+	c.MaxRetries = int(maxRetries)
+	c.RetryOnMessages = retryOnMessages
+	c.RetryMaxElapsedTime = retryMaxElapsedTime
+	c.ReadCacheTTL = readCacheTTL
+	if dialTimeout > 0 || dialKeepAlive > 0 || dnsCacheTTL > 0 {
+		c.SetDialer(client.DialerConfig{
+			DialTimeout: dialTimeout,
+			KeepAlive:   dialKeepAlive,
+			DNSCacheTTL: dnsCacheTTL,
+		})
+	}
+	if pollInterval > 0 {
+		c.PollInterval = pollInterval
+	}
+	if pollTimeout > 0 {
+		c.PollTimeout = pollTimeout
+	}
+	if !data.CreateConsistencyRetries.IsNull() {
+		c.CreateConsistencyRetries = int(createConsistencyRetries)
+	}
+	c.AllowCrossHostRedirect = data.AllowCrossHostRedirect.ValueBool()
+
+	logEffectiveConfig(ctx, c, apiURLSource, tokenSource)
+
+	if c.ReadOnly {
+		resp.Diagnostics.AddAttributeWarning(
+			path.Root("read_only"),
+			"Read-Only Mode",
+			"The provider is read-only: it won't send any request that could modify data. Plans and refreshes work, but applying changes will fail.",
+		)
+	}
+
+	providerData := &RevosProviderData{
+		Client:          c,
+		DefaultTags:     defaultTags,
+		AdoptServerData: data.AdoptServerData.ValueBool(),
+		MaxDataBytes:    maxDataBytes,
+		MinifyData:      data.MinifyData.ValueBool(),
+		DataSchema:      dataSchema,
+	}
+
+	resp.DataSourceData = providerData
+	resp.ResourceData = providerData
+}
+
+// apiURLSourceName describes where the API URL came from, for diagnostics
+func apiURLSourceName(source string) string {
+	if source == "cli_config" {
+		return "api_url in ~/.revos/config.json"
+	}
+	return source
+}
+
+// parseDurationAttribute parses an optional positive duration attribute,
+// returning zero if it is unset
+func parseDurationAttribute(v types.String, attribute, summary string, diags *diag.Diagnostics) time.Duration {
+	if v.IsNull() {
+		return 0
+	}
+	d, err := time.ParseDuration(v.ValueString())
+	if err == nil && d <= 0 {
+		err = fmt.Errorf("must be positive")
+	}
+	if err != nil {
+		diags.AddAttributeError(path.Root(attribute), summary,
+			fmt.Sprintf("%s must be a duration such as \"30s\" or \"5m\", got %q: %s", attribute, v.ValueString(), err))
+		return 0
+	}
+	return d
+}
+
+// normalizeAPIURL checks the API URL is an absolute http(s) URL and strips
+// trailing slashes, since request paths are appended with a leading slash
+func normalizeAPIURL(raw string) (string, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", fmt.Errorf("missing or unsupported scheme %q", u.Scheme)
+	}
+	if u.Host == "" {
+		return "", fmt.Errorf("missing host")
+	}
+	return strings.TrimRight(raw, "/"), nil
+}
 
-	resp.DataSourceData = c
-	resp.ResourceData = c
+// resourcePaths are the API paths the client appends to the API URL, longest
+// first so that the most specific one is stripped
+var resourcePaths = []string{"/cube-overlay-templates", "/cube-overlays"}
+
+// stripResourcePath removes a resource path that was included in a
+// normalized API URL by mistake, which would otherwise double it in every
+// request, e.g. /cube-overlays/cube-overlays. It returns the base URL and the
+// removed segment.
+func stripResourcePath(apiURL string) (string, string, bool) {
+	u, err := url.Parse(apiURL)
+	if err != nil || u.RawQuery != "" || u.Fragment != "" {
+		return apiURL, "", false
+	}
+	for _, segment := range resourcePaths {
+		if strings.HasSuffix(strings.ToLower(u.Path), segment) {
+			return strings.TrimRight(apiURL[:len(apiURL)-len(segment)], "/"), segment, true
+		}
+	}
+	return apiURL, "", false
+}
+
+// logEffectiveConfig logs the configuration the client ended up with and
+// where the URL and token came from, to debug how attributes and environment
+// variables interacted. The token itself is never logged.
+func logEffectiveConfig(ctx context.Context, c *client.Client, apiURLSource, tokenSource string) {
+	requestTimeout := c.RequestTimeout
+	if requestTimeout <= 0 {
+		requestTimeout = client.DefaultRequestTimeout
+	}
+
+	apiURL := c.APIURL
+	if u, err := url.Parse(apiURL); err == nil {
+		apiURL = u.Redacted()
+	}
+
+	tflog.Info(ctx, "Configured Revos provider", map[string]interface{}{
+		"api_url":                    apiURL,
+		"api_url_source":             apiURLSource,
+		"token_set":                  c.Token != "",
+		"token_source":               tokenSource,
+		"auth_scheme":                c.AuthScheme,
+		"request_timeout":            requestTimeout.String(),
+		"max_retries":                c.MaxRetries,
+		"retry_on_messages":          c.RetryOnMessages,
+		"retry_max_elapsed_time":     c.RetryMaxElapsedTime.String(),
+		"read_cache_ttl":             c.ReadCacheTTL.String(),
+		"max_response_bytes":         c.MaxResponseBytes,
+		"compress_requests":          c.CompressRequests,
+		"response_envelope":          c.ResponseEnvelope,
+		"update_method":              c.UpdateMethod,
+		"concurrency_check":          c.ConcurrencyCheck,
+		"strict_decode":              c.StrictDecode,
+		"read_only":                  c.ReadOnly,
+		"log_request_bodies":         c.LogBodies,
+		"poll_interval":              c.PollInterval.String(),
+		"poll_timeout":               c.PollTimeout.String(),
+		"create_consistency_retries": c.CreateConsistencyRetries,
+		"allow_cross_host_redirect":  c.AllowCrossHostRedirect,
+		"custom_headers":             headerNames(c.Headers),
+		"signing_enabled":            c.SigningKey != "",
+		"signing_header":             c.SigningHeader,
+	})
+}
+
+// headerNames returns the sorted names of headers, whose values may be
+// credentials and are not logged
+func headerNames(headers map[string]string) []string {
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// warnEnvOverridden reports that an explicitly configured attribute is used
+// instead of an environment variable that is also set
+func warnEnvOverridden(ctx context.Context, resp *provider.ConfigureResponse, attribute, envVar string) {
+	tflog.Warn(ctx, "Provider attribute overrides environment variable", map[string]interface{}{
+		"attribute":    attribute,
+		"env_variable": envVar,
+	})
+	resp.Diagnostics.AddAttributeWarning(
+		path.Root(attribute),
+		"Environment Variable Overridden",
+		fmt.Sprintf("Both the %s attribute and the %s environment variable are set. The %s attribute takes precedence.", attribute, envVar, attribute),
+	)
 }
 
 func (p *RevosProvider) Resources(ctx context.Context) []func() resource.Resource {
 	return []func() resource.Resource{
 		NewOverlayResource,
+		NewOverlayBindingResource,
+		NewOverlayShareResource,
+		NewOverlayTemplateResource,
 	}
 }
 
 func (p *RevosProvider) DataSources(ctx context.Context) []func() datasource.DataSource {
-	return []func() datasource.DataSource{}
+	return []func() datasource.DataSource{
+		NewOverlayValidationDataSource,
+		NewOverlayVersionsDataSource,
+		NewOverlaysDataSource,
+		NewOverlaySearchDataSource,
+		NewOverlayGraphDataSource,
+		NewOverlayDiffDataSource,
+		NewOverlayDataDataSource,
+	}
+}
+
+func (p *RevosProvider) Functions(ctx context.Context) []func() function.Function {
+	return []func() function.Function{
+		NewNormalizeJSONFunction,
+		NewIsValidOverlayFunction,
+	}
 }
@@ -1,25 +1,50 @@
 package provider
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math/big"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
 	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
 	"github.com/revosai/terraform-provider-revos/internal/client"
+	"github.com/santhosh-tekuri/jsonschema/v5"
 )
 
 // Ensure implementation satisfies interfaces.
 var _ resource.Resource = &OverlayResource{}
 var _ resource.ResourceWithImportState = &OverlayResource{}
+var _ resource.ResourceWithValidateConfig = &OverlayResource{}
 
-// jsonSemanticEqualModifier is a plan modifier that suppresses diffs for JSON strings
-// that are semantically equal (same content, different key ordering)
+// jsonSemanticEqualModifier is a plan modifier that keeps the data in state
+// when the configured data differs from it only at ignore_data_paths or, with
+// case_insensitive_data_values, in the case of string values. NormalizedJSON
+// can't see those attributes, and the framework only applies its semantic
+// equality to values the provider returns, not to the configuration at plan
+// time, so the modifier also covers data that is merely reformatted.
 type jsonSemanticEqualModifier struct{}
 
 func (m jsonSemanticEqualModifier) Description(ctx context.Context) string {
@@ -41,13 +66,147 @@ func (m jsonSemanticEqualModifier) PlanModifyString(ctx context.Context, req pla
 		return
 	}
 
+	ignorePaths := types.ListNull(types.StringType)
+	foldCase := types.BoolNull()
+	if req.Config.Schema != nil {
+		resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("ignore_data_paths"), &ignorePaths)...)
+		resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("case_insensitive_data_values"), &foldCase)...)
+		if resp.Diagnostics.HasError() || ignorePaths.IsUnknown() || foldCase.IsUnknown() {
+			return
+		}
+	}
+
 	// Compare semantically
-	if jsonEqual(req.StateValue.ValueString(), req.ConfigValue.ValueString()) {
+	if jsonEqualIgnoring(req.StateValue.ValueString(), req.ConfigValue.ValueString(), stringElements(ignorePaths), foldCase.ValueBool()) {
 		// They're semantically equal, use state value to suppress diff
 		resp.PlanValue = req.StateValue
 	}
 }
 
+// Overlay naming rules enforced by the Revos API
+const (
+	overlayNameMaxLength = 128
+	overlayNamePattern   = `^[A-Za-z0-9][A-Za-z0-9_.-]*$`
+)
+
+var overlayNameRegexp = regexp.MustCompile(overlayNamePattern)
+
+// overlayNameValidator checks overlay names against the API's naming rules at
+// plan time, instead of letting them surface as a 400 on apply. With
+// reserved set, it checks a name prefix that leaves room for that many
+// generated characters.
+type overlayNameValidator struct {
+	reserved int
+}
+
+func (v overlayNameValidator) subject() string {
+	if v.reserved > 0 {
+		return "Name prefix"
+	}
+	return "Name"
+}
+
+func (v overlayNameValidator) Description(ctx context.Context) string {
+	return fmt.Sprintf("%s must be 1-%d characters, start with a letter or digit, and contain only letters, digits, '_', '.' and '-'", v.subject(), overlayNameMaxLength-v.reserved)
+}
+
+func (v overlayNameValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v overlayNameValidator) ValidateString(ctx context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+
+	name := req.ConfigValue.ValueString()
+	maxLength := overlayNameMaxLength - v.reserved
+
+	switch {
+	case name == "":
+		resp.Diagnostics.AddAttributeError(req.Path, "Invalid Overlay Name", fmt.Sprintf("%s must not be empty.", v.subject()))
+	case len(name) > maxLength:
+		resp.Diagnostics.AddAttributeError(req.Path, "Invalid Overlay Name",
+			fmt.Sprintf("%s must be at most %d characters, got %d.", v.subject(), maxLength, len(name)))
+	case !overlayNameRegexp.MatchString(name):
+		resp.Diagnostics.AddAttributeError(req.Path, "Invalid Overlay Name",
+			fmt.Sprintf("%s %q must start with a letter or digit and contain only letters, digits, '_', '.' and '-'.", v.subject(), name))
+	}
+}
+
+// generatedNameSuffixLength is the length of the unique suffix appended to
+// name_prefix
+const generatedNameSuffixLength = 26
+
+// generateOverlayName appends a unique suffix to prefix. The suffix starts
+// with a UTC timestamp, so generated names sort by creation time.
+func generateOverlayName(prefix string) (string, error) {
+	random, err := uuid.GenerateUUID()
+	if err != nil {
+		return "", err
+	}
+	timestamp := time.Now().UTC().Format("20060102150405")
+	return prefix + timestamp + strings.ReplaceAll(random, "-", "")[:generatedNameSuffixLength-len(timestamp)], nil
+}
+
+// requiresReplaceIfPrefixSet replaces the overlay when name_prefix changes to
+// a new prefix, but not when it is removed in favour of an explicit name
+var requiresReplaceIfPrefixSet = stringplanmodifier.RequiresReplaceIf(
+	func(ctx context.Context, req planmodifier.StringRequest, resp *stringplanmodifier.RequiresReplaceIfFuncResponse) {
+		resp.RequiresReplace = !req.PlanValue.IsNull()
+	},
+	"Changing name_prefix replaces the overlay with one with a newly generated name.",
+	"Changing name_prefix replaces the overlay with one with a newly generated name.",
+)
+
+// Supported values for the deletion_mode attribute
+const (
+	deletionModeDelete  = "delete"
+	deletionModeArchive = "archive"
+)
+
+// Supported values for the merge_strategy attribute
+const (
+	mergeStrategyReplace = "replace"
+	mergeStrategyMerge   = "merge"
+)
+
+// oneOfValidator checks a string attribute is one of a fixed set of values
+type oneOfValidator struct {
+	summary string
+	values  []string
+}
+
+func (v oneOfValidator) Description(ctx context.Context) string {
+	return fmt.Sprintf("Must be one of: %s", v.quoted())
+}
+
+func (v oneOfValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v oneOfValidator) ValidateString(ctx context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+
+	for _, value := range v.values {
+		if req.ConfigValue.ValueString() == value {
+			return
+		}
+	}
+	resp.Diagnostics.AddAttributeError(req.Path, v.summary,
+		fmt.Sprintf("%s must be one of %s, got %q.", req.Path, v.quoted(), req.ConfigValue.ValueString()))
+}
+
+func (v oneOfValidator) quoted() string {
+	quoted := make([]string, len(v.values))
+	for i, value := range v.values {
+		quoted[i] = strconv.Quote(value)
+	}
+	return strings.Join(quoted, ", ")
+}
+
 // Implement ResourceWithModifyPlan to handle computed field drift
 var _ resource.ResourceWithModifyPlan = &OverlayResource{}
 
@@ -57,32 +216,206 @@ func (r *OverlayResource) ModifyPlan(ctx context.Context, req resource.ModifyPla
 		return
 	}
 
-	// If creating, nothing to do
+	var plan OverlayResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// With data_base64, data is planned as the decoded definition, so the
+	// rest of the resource works on data alone
+	if !plan.DataBase64.IsNull() {
+		plan.Data = r.planDecodedData(ctx, req, plan, &resp.Diagnostics)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("data"), plan.Data)...)
+	}
+
+	// Plan the effective tags, provider defaults merged with resource tags,
+	// unless the resource tags aren't known yet
+	if !plan.Tags.IsUnknown() {
+		tagsAll, diags := r.tagsAll(ctx, plan.Tags)
+		resp.Diagnostics.Append(diags...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+		plan.TagsAll = tagsAll
+		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("tags_all"), tagsAll)...)
+	}
+
+	r.checkDependencies(ctx, plan.DependsOn, resp)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// The hash, size and cube names only depend on the rendered data, so they can be
+	// planned whenever the data and its variables are known
+	if !plan.Data.IsUnknown() && !plan.DataVars.IsUnknown() {
+		rendered, diags := renderedData(ctx, plan)
+		if !diags.HasError() {
+			resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("data_hash"), dataHashValue(rendered))...)
+			resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("definition_size_bytes"), definitionSizeValue(rendered))...)
+			resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("cube_names"), cubeNamesValue(rendered))...)
+			validateDataSchema(r.dataSchema, rendered.ValueString(), &resp.Diagnostics)
+		}
+	}
+
+	// If creating, nothing more to do
 	if req.State.Raw.IsNull() {
 		return
 	}
 
-	var plan, state OverlayResourceModel
-	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	var state OverlayResourceModel
 	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
 	if resp.Diagnostics.HasError() {
 		return
 	}
 
-	// Check if name, description, and data are unchanged
+	// The API's data, as refreshed into state, only conflicts with the
+	// configuration if it changed values the configuration sets
+	if r.adoptServerData && !plan.Data.Equal(state.Data) && dataAdoptable(ctx, plan, state) {
+		plan.Data = state.Data
+		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("data"), state.Data)...)
+		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("data_hash"), state.DataHash)...)
+		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("definition_size_bytes"), state.DefinitionSize)...)
+		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("cube_names"), state.CubeNames)...)
+	}
+
+	// Carry computed fields the plan doesn't change forward from state
+	preserved := append([]string{}, immutableComputedAttributes...)
+	if overlayUnchanged(plan, state) {
+		preserved = append(preserved, mutableComputedAttributes...)
+	} else {
+		// The framework only plans them unknown for configuration changes,
+		// not for data decoded from data_base64 differing from state
+		for _, name := range mutableComputedAttributes {
+			resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root(name), types.StringUnknown())...)
+		}
+	}
+	for _, name := range preserved {
+		var value attr.Value
+		resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root(name), &value)...)
+		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root(name), value)...)
+	}
+}
+
+// immutableComputedAttributes are set by the API when an overlay is created
+// and never change, so updates plan them from state
+var immutableComputedAttributes = []string{"organization_id", "created_by", "created_at"}
+
+// mutableComputedAttributes change whenever the API writes the overlay, so
+// they are only planned from state when the overlay is left unchanged
+var mutableComputedAttributes = []string{"updated_at", "version"}
+
+// planDecodedData returns the planned data for data_base64: the decoded
+// definition, or the data in state if that is semantically the same
+func (r *OverlayResource) planDecodedData(ctx context.Context, req resource.ModifyPlanRequest, plan OverlayResourceModel, diags *diag.Diagnostics) NormalizedJSON {
+	if plan.DataBase64.IsUnknown() {
+		return NewNormalizedJSONUnknown()
+	}
+
+	decoded, err := decodeDataBase64(plan.DataBase64.ValueString())
+	if err != nil {
+		diags.AddAttributeError(path.Root("data_base64"), "Invalid Base64 in data_base64", err.Error())
+		return NewNormalizedJSONUnknown()
+	}
+
+	if !req.State.Raw.IsNull() && !plan.IgnoreDataPaths.IsUnknown() && !plan.CaseInsensitive.IsUnknown() {
+		var stateData NormalizedJSON
+		diags.Append(req.State.GetAttribute(ctx, path.Root("data"), &stateData)...)
+		if !stateData.IsNull() && jsonEqualIgnoring(stateData.ValueString(), decoded, stringElements(plan.IgnoreDataPaths), plan.CaseInsensitive.ValueBool()) {
+			return stateData
+		}
+	}
+	return NewNormalizedJSONValue(decoded)
+}
+
+// decodeDataBase64 decodes data_base64. Whitespace, such as the line breaks
+// of base64 tools, is ignored, and padding is optional.
+func decodeDataBase64(s string) (string, error) {
+	s = strings.Join(strings.Fields(s), "")
+	decoded, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		decoded, err = base64.RawStdEncoding.DecodeString(s)
+	}
+	if err != nil {
+		return "", fmt.Errorf("data_base64 is not valid base64: %w", err)
+	}
+	return string(decoded), nil
+}
+
+// dataAdoptable reports whether the data in state contains the planned data,
+// once rendered: every key the configuration sets has the same value, and
+// the state only adds keys. Arrays must have the same length.
+func dataAdoptable(ctx context.Context, plan, state OverlayResourceModel) bool {
+	if plan.Data.IsUnknown() || plan.DataVars.IsUnknown() || plan.IgnoreDataPaths.IsUnknown() || state.Data.IsNull() {
+		return false
+	}
+
+	rendered, diags := renderedData(ctx, plan)
+	if diags.HasError() {
+		return false
+	}
+
+	ignore := stringElements(plan.IgnoreDataPaths)
+	return jsonContains(stripDataPaths(state.Data.ValueString(), ignore), stripDataPaths(rendered.ValueString(), ignore))
+}
+
+// checkDependencies reports depends_on_overlays entries that match no
+// overlay by ID or name
+func (r *OverlayResource) checkDependencies(ctx context.Context, dependsOn types.List, resp *resource.ModifyPlanResponse) {
+	if r.client == nil || dependsOn.IsNull() || dependsOn.IsUnknown() {
+		return
+	}
+
+	for i, element := range dependsOn.Elements() {
+		dependency, ok := element.(types.String)
+		if !ok || dependency.IsNull() || dependency.IsUnknown() {
+			continue
+		}
+		ref := dependency.ValueString()
+
+		_, err := r.client.GetOverlay(ctx, ref)
+		if client.IsNotFound(err) {
+			_, err = r.client.GetOverlayByName(ctx, ref)
+		}
+		if client.IsNotFound(err) {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("depends_on_overlays").AtListIndex(i),
+				"Missing Overlay Dependency",
+				fmt.Sprintf("No overlay has the ID or name %q.", ref),
+			)
+		} else if err != nil {
+			resp.Diagnostics.AddAttributeWarning(
+				path.Root("depends_on_overlays").AtListIndex(i),
+				"Unable to Check Overlay Dependency",
+				fmt.Sprintf("Unable to check that overlay %q exists, got error: %s", ref, err),
+			)
+		}
+	}
+}
+
+// overlayUnchanged reports whether a plan leaves the overlay as stored by the
+// API unchanged, so that only Terraform-side settings such as timeouts or
+// deletion_mode differ from the state
+func overlayUnchanged(plan, state OverlayResourceModel) bool {
+	return definitionUnchanged(plan, state) && plan.Locked.Equal(state.Locked)
+}
+
+// definitionUnchanged reports whether a plan leaves everything an update
+// sends unchanged. The lock is set separately.
+func definitionUnchanged(plan, state OverlayResourceModel) bool {
 	nameUnchanged := plan.Name.Equal(state.Name)
 	// Treat null and empty string as equal for description
 	descUnchanged := stringEqualOrBothEmpty(plan.Description, state.Description)
-	dataUnchanged := jsonEqual(plan.Data.ValueString(), state.Data.ValueString())
-
-	// If all user-controlled fields are unchanged, preserve computed fields from state
-	if nameUnchanged && descUnchanged && dataUnchanged {
-		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("organization_id"), state.OrganizationID)...)
-		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("created_by"), state.CreatedBy)...)
-		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("created_at"), state.CreatedAt)...)
-		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("updated_at"), state.UpdatedAt)...)
-		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("data"), state.Data)...)
-	}
+	dataUnchanged := plan.Data.Equal(state.Data) ||
+		jsonEqualIgnoring(plan.Data.ValueString(), state.Data.ValueString(), stringElements(plan.IgnoreDataPaths), plan.CaseInsensitive.ValueBool())
+	tagsUnchanged := plan.TagsAll.Equal(state.TagsAll)
+	varsUnchanged := plan.DataVars.Equal(state.DataVars)
+	enabledUnchanged := plan.Enabled.Equal(state.Enabled)
+
+	return nameUnchanged && descUnchanged && dataUnchanged && tagsUnchanged && varsUnchanged && enabledUnchanged
 }
 
 func NewOverlayResource() resource.Resource {
@@ -90,18 +423,140 @@ func NewOverlayResource() resource.Resource {
 }
 
 type OverlayResource struct {
-	client *client.Client
+	client          client.OverlayAPI
+	defaultTags     map[string]string
+	adoptServerData bool
+	maxDataBytes    int64
+	minifyData      bool
+	dataSchema      *jsonschema.Schema
 }
 
 type OverlayResourceModel struct {
-	ID             types.String `tfsdk:"id"`
-	Name           types.String `tfsdk:"name"`
-	Description    types.String `tfsdk:"description"`
-	OrganizationID types.String `tfsdk:"organization_id"`
-	Data           types.String `tfsdk:"data"` // JSON String
-	CreatedBy      types.String `tfsdk:"created_by"`
-	CreatedAt      types.String `tfsdk:"created_at"`
-	UpdatedAt      types.String `tfsdk:"updated_at"`
+	ID              types.String   `tfsdk:"id"`
+	Name            types.String   `tfsdk:"name"`
+	NamePrefix      types.String   `tfsdk:"name_prefix"`
+	Description     types.String   `tfsdk:"description"`
+	Enabled         types.Bool     `tfsdk:"enabled"`
+	Locked          types.Bool     `tfsdk:"locked"`
+	Type            types.String   `tfsdk:"type"`
+	OrganizationID  types.String   `tfsdk:"organization_id"`
+	Data            NormalizedJSON `tfsdk:"data"`
+	DataBase64      types.String   `tfsdk:"data_base64"`
+	DataVars        types.Map      `tfsdk:"data_vars"`
+	DataFormat      types.String   `tfsdk:"data_format"`
+	DeletionMode    types.String   `tfsdk:"deletion_mode"`
+	DeletionProtect types.Bool     `tfsdk:"deletion_protection"`
+	MergeStrategy   types.String   `tfsdk:"merge_strategy"`
+	ExportPath      types.String   `tfsdk:"export_path"`
+	DependsOn       types.List     `tfsdk:"depends_on_overlays"`
+	IgnoreDataPaths types.List     `tfsdk:"ignore_data_paths"`
+	CaseInsensitive types.Bool     `tfsdk:"case_insensitive_data_values"`
+	ValidateJoins   types.Bool     `tfsdk:"validate_joins"`
+	AdoptExisting   types.Bool     `tfsdk:"adopt_existing"`
+	CreatedBy       types.String   `tfsdk:"created_by"`
+	CreatedAt       types.String   `tfsdk:"created_at"`
+	UpdatedAt       types.String   `tfsdk:"updated_at"`
+	Version         types.String   `tfsdk:"version"`
+	Tags            types.Map      `tfsdk:"tags"`
+	TagsAll         types.Map      `tfsdk:"tags_all"`
+	DataHash        types.String   `tfsdk:"data_hash"`
+	DefinitionSize  types.Int64    `tfsdk:"definition_size_bytes"`
+	CubeNames       types.List     `tfsdk:"cube_names"`
+	Timeouts        timeouts.Value `tfsdk:"timeouts"`
+}
+
+// staleStateDetail explains how to recover when the API rejects a write
+// because the overlay changed since Terraform last read it
+const staleStateDetail = "The overlay was modified outside of Terraform since it was last read. " +
+	"Run `terraform apply -refresh-only` to refresh the state, review the changes, and apply again."
+
+// overlayPrecondition conditions a write on the overlay being as last read
+// into state
+func overlayPrecondition(state OverlayResourceModel) client.Precondition {
+	return client.Precondition{
+		Version:   client.Version(state.Version.ValueString()),
+		UpdatedAt: state.UpdatedAt.ValueString(),
+	}
+}
+
+// organizationIDValue returns the organization ID the API returned, or the
+// prior one if the response omitted it, as some older endpoints do. An
+// overlay never moves between organizations.
+func organizationIDValue(apiValue string, prior types.String) types.String {
+	if apiValue == "" && !prior.IsNull() && !prior.IsUnknown() {
+		return prior
+	}
+	return types.StringValue(apiValue)
+}
+
+// apiWarningDiagnostics turns the warnings the API returned with a create or
+// update into warning diagnostics
+func apiWarningDiagnostics(overlay *client.CubeOverlay) diag.Diagnostics {
+	var diags diag.Diagnostics
+	for _, warning := range overlay.Warnings {
+		diags.AddWarning("Revos API Warning", warning)
+	}
+	return diags
+}
+
+// payloadAttributes maps the overlay payload fields the API may report
+// validation errors for to their attribute. Other paths are within data.
+var payloadAttributes = map[string]string{
+	"name":        "name",
+	"description": "description",
+	"tags":        "tags",
+	"enabled":     "enabled",
+}
+
+// writeErrorDiagnostics reports a failed create or update. Validation errors
+// the API reports per field are attached to the attribute they concern, with
+// their location in data as a JSON Pointer. Other errors are reported as is.
+func writeErrorDiagnostics(action string, err error) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	var apiErr *client.APIError
+	if !errors.As(err, &apiErr) || len(apiErr.FieldErrors()) == 0 {
+		diags.AddError("Client Error", fmt.Sprintf("Unable to %s overlay, got error: %s", action, err))
+		return diags
+	}
+
+	requestID := ""
+	if apiErr.RequestID != "" {
+		requestID = fmt.Sprintf(" (request ID: %s)", apiErr.RequestID)
+	}
+	for _, fieldErr := range apiErr.FieldErrors() {
+		tokens := fieldPathTokens(fieldErr.Path)
+		if len(tokens) > 0 {
+			if attribute, ok := payloadAttributes[tokens[0]]; ok {
+				diags.AddAttributeError(path.Root(attribute), "API Validation Error",
+					fmt.Sprintf("The API rejected %s: %s%s", attribute, fieldErr.Message, requestID))
+				continue
+			}
+			if tokens[0] == "data" {
+				tokens = tokens[1:]
+			}
+		}
+
+		detail := fmt.Sprintf("The API rejected data: %s%s", fieldErr.Message, requestID)
+		if len(tokens) > 0 {
+			detail = fmt.Sprintf("The API rejected the value at %s in data: %s%s", jsonPointer(tokens), fieldErr.Message, requestID)
+		}
+		diags.AddAttributeError(path.Root("data"), "API Validation Error", detail)
+	}
+	return diags
+}
+
+// unknownFieldsDiagnostics warns about overlay fields the API returned that
+// this provider version doesn't know, which are only reported with the
+// provider's strict_decode
+func unknownFieldsDiagnostics(overlay *client.CubeOverlay) diag.Diagnostics {
+	var diags diag.Diagnostics
+	if len(overlay.UnknownFields) > 0 {
+		diags.AddWarning("Unknown Overlay Fields",
+			fmt.Sprintf("The API returned overlay fields this provider doesn't know: %s. "+
+				"The API may be newer than the provider; consider upgrading the provider.", strings.Join(overlay.UnknownFields, ", ")))
+	}
+	return diags
 }
 
 func (r *OverlayResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
@@ -113,24 +568,121 @@ func (r *OverlayResource) Schema(ctx context.Context, req resource.SchemaRequest
 		Description: "Manages a Revos Cube Overlay.",
 		Attributes: map[string]schema.Attribute{
 			"id": schema.StringAttribute{
-				Computed:    true,
+				Computed:      true,
 				PlanModifiers: []planmodifier.String{stringplanmodifier.UseStateForUnknown()},
 			},
 			"name": schema.StringAttribute{
-				Required:    true,
-				Description: "The name of the overlay. Must be unique.",
+				Optional:      true,
+				Computed:      true,
+				Description:   "The name of the overlay. Must be unique. Exactly one of name and name_prefix must be set.",
+				Validators:    []validator.String{overlayNameValidator{}},
+				PlanModifiers: []planmodifier.String{stringplanmodifier.UseStateForUnknown()},
+			},
+			"name_prefix": schema.StringAttribute{
+				Optional:      true,
+				Description:   fmt.Sprintf("Creates a unique name beginning with this prefix, followed by %d generated characters. Exactly one of name and name_prefix must be set.", generatedNameSuffixLength),
+				Validators:    []validator.String{overlayNameValidator{reserved: generatedNameSuffixLength}},
+				PlanModifiers: []planmodifier.String{requiresReplaceIfPrefixSet},
 			},
 			"description": schema.StringAttribute{
 				Optional:    true,
 				Description: "The description of the overlay.",
 			},
+			"enabled": schema.BoolAttribute{
+				Optional:    true,
+				Computed:    true,
+				Default:     booldefault.StaticBool(true),
+				Description: "Whether the overlay is active. A disabled overlay is kept but not applied, to stage a definition before activating it. Defaults to true.",
+			},
+			"locked": schema.BoolAttribute{
+				Optional:    true,
+				Computed:    true,
+				Default:     booldefault.StaticBool(false),
+				Description: "Whether the overlay is locked against edits in the Revos UI, so it can only be changed through the API, e.g. by Terraform. A lock or unlock made outside of Terraform is reported as drift. Defaults to false.",
+			},
+			"type": schema.StringAttribute{
+				Optional:    true,
+				Computed:    true,
+				Default:     stringdefault.StaticString(client.OverlayTypeCube),
+				Description: "The type of the overlay: \"cube\" (default), \"view\" or \"dashboard\". Changing it replaces the overlay.",
+				Validators: []validator.String{oneOfValidator{
+					summary: "Invalid Overlay Type",
+					values:  []string{client.OverlayTypeCube, client.OverlayTypeView, client.OverlayTypeDashboard},
+				}},
+				PlanModifiers: []planmodifier.String{stringplanmodifier.RequiresReplace()},
+			},
 			"organization_id": schema.StringAttribute{
 				Computed: true,
 			},
 			"data": schema.StringAttribute{
-				Required:      true,
-				Description:   "The JSON string representation of the Cube definition.",
+				CustomType:    NormalizedJSONType{},
+				Optional:      true,
+				Computed:      true,
+				Description:   "The JSON string representation of the Cube definition. Exactly one of data and data_base64 must be set; with data_base64, this is the decoded definition.",
 				PlanModifiers: []planmodifier.String{jsonSemanticEqualModifier{}},
+				Validators:    []validator.String{joinReferenceValidator{}},
+			},
+			"data_base64": schema.StringAttribute{
+				Optional:    true,
+				Description: "The Cube definition as base64-encoded JSON, e.g. when it is passed through a CI variable. It is decoded into data, which changes made outside of Terraform are compared against.",
+			},
+			"data_format": schema.StringAttribute{
+				Optional:    true,
+				Computed:    true,
+				Default:     stringdefault.StaticString(dataFormatJSON),
+				Description: "The format of data: \"json\" (default) for strict JSON, or \"json5\" to allow comments and trailing commas. JSON5 data is normalized to strict JSON before it is sent to the API.",
+				Validators: []validator.String{oneOfValidator{
+					summary: "Invalid Data Format",
+					values:  []string{dataFormatJSON, dataFormatJSON5},
+				}},
+			},
+			"deletion_mode": schema.StringAttribute{
+				Optional:    true,
+				Computed:    true,
+				Default:     stringdefault.StaticString(deletionModeDelete),
+				Description: "What destroying the resource does: \"delete\" (default) deletes the overlay, \"archive\" archives it so it is kept for audit.",
+				Validators: []validator.String{oneOfValidator{
+					summary: "Invalid Deletion Mode",
+					values:  []string{deletionModeDelete, deletionModeArchive},
+				}},
+			},
+			"deletion_protection": schema.BoolAttribute{
+				Optional:    true,
+				Computed:    true,
+				Default:     booldefault.StaticBool(false),
+				Description: "Refuse to destroy or replace the overlay while true. Unlike the prevent_destroy lifecycle argument, it can be set from a variable, e.g. in a module. Defaults to false.",
+			},
+			"merge_strategy": schema.StringAttribute{
+				Optional:    true,
+				Computed:    true,
+				Default:     stringdefault.StaticString(mergeStrategyReplace),
+				Description: "How updates apply data: \"replace\" (default) replaces the overlay's data, \"merge\" deep-merges it into the data on the server, so keys managed elsewhere are kept. Objects are merged key by key; arrays and other values replace the server's. In merge mode, keys on the server that data doesn't set are not drift.",
+				Validators: []validator.String{oneOfValidator{
+					summary: "Invalid Merge Strategy",
+					values:  []string{mergeStrategyReplace, mergeStrategyMerge},
+				}},
+			},
+			"export_path": schema.StringAttribute{
+				Optional:    true,
+				Description: "A local file to write the overlay's data to, as returned by the API, after every create, update and refresh that reads it, e.g. to keep a snapshot in version control for review. The data is written as canonical JSON with sorted keys and replaced atomically. Failing to write it is a warning.",
+			},
+			"data_vars": schema.MapAttribute{
+				Optional:    true,
+				ElementType: types.StringType,
+				Description: "Values substituted for ${key} placeholders in data before it is sent to the API. Every placeholder must have a value. Escape placeholders in HCL strings as $${key}.",
+			},
+			"data_hash": schema.StringAttribute{
+				Computed:    true,
+				Description: "The SHA-256 of the canonicalized data, after data_vars are substituted. Key order and whitespace don't affect it, so it only changes when the definition does.",
+			},
+			"definition_size_bytes": schema.Int64Attribute{
+				Computed:    true,
+				Description: "The size in bytes of the canonicalized data, after data_vars are substituted, e.g. to check that an overlay isn't growing too large.",
+			},
+			"cube_names": schema.ListAttribute{
+				Computed:    true,
+				ElementType: types.StringType,
+				Description: "The names of the cubes the data defines, after data_vars are substituted, in definition order. Empty if it defines none.",
 			},
 			"created_by": schema.StringAttribute{
 				Computed: true,
@@ -141,104 +693,707 @@ func (r *OverlayResource) Schema(ctx context.Context, req resource.SchemaRequest
 			"updated_at": schema.StringAttribute{
 				Computed: true,
 			},
+			"version": schema.StringAttribute{
+				Computed:    true,
+				Description: "The concurrency version (ETag) of the overlay, sent with updates and deletes to prevent overwriting changes made since the last read.",
+			},
+			"ignore_data_paths": schema.ListAttribute{
+				Optional:    true,
+				ElementType: types.StringType,
+				Description: "JSON Pointers (RFC 6901), such as \"/cubes/0/lastRefreshed\", to values in data that are managed by the API. Differences at these paths are not reported as drift or planned as changes.",
+			},
+			"case_insensitive_data_values": schema.BoolAttribute{
+				Optional: true,
+				Description: "Treat string values in data that only differ in case, such as \"ONE_TO_MANY\" and \"one_to_many\", as equal, for APIs that normalize the case of enum values. " +
+					"This applies to every string value, including SQL and names, so a change that only alters case is neither planned nor reported as drift. Defaults to false.",
+			},
+			"validate_joins": schema.BoolAttribute{
+				Optional:    true,
+				Description: "Warn at plan time about joins in data whose name or SQL references a cube data doesn't define. Defaults to false, as overlays may join cubes defined elsewhere.",
+			},
+			"adopt_existing": schema.BoolAttribute{
+				Optional:    true,
+				Description: "On create, take over an existing overlay with the same name instead of creating another one, updating it to this configuration. Archived overlays are not adopted. Defaults to false.",
+			},
+			"depends_on_overlays": schema.ListAttribute{
+				Optional:    true,
+				ElementType: types.StringType,
+				Description: "IDs or names of overlays this overlay references. They are checked to exist at plan time. This doesn't order operations; use depends_on for that.",
+			},
+			"tags": schema.MapAttribute{
+				Optional:    true,
+				ElementType: types.StringType,
+				Description: "Tags to label the overlay with. Overrides provider default_tags with the same key.",
+			},
+			"tags_all": schema.MapAttribute{
+				Computed:    true,
+				ElementType: types.StringType,
+				Description: "All tags of the overlay, including those inherited from the provider default_tags.",
+			},
 		},
+		Blocks: map[string]schema.Block{
+			"timeouts": timeouts.Block(ctx, timeouts.Opts{
+				Create: true,
+				Read:   true,
+				Update: true,
+				Delete: true,
+			}),
+		},
+	}
+}
+
+func (r *OverlayResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	providerData, ok := req.ProviderData.(*RevosProviderData)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Resource Configure Type",
+			fmt.Sprintf("Expected *RevosProviderData, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+		return
+	}
+
+	r.client = providerData.Client
+	r.defaultTags = providerData.DefaultTags
+	r.adoptServerData = providerData.AdoptServerData
+	r.maxDataBytes = providerData.MaxDataBytes
+	r.minifyData = providerData.MinifyData
+	r.dataSchema = providerData.DataSchema
+}
+
+func (r *OverlayResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var data OverlayResourceModel
+
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	createTimeout, diags := data.Timeouts.Create(ctx, 0)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	ctx, cancel := withTimeout(ctx, createTimeout)
+	defer cancel()
+
+	if data.Name.IsUnknown() && !data.NamePrefix.IsNull() {
+		name, err := generateOverlayName(data.NamePrefix.ValueString())
+		if err != nil {
+			resp.Diagnostics.AddError("Name Generation Error", fmt.Sprintf("Unable to generate a name from name_prefix, got error: %s", err))
+			return
+		}
+		data.Name = types.StringValue(name)
+	}
+
+	rendered, diags := renderedData(ctx, data)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	rawData, err := parseOverlayData(rendered.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("data"), "Invalid JSON in data", err.Error())
+		return
+	}
+	rawData = r.sizedData(rawData, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	tags, diags := r.mergedTags(ctx, data.Tags)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	payload := client.OverlayPayload{
+		Name:        data.Name.ValueString(),
+		Description: data.Description.ValueString(),
+		Data:        rawData,
+		Tags:        tags,
+		Enabled:     data.Enabled.ValueBoolPointer(),
+		Type:        data.Type.ValueString(),
+	}
+
+	var overlay *client.CubeOverlay
+	if data.AdoptExisting.ValueBool() {
+		overlay = r.adoptExistingOverlay(ctx, payload, &resp.Diagnostics)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	if overlay == nil {
+		overlay, err = r.client.CreateOverlay(ctx, payload)
+	}
+	if err != nil {
+		overlay = r.findCreatedOverlay(ctx, payload.Name, err)
+		if overlay == nil {
+			resp.Diagnostics.Append(writeErrorDiagnostics("create", err)...)
+			return
+		}
+
+		// Save what was created, so the next run reconciles it rather than
+		// creating a duplicate
+		resp.Diagnostics.AddError(
+			"Overlay Partially Created",
+			fmt.Sprintf("Creating the overlay failed with: %s\n\nThe overlay was created anyway with ID %s and has been saved to state. "+
+				"Run terraform apply again to reconcile it.", err, overlay.ID),
+		)
+	}
+
+	resp.Diagnostics.Append(unknownFieldsDiagnostics(overlay)...)
+	resp.Diagnostics.Append(apiWarningDiagnostics(overlay)...)
+
+	// Update computed fields from API response
+	data.ID = types.StringValue(overlay.ID)
+	data.OrganizationID = types.StringValue(overlay.OrganizationID)
+	data.CreatedBy = types.StringValue(overlay.CreatedBy)
+	data.CreatedAt = types.StringValue(overlay.CreatedAt)
+	data.UpdatedAt = types.StringValue(overlay.UpdatedAt)
+	data.Version = types.StringValue(string(overlay.Version))
+	data.TagsAll, diags = tagsValue(ctx, tags)
+	resp.Diagnostics.Append(diags...)
+	data.DataHash = dataHashValue(rendered)
+	data.DefinitionSize = definitionSizeValue(rendered)
+	data.CubeNames = cubeNamesValue(rendered)
+
+	// The overlay is created unlocked. If locking it fails, save it as such
+	// so the next run retries.
+	locked := data.Locked.ValueBool()
+	data.Locked = types.BoolValue(overlay.Locked)
+	if locked && !overlay.Locked && !resp.Diagnostics.HasError() {
+		resp.Diagnostics.Append(r.setOverlayLock(ctx, &data, true)...)
+	}
+
+	// Keep the planned data value - API returns same content but with different key ordering
+	// data.Data is already set from the plan, no need to update it
+
+	exportOverlayData(data.ExportPath, exportedData(overlay, rendered), &resp.Diagnostics)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+// setOverlayLock locks or unlocks the overlay of model, updating its lock
+// state and version from the response
+func (r *OverlayResource) setOverlayLock(ctx context.Context, model *OverlayResourceModel, locked bool) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	setLock, action := r.client.UnlockOverlay, "unlock"
+	if locked {
+		setLock, action = r.client.LockOverlay, "lock"
+	}
+	overlay, err := setLock(ctx, model.ID.ValueString(), overlayPrecondition(*model))
+	if err != nil {
+		if client.IsPreconditionFailed(err) {
+			diags.AddError("Overlay Modified Concurrently", staleStateDetail)
+			return diags
+		}
+		diags.AddError("Client Error", fmt.Sprintf("Unable to %s overlay, got error: %s", action, err))
+		return diags
+	}
+
+	model.Locked = types.BoolValue(overlay.Locked)
+	model.UpdatedAt = types.StringValue(overlay.UpdatedAt)
+	model.Version = types.StringValue(string(overlay.Version))
+	return diags
+}
+
+// withTimeout derives a context for an operation with the given timeout. A
+// zero timeout leaves each request to the client's default request timeout.
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// adoptExistingOverlay updates the active overlay named as planned to the
+// planned definition, so that it is managed from now on. It returns nil if
+// there is no such overlay, and reports errors to diags.
+func (r *OverlayResource) adoptExistingOverlay(ctx context.Context, payload client.OverlayPayload, diags *diag.Diagnostics) *client.CubeOverlay {
+	existing, err := r.client.GetOverlayByName(ctx, payload.Name)
+	if client.IsNotFound(err) || (err == nil && existing.Archived) {
+		return nil
+	}
+	if err == nil {
+		// Fetch it by ID too, as listings don't carry the version
+		existing, err = r.client.GetOverlay(ctx, existing.ID)
+	}
+	if err != nil {
+		diags.AddError("Client Error", fmt.Sprintf("Unable to look up an existing overlay named %q, got error: %s", payload.Name, err))
+		return nil
+	}
+
+	tflog.Info(ctx, "Adopting existing overlay", map[string]interface{}{
+		"id":   existing.ID,
+		"name": existing.Name,
+	})
+	overlay, err := r.client.UpdateOverlay(ctx, existing.ID, payload, client.Precondition{
+		Version:   existing.Version,
+		UpdatedAt: existing.UpdatedAt,
+	})
+	if err != nil {
+		if client.IsPreconditionFailed(err) {
+			diags.AddError("Overlay Modified Concurrently",
+				fmt.Sprintf("The existing overlay %s was modified while it was being adopted. Run terraform apply again.", existing.ID))
+			return nil
+		}
+		diags.AddError("Client Error", fmt.Sprintf("Unable to update existing overlay %s to adopt it, got error: %s", existing.ID, err))
+		return nil
+	}
+	return overlay
+}
+
+// createRecoveryTimeout bounds the lookup of an overlay a failed create may
+// have created, which runs after the create's own deadline may have passed
+const createRecoveryTimeout = time.Minute
+
+// findCreatedOverlay looks for an overlay that a failed create may have
+// created anyway, e.g. when the response was lost or couldn't be parsed.
+// Nothing was created if the request wasn't sent or was refused with a
+// client error (4xx), so there is nothing to find then. Archived overlays
+// with the name are older ones, so only active overlays are looked up.
+func (r *OverlayResource) findCreatedOverlay(ctx context.Context, name string, createErr error) *client.CubeOverlay {
+	var apiErr *client.APIError
+	var readOnlyErr *client.ReadOnlyError
+	if (errors.As(createErr, &apiErr) && apiErr.StatusCode < 500) || errors.As(createErr, &readOnlyErr) || client.IsNotSent(createErr) {
+		return nil
+	}
+
+	// A lost response often means the create timed out, so the lookup can't
+	// use the create's context
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), createRecoveryTimeout)
+	defer cancel()
+
+	overlay, err := r.client.GetCreatedOverlayByName(ctx, name)
+	if err == nil {
+		// Fetch it by ID too, as listings don't carry the version
+		overlay, err = r.client.GetOverlay(ctx, overlay.ID)
+	}
+	if err != nil {
+		tflog.Debug(ctx, "No overlay found after failed create", map[string]interface{}{
+			"name":  name,
+			"error": err.Error(),
+		})
+		return nil
+	}
+	return overlay
+}
+
+func (r *OverlayResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var data OverlayResourceModel
+
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	readTimeout, diags := data.Timeouts.Read(ctx, 0)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	ctx, cancel := withTimeout(ctx, readTimeout)
+	defer cancel()
+
+	overlay, modified, err := r.client.GetOverlayIfModified(ctx, data.ID.ValueString(), client.Version(data.Version.ValueString()))
+	if err != nil {
+		// If 404, remove from state
+		if client.IsNotFound(err) {
+			resp.State.RemoveResource(ctx)
+			return
+		}
+
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to read overlay, got error: %s", err))
+		return
+	}
+	if !modified {
+		// The overlay still has the version in state, so the state is
+		// current and its data needn't be compared again. The export is
+		// still rewritten, in case the file was removed or edited.
+		tflog.Debug(ctx, "Overlay not modified since last read", map[string]interface{}{"id": data.ID.ValueString()})
+		exportUnchangedData(ctx, data, &resp.Diagnostics)
+		return
+	}
+	resp.Diagnostics.Append(unknownFieldsDiagnostics(overlay)...)
+
+	// The next plan will rename the overlay back, so say why
+	if !data.Name.IsNull() && data.Name.ValueString() != overlay.Name {
+		tflog.Info(ctx, "Overlay was renamed outside of Terraform", map[string]interface{}{
+			"id":         overlay.ID,
+			"state_name": data.Name.ValueString(),
+			"api_name":   overlay.Name,
+		})
+	}
+	data.Name = types.StringValue(overlay.Name)
+	// The API doesn't distinguish an empty description from none, so keep
+	// whichever of null and "" the state already has, to match the config
+	if !stringEqualOrBothEmpty(data.Description, types.StringValue(overlay.Description)) {
+		data.Description = descriptionValue(overlay.Description)
+	}
+	data.Enabled = types.BoolValue(overlay.IsEnabled())
+	data.Locked = types.BoolValue(overlay.Locked)
+	data.Type = types.StringValue(overlay.OverlayType())
+	data.OrganizationID = organizationIDValue(overlay.OrganizationID, data.OrganizationID)
+	data.CreatedBy = types.StringValue(overlay.CreatedBy)
+	data.CreatedAt = types.StringValue(overlay.CreatedAt)
+	data.UpdatedAt = types.StringValue(overlay.UpdatedAt)
+	data.Version = types.StringValue(string(overlay.Version))
+
+	var priorTags map[string]string
+	resp.Diagnostics.Append(data.Tags.ElementsAs(ctx, &priorTags, false)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	tags, diags := tagsValue(ctx, resourceTags(overlay.Tags, r.defaultTags, priorTags))
+	resp.Diagnostics.Append(diags...)
+	// Keep an empty tags map from the config rather than flipping it to null
+	if !(tags.IsNull() && !data.Tags.IsNull() && len(data.Tags.Elements()) == 0) {
+		data.Tags = tags
+	}
+	data.TagsAll, diags = tagsValue(ctx, overlay.Tags)
+	resp.Diagnostics.Append(diags...)
+
+	// Only update data if semantically different (API returns different key
+	// ordering). The API holds the rendered data, so compare against that,
+	// after migrating any legacy layout the API still stores.
+	rendered, diags := renderedData(ctx, data)
+	if diags.HasError() {
+		rendered = data.Data.StringValue
+	}
+	apiData := migrateData(string(overlay.Data))
+	// In merge mode, the server may hold keys the data doesn't manage
+	merge := data.MergeStrategy.ValueString() == mergeStrategyMerge
+	if !jsonEqualIgnoring(rendered.ValueString(), apiData, stringElements(data.IgnoreDataPaths), data.CaseInsensitive.ValueBool()) &&
+		!(merge && !rendered.IsNull() && jsonContains(apiData, rendered.ValueString())) {
+		// The next plan will put the data back, so say what changed
+		if !rendered.IsNull() && !((r.adoptServerData || merge) && jsonContains(apiData, rendered.ValueString())) {
+			tflog.Info(ctx, "Overlay data was changed outside of Terraform", map[string]interface{}{
+				"id":           overlay.ID,
+				"changed_keys": jsonTopLevelDiff(rendered.ValueString(), apiData),
+			})
+		}
+		data.Data = NewNormalizedJSONValue(apiData)
+		rendered = data.Data.StringValue
+	}
+	data.DataHash = dataHashValue(rendered)
+	data.DefinitionSize = definitionSizeValue(rendered)
+	data.CubeNames = cubeNamesValue(rendered)
+
+	exportOverlayData(data.ExportPath, exportedData(overlay, rendered), &resp.Diagnostics)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *OverlayResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var data OverlayResourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	switch {
+	case !data.Name.IsNull() && !data.NamePrefix.IsNull():
+		resp.Diagnostics.AddAttributeError(path.Root("name_prefix"), "Conflicting Name Attributes",
+			"Only one of name and name_prefix can be set.")
+	case data.Name.IsNull() && data.NamePrefix.IsNull():
+		resp.Diagnostics.AddAttributeError(path.Root("name"), "Missing Name",
+			"One of name and name_prefix must be set.")
+	}
+
+	if !data.IgnoreDataPaths.IsUnknown() {
+		for i, element := range data.IgnoreDataPaths.Elements() {
+			pointer, ok := element.(types.String)
+			if !ok || pointer.IsNull() || pointer.IsUnknown() {
+				continue
+			}
+			if _, err := parseJSONPointer(pointer.ValueString()); err != nil {
+				resp.Diagnostics.AddAttributeError(path.Root("ignore_data_paths").AtListIndex(i), "Invalid JSON Pointer",
+					fmt.Sprintf("%q is not a valid JSON Pointer: %s.", pointer.ValueString(), err))
+			}
+		}
+	}
+
+	switch {
+	case !data.Data.IsNull() && !data.DataBase64.IsNull():
+		resp.Diagnostics.AddAttributeError(path.Root("data_base64"), "Conflicting Data Attributes",
+			"Only one of data and data_base64 can be set.")
+		return
+	case data.Data.IsNull() && data.DataBase64.IsNull():
+		resp.Diagnostics.AddAttributeError(path.Root("data"), "Missing Data",
+			"One of data and data_base64 must be set.")
+		return
+	}
+
+	if !data.DataBase64.IsNull() && !data.DataBase64.IsUnknown() {
+		decoded, err := decodeDataBase64(data.DataBase64.ValueString())
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("data_base64"), "Invalid Base64 in data_base64", err.Error())
+			return
+		}
+		data.Data = NewNormalizedJSONValue(decoded)
+	}
+
+	if data.Data.IsNull() || data.Data.IsUnknown() || data.DataFormat.IsUnknown() {
+		return
+	}
+
+	source, diags := sourceData(data)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if !data.DataBase64.IsNull() {
+		if _, err := parseOverlayData(source.ValueString()); err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("data_base64"), "Invalid JSON in data_base64", err.Error())
+			return
+		}
+	}
+
+	if data.DataVars.IsNull() || data.DataVars.IsUnknown() {
+		return
+	}
+
+	var vars map[string]string
+	resp.Diagnostics.Append(data.DataVars.ElementsAs(ctx, &vars, false)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if _, err := renderTemplate(source.ValueString(), vars); err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("data_vars"), "Unresolved Placeholder", err.Error())
+	}
+
+	used := map[string]bool{}
+	for _, name := range templatePlaceholders(source.ValueString()) {
+		used[name] = true
+	}
+	for name := range vars {
+		if !used[name] {
+			resp.Diagnostics.AddAttributeWarning(path.Root("data_vars"), "Unused Data Variable",
+				fmt.Sprintf("data_vars contains %q, but data has no ${%s} placeholder.", name, name))
+		}
+	}
+}
+
+// templatePlaceholderRegexp matches ${key} placeholders in overlay data
+var templatePlaceholderRegexp = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// templatePlaceholders returns the names of the placeholders in s, in order
+// of first appearance
+func templatePlaceholders(s string) []string {
+	var names []string
+	seen := map[string]bool{}
+	for _, m := range templatePlaceholderRegexp.FindAllStringSubmatch(s, -1) {
+		if !seen[m[1]] {
+			seen[m[1]] = true
+			names = append(names, m[1])
+		}
+	}
+	return names
+}
+
+// renderTemplate substitutes vars for the ${key} placeholders in the JSON
+// string s. Values are JSON-escaped, as placeholders are expected inside
+// JSON strings. It fails if any placeholder has no value.
+func renderTemplate(s string, vars map[string]string) (string, error) {
+	var missing []string
+	for _, name := range templatePlaceholders(s) {
+		if _, ok := vars[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return "", fmt.Errorf("data references placeholders with no value in data_vars: %s", strings.Join(missing, ", "))
+	}
+
+	return templatePlaceholderRegexp.ReplaceAllStringFunc(s, func(m string) string {
+		escaped, _ := json.Marshal(vars[templatePlaceholderRegexp.FindStringSubmatch(m)[1]])
+		return string(escaped[1 : len(escaped)-1])
+	}), nil
+}
+
+// trimDataInput strips a leading UTF-8 byte order mark and surrounding
+// whitespace, which definitions read with file() often have. encoding/json
+// rejects the byte order mark.
+func trimDataInput(s string) string {
+	return strings.TrimSpace(strings.TrimPrefix(s, "\ufeff"))
+}
+
+// sourceData returns the data attribute as strict JSON, normalizing it if
+// data_format is json5
+func sourceData(data OverlayResourceModel) (types.String, diag.Diagnostics) {
+	var diags diag.Diagnostics
+	if data.Data.IsNull() || data.Data.IsUnknown() {
+		return data.Data.StringValue, diags
+	}
+	if data.DataFormat.ValueString() != dataFormatJSON5 {
+		return types.StringValue(trimDataInput(data.Data.ValueString())), diags
+	}
+
+	normalized, err := normalizeJSON5(trimDataInput(data.Data.ValueString()))
+	if err != nil {
+		diags.AddAttributeError(path.Root("data"), "Invalid JSON5 in data", err.Error())
+		return data.Data.StringValue, diags
+	}
+	return types.StringValue(normalized), diags
+}
+
+// renderedData returns the data attribute as it is sent to the API: as
+// strict JSON, with data_vars substituted if set
+func renderedData(ctx context.Context, data OverlayResourceModel) (types.String, diag.Diagnostics) {
+	source, diags := sourceData(data)
+	if diags.HasError() || data.DataVars.IsNull() || source.IsNull() || source.IsUnknown() {
+		return source, diags
+	}
+
+	var vars map[string]string
+	diags.Append(data.DataVars.ElementsAs(ctx, &vars, false)...)
+	if diags.HasError() {
+		return source, diags
+	}
+
+	rendered, err := renderTemplate(source.ValueString(), vars)
+	if err != nil {
+		diags.AddAttributeError(path.Root("data_vars"), "Unresolved Placeholder", err.Error())
+		return source, diags
 	}
+	return types.StringValue(rendered), diags
 }
 
-func (r *OverlayResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
-	if req.ProviderData == nil {
-		return
+// parseOverlayData parses the data attribute, which must be a JSON object
+func parseOverlayData(s string) (json.RawMessage, error) {
+	var rawData json.RawMessage
+	if err := json.Unmarshal([]byte(trimDataInput(s)), &rawData); err != nil {
+		return nil, err
 	}
 
-	client, ok := req.ProviderData.(*client.Client)
-	if !ok {
-		resp.Diagnostics.AddError(
-			"Unexpected Resource Configure Type",
-			fmt.Sprintf("Expected *client.Client, got: %T. Please report this issue to the provider developers.", req.ProviderData),
-		)
-		return
+	var obj map[string]interface{}
+	if err := json.Unmarshal(rawData, &obj); err != nil || obj == nil {
+		return nil, fmt.Errorf("data must be a JSON object, got %s. Wrap the definition in an object, e.g. jsonencode({ ... })", jsonKind(rawData))
 	}
 
-	r.client = client
+	return rawData, nil
 }
 
-func (r *OverlayResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
-	var data OverlayResourceModel
-
-	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
-	if resp.Diagnostics.HasError() {
-		return
+// sizedData returns the overlay data to send, minified if minify_data is set.
+// Data larger than max_data_bytes is rejected here, since the API would only
+// reject it after the whole body was uploaded.
+func (r *OverlayResource) sizedData(rawData json.RawMessage, diags *diag.Diagnostics) json.RawMessage {
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, rawData); err != nil {
+		// parseOverlayData already accepted it
+		return rawData
 	}
-
-	var rawData json.RawMessage
-	if err := json.Unmarshal([]byte(data.Data.ValueString()), &rawData); err != nil {
-		resp.Diagnostics.AddError("Invalid JSON in data", err.Error())
-		return
+	if r.minifyData {
+		rawData = compact.Bytes()
 	}
 
-	payload := client.OverlayPayload{
-		Name:        data.Name.ValueString(),
-		Description: data.Description.ValueString(),
-		Data:        rawData,
+	if r.maxDataBytes == 0 || int64(len(rawData)) <= r.maxDataBytes {
+		return rawData
 	}
 
-	overlay, err := r.client.CreateOverlay(payload)
-	if err != nil {
-		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to create overlay, got error: %s", err))
-		return
+	detail := fmt.Sprintf("The overlay data is %d bytes, more than the provider's max_data_bytes of %d.", len(rawData), r.maxDataBytes)
+	if !r.minifyData && int64(compact.Len()) <= r.maxDataBytes {
+		detail += fmt.Sprintf(" Without whitespace it is %d bytes: set minify_data = true in the provider block to send it minified.", compact.Len())
+	} else {
+		detail += " Split the definition across several overlays, or raise max_data_bytes if the API accepts larger bodies."
 	}
+	diags.AddAttributeError(path.Root("data"), "Overlay Data Too Large", detail)
+	return nil
+}
 
-	// Update computed fields from API response
-	data.ID = types.StringValue(overlay.ID)
-	data.OrganizationID = types.StringValue(overlay.OrganizationID)
-	data.CreatedBy = types.StringValue(overlay.CreatedBy)
-	data.CreatedAt = types.StringValue(overlay.CreatedAt)
-	data.UpdatedAt = types.StringValue(overlay.UpdatedAt)
-
-	// Keep the planned data value - API returns same content but with different key ordering
-	// data.Data is already set from the plan, no need to update it
+// jsonKind describes the type of a JSON value for error messages
+func jsonKind(raw json.RawMessage) string {
+	var v interface{}
+	_ = json.Unmarshal(raw, &v)
 
-	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+	switch v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "a string"
+	case float64:
+		return "a number"
+	case bool:
+		return "a boolean"
+	case []interface{}:
+		return "an array"
+	default:
+		return "an object"
+	}
 }
 
-func (r *OverlayResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
-	var data OverlayResourceModel
-
-	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
-	if resp.Diagnostics.HasError() {
-		return
+// mergeTags merges resource tags over provider default tags. Resource tags
+// win on key conflicts. The result is never nil, so that the API clears
+// tags that were removed.
+func mergeTags(defaults, tags map[string]string) map[string]string {
+	merged := make(map[string]string, len(defaults)+len(tags))
+	for k, v := range defaults {
+		merged[k] = v
 	}
+	for k, v := range tags {
+		merged[k] = v
+	}
+	return merged
+}
 
-	overlay, err := r.client.GetOverlay(data.ID.ValueString())
-	if err != nil {
-		// If 404, remove from state
-		if err.Error() == "API error 404: Not Found" || (len(err.Error()) > 13 && err.Error()[0:13] == "API error 404") {
-			resp.State.RemoveResource(ctx)
-			return
+// resourceTags works out the resource-level tags from the tags returned by
+// the API, by dropping tags inherited from the provider defaults. A tag that
+// was already set on the resource is kept even if it matches a default.
+func resourceTags(apiTags, defaults, prior map[string]string) map[string]string {
+	tags := make(map[string]string, len(apiTags))
+	for k, v := range apiTags {
+		if _, ok := prior[k]; ok {
+			tags[k] = v
+			continue
 		}
-
-		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to read overlay, got error: %s", err))
-		return
+		if dv, ok := defaults[k]; ok && dv == v {
+			continue
+		}
+		tags[k] = v
 	}
+	return tags
+}
 
-	data.Name = types.StringValue(overlay.Name)
-	// Store null instead of empty string for description (to match config when unset)
-	if overlay.Description == "" {
-		data.Description = types.StringNull()
-	} else {
-		data.Description = types.StringValue(overlay.Description)
+// mergedTags merges the resource tags attribute over the provider defaults
+func (r *OverlayResource) mergedTags(ctx context.Context, v types.Map) (map[string]string, diag.Diagnostics) {
+	var tags map[string]string
+	diags := v.ElementsAs(ctx, &tags, false)
+	return mergeTags(r.defaultTags, tags), diags
+}
+
+// tagsAll returns the tags_all value for the resource tags attribute
+func (r *OverlayResource) tagsAll(ctx context.Context, v types.Map) (types.Map, diag.Diagnostics) {
+	tags, diags := r.mergedTags(ctx, v)
+	if diags.HasError() {
+		return types.MapNull(types.StringType), diags
 	}
-	data.OrganizationID = types.StringValue(overlay.OrganizationID)
-	data.CreatedBy = types.StringValue(overlay.CreatedBy)
-	data.CreatedAt = types.StringValue(overlay.CreatedAt)
-	data.UpdatedAt = types.StringValue(overlay.UpdatedAt)
+	tagsAll, d := tagsValue(ctx, tags)
+	diags.Append(d...)
+	return tagsAll, diags
+}
 
-	// Only update data if semantically different (API returns different key ordering)
-	if !jsonEqual(data.Data.ValueString(), string(overlay.Data)) {
-		data.Data = types.StringValue(string(overlay.Data))
+// tagsValue converts tags to a map value, null when there are none
+func tagsValue(ctx context.Context, tags map[string]string) (types.Map, diag.Diagnostics) {
+	if len(tags) == 0 {
+		return types.MapNull(types.StringType), nil
 	}
+	return types.MapValueFrom(ctx, types.StringType, tags)
+}
 
-	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+// descriptionValue converts an API description to state, storing null
+// instead of an empty string to match the config when unset
+func descriptionValue(description string) types.String {
+	if description == "" {
+		return types.StringNull()
+	}
+	return types.StringValue(description)
 }
 
 // stringEqualOrBothEmpty returns true if both values are equal, or both are "empty" (null or "")
@@ -251,20 +1406,191 @@ func stringEqualOrBothEmpty(a, b types.String) bool {
 	return a.Equal(b)
 }
 
+// decodeJSON decodes a JSON string, keeping numbers as json.Number so that
+// they are neither rounded nor reformatted
+func decodeJSON(s string) (interface{}, error) {
+	dec := json.NewDecoder(strings.NewReader(trimDataInput(s)))
+	dec.UseNumber()
+
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	if dec.More() {
+		return nil, fmt.Errorf("unexpected data after top-level value")
+	}
+	return v, nil
+}
+
+// canonicalJSON re-encodes a JSON string with sorted object keys and no
+// insignificant whitespace, so semantically equal documents encode the same.
+// Numbers are kept as written, e.g. 1e3 and 1.50, to avoid float rounding
+// and reformatting, and so are <, > and &, which often appear in SQL.
+func canonicalJSON(s string) ([]byte, error) {
+	v, err := decodeJSON(s)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// dataHash returns the hex SHA-256 of the canonicalized JSON string
+func dataHash(s string) (string, error) {
+	canonical, err := canonicalJSON(s)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(canonical)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// dataHashValue returns the data_hash attribute value for the data attribute.
+// Data that isn't valid JSON fails elsewhere, so it just has no hash.
+func dataHashValue(data types.String) types.String {
+	if data.IsNull() || data.IsUnknown() {
+		return types.StringNull()
+	}
+	hash, err := dataHash(data.ValueString())
+	if err != nil {
+		return types.StringNull()
+	}
+	return types.StringValue(hash)
+}
+
+// definitionSizeValue returns the definition_size_bytes attribute value for
+// the data attribute, the length of the data_hash input
+func definitionSizeValue(data types.String) types.Int64 {
+	if data.IsNull() || data.IsUnknown() {
+		return types.Int64Null()
+	}
+	canonical, err := canonicalJSON(data.ValueString())
+	if err != nil {
+		return types.Int64Null()
+	}
+	return types.Int64Value(int64(len(canonical)))
+}
+
+// cubeNamesValue returns the cube_names attribute value for the data
+// attribute. Cubes are either a list of objects with a name, in order, or an
+// object keyed by name, sorted.
+func cubeNamesValue(data types.String) types.List {
+	if data.IsNull() || data.IsUnknown() {
+		return types.ListNull(types.StringType)
+	}
+	canonical, err := canonicalJSON(data.ValueString())
+	if err != nil {
+		return types.ListNull(types.StringType)
+	}
+
+	var overlay struct {
+		Cubes json.RawMessage `json:"cubes"`
+	}
+	names := []attr.Value{}
+	if json.Unmarshal([]byte(canonical), &overlay) != nil {
+		return types.ListValueMust(types.StringType, names)
+	}
+
+	var list []struct {
+		Name string `json:"name"`
+	}
+	var keyed map[string]json.RawMessage
+	switch {
+	case json.Unmarshal(overlay.Cubes, &list) == nil:
+		for _, cube := range list {
+			if cube.Name != "" {
+				names = append(names, types.StringValue(cube.Name))
+			}
+		}
+	case json.Unmarshal(overlay.Cubes, &keyed) == nil:
+		keys := make([]string, 0, len(keyed))
+		for name := range keyed {
+			keys = append(keys, name)
+		}
+		sort.Strings(keys)
+		for _, name := range keys {
+			names = append(names, types.StringValue(name))
+		}
+	}
+	return types.ListValueMust(types.StringType, names)
+}
+
+// stringElements returns the known elements of a list of strings
+func stringElements(list types.List) []string {
+	var values []string
+	for _, element := range list.Elements() {
+		if s, ok := element.(types.String); ok && !s.IsNull() && !s.IsUnknown() {
+			values = append(values, s.ValueString())
+		}
+	}
+	return values
+}
+
 // jsonEqual compares two JSON strings for semantic equality (ignoring key order)
 func jsonEqual(a, b string) bool {
-	var objA, objB interface{}
-	if err := json.Unmarshal([]byte(a), &objA); err != nil {
+	objA, err := decodeJSON(a)
+	if err != nil {
 		return false
 	}
-	if err := json.Unmarshal([]byte(b), &objB); err != nil {
+	objB, err := decodeJSON(b)
+	if err != nil {
 		return false
 	}
 	return deepEqual(objA, objB)
 }
 
+// jsonContains reports whether JSON string a contains b, as deepContains
+func jsonContains(a, b string) bool {
+	objA, err := decodeJSON(a)
+	if err != nil {
+		return false
+	}
+	objB, err := decodeJSON(b)
+	if err != nil {
+		return false
+	}
+	return deepContains(objA, objB)
+}
+
+// jsonTopLevelDiff returns the sorted top-level keys that were added, removed
+// or changed between two JSON objects. It returns nil if either isn't one.
+func jsonTopLevelDiff(a, b string) []string {
+	decodedA, errA := decodeJSON(a)
+	decodedB, errB := decodeJSON(b)
+	objA, okA := decodedA.(map[string]interface{})
+	objB, okB := decodedB.(map[string]interface{})
+	if errA != nil || errB != nil || !okA || !okB {
+		return nil
+	}
+
+	var changed []string
+	for k, valA := range objA {
+		if valB, exists := objB[k]; !exists || !deepEqual(valA, valB) {
+			changed = append(changed, k)
+		}
+	}
+	for k := range objB {
+		if _, exists := objA[k]; !exists {
+			changed = append(changed, k)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
 // deepEqual recursively compares two values for equality
 func deepEqual(a, b interface{}) bool {
+	return deepEqualFold(a, b, false)
+}
+
+// deepEqualFold compares two values like deepEqual. With foldCase, strings
+// that only differ in case are equal; object keys are still compared exactly.
+func deepEqualFold(a, b interface{}, foldCase bool) bool {
 	switch va := a.(type) {
 	case map[string]interface{}:
 		vb, ok := b.(map[string]interface{})
@@ -273,7 +1599,7 @@ func deepEqual(a, b interface{}) bool {
 		}
 		for k, valA := range va {
 			valB, exists := vb[k]
-			if !exists || !deepEqual(valA, valB) {
+			if !exists || !deepEqualFold(valA, valB, foldCase) {
 				return false
 			}
 		}
@@ -284,27 +1610,148 @@ func deepEqual(a, b interface{}) bool {
 			return false
 		}
 		for i := range va {
-			if !deepEqual(va[i], vb[i]) {
+			if !deepEqualFold(va[i], vb[i], foldCase) {
 				return false
 			}
 		}
 		return true
+	case json.Number:
+		vb, ok := b.(json.Number)
+		return ok && numbersEqual(va, vb)
+	case string:
+		vb, ok := b.(string)
+		if foldCase {
+			return ok && strings.EqualFold(va, vb)
+		}
+		return ok && va == vb
 	default:
 		return a == b
 	}
 }
 
+// numberPrecision is the precision numbers are compared with, enough to tell
+// apart integers of up to 150 digits
+const numberPrecision = 512
+
+// numbersEqual reports whether two JSON numbers have the same value, however
+// they are written, e.g. 1e3 and 1000
+func numbersEqual(a, b json.Number) bool {
+	if a == b {
+		return true
+	}
+	fa, _, errA := big.ParseFloat(string(a), 10, numberPrecision, big.ToNearestEven)
+	fb, _, errB := big.ParseFloat(string(b), 10, numberPrecision, big.ToNearestEven)
+	if errA != nil || errB != nil {
+		return false
+	}
+	return fa.Cmp(fb) == 0
+}
+
+// deepContains reports whether a contains b: objects in a may have keys b
+// doesn't, but otherwise the values must be equal, like deepEqual
+func deepContains(a, b interface{}) bool {
+	switch vb := b.(type) {
+	case map[string]interface{}:
+		va, ok := a.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		for k, valB := range vb {
+			valA, exists := va[k]
+			if !exists || !deepContains(valA, valB) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		va, ok := a.([]interface{})
+		if !ok || len(va) != len(vb) {
+			return false
+		}
+		for i := range vb {
+			if !deepContains(va[i], vb[i]) {
+				return false
+			}
+		}
+		return true
+	default:
+		return deepEqual(a, b)
+	}
+}
+
 func (r *OverlayResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
-	var data OverlayResourceModel
+	var data, state OverlayResourceModel
 
 	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
 	if resp.Diagnostics.HasError() {
 		return
 	}
 
-	var rawData json.RawMessage
-	if err := json.Unmarshal([]byte(data.Data.ValueString()), &rawData); err != nil {
-		resp.Diagnostics.AddError("Invalid JSON in data", err.Error())
+	updateTimeout, diags := data.Timeouts.Update(ctx, 0)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	ctx, cancel := withTimeout(ctx, updateTimeout)
+	defer cancel()
+
+	// Nothing to send, e.g. when only timeouts, deletion_mode or
+	// deletion_protection changed or the data was only reformatted, so skip
+	// the write and carry state forward
+	if overlayUnchanged(data, state) {
+		tflog.Debug(ctx, "Overlay unchanged, skipping update", map[string]interface{}{"id": state.ID.ValueString()})
+		carryForwardComputed(&data, state)
+		exportUnchangedData(ctx, data, &resp.Diagnostics)
+		resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+		return
+	}
+
+	// The lock is set on its own. Unlock before writing, in case the API
+	// rejects any change to a locked overlay, and lock after.
+	lock := data.Locked.ValueBool() && !state.Locked.ValueBool()
+	if state.Locked.ValueBool() && !data.Locked.ValueBool() {
+		resp.Diagnostics.Append(r.setOverlayLock(ctx, &state, false)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+	if definitionUnchanged(data, state) {
+		carryForwardComputed(&data, state)
+		if lock {
+			data.Locked = state.Locked
+			resp.Diagnostics.Append(r.setOverlayLock(ctx, &data, true)...)
+		}
+		exportUnchangedData(ctx, data, &resp.Diagnostics)
+		resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+		return
+	}
+
+	rendered, diags := renderedData(ctx, data)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	rawData, err := parseOverlayData(rendered.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("data"), "Invalid JSON in data", err.Error())
+		return
+	}
+	if data.MergeStrategy.ValueString() == mergeStrategyMerge {
+		rawData = r.mergedData(ctx, data.ID.ValueString(), rawData, &resp.Diagnostics)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+	rawData = r.sizedData(rawData, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	tags, diags := r.mergedTags(ctx, data.Tags)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
 		return
 	}
 
@@ -312,26 +1759,132 @@ func (r *OverlayResource) Update(ctx context.Context, req resource.UpdateRequest
 		Name:        data.Name.ValueString(),
 		Description: data.Description.ValueString(),
 		Data:        rawData,
+		Tags:        tags,
+		Enabled:     data.Enabled.ValueBoolPointer(),
+		Type:        data.Type.ValueString(),
 	}
 
-	overlay, err := r.client.UpdateOverlay(data.ID.ValueString(), payload)
+	overlay, err := r.client.UpdateOverlay(ctx, data.ID.ValueString(), payload, overlayPrecondition(state))
 	if err != nil {
-		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to update overlay, got error: %s", err))
+		if client.IsPreconditionFailed(err) {
+			resp.Diagnostics.AddError("Overlay Modified Concurrently", staleStateDetail)
+			return
+		}
+		resp.Diagnostics.Append(writeErrorDiagnostics("update", err)...)
 		return
 	}
+	resp.Diagnostics.Append(unknownFieldsDiagnostics(overlay)...)
+	resp.Diagnostics.Append(apiWarningDiagnostics(overlay)...)
 
-	// Update computed fields from API response
-	data.OrganizationID = types.StringValue(overlay.OrganizationID)
-	data.CreatedBy = types.StringValue(overlay.CreatedBy)
-	data.CreatedAt = types.StringValue(overlay.CreatedAt)
+	// Update computed fields from API response. The immutable ones were
+	// planned from state.
 	data.UpdatedAt = types.StringValue(overlay.UpdatedAt)
+	data.Version = types.StringValue(string(overlay.Version))
+	data.TagsAll, diags = tagsValue(ctx, tags)
+	resp.Diagnostics.Append(diags...)
+	data.DataHash = dataHashValue(rendered)
+	data.DefinitionSize = definitionSizeValue(rendered)
+	data.CubeNames = cubeNamesValue(rendered)
+	if lock {
+		data.Locked = types.BoolValue(overlay.Locked)
+		resp.Diagnostics.Append(r.setOverlayLock(ctx, &data, true)...)
+	}
 
 	// Keep the planned data value - API returns same content but with different key ordering
 	// data.Data is already set from the plan, no need to update it
 
+	exportOverlayData(data.ExportPath, exportedData(overlay, rendered), &resp.Diagnostics)
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
 }
 
+// exportUnchangedData exports the data of an overlay an update didn't write,
+// e.g. when only export_path changed, or a read found unmodified. The data
+// in the plan or state then equals the API's.
+func exportUnchangedData(ctx context.Context, data OverlayResourceModel, diags *diag.Diagnostics) {
+	if data.ExportPath.IsNull() {
+		return
+	}
+	if rendered, d := renderedData(ctx, data); !d.HasError() {
+		exportOverlayData(data.ExportPath, rendered.ValueString(), diags)
+	}
+}
+
+// mergedData deep-merges rawData into the overlay's data on the server. The
+// update is still conditioned on the version in state, so data changed on
+// the server since the last refresh is not merged into silently.
+func (r *OverlayResource) mergedData(ctx context.Context, id string, rawData json.RawMessage, diags *diag.Diagnostics) json.RawMessage {
+	overlay, err := r.client.GetOverlay(ctx, id)
+	if err != nil {
+		diags.AddError("Client Error", fmt.Sprintf("Unable to read overlay to merge data into, got error: %s", err))
+		return nil
+	}
+
+	current, err := decodeJSON(migrateData(string(overlay.Data)))
+	if err != nil {
+		diags.AddAttributeError(path.Root("data"), "Invalid Server Data",
+			fmt.Sprintf("Unable to merge data into the overlay's data on the server, which isn't valid JSON: %s", err))
+		return nil
+	}
+	update, err := decodeJSON(string(rawData))
+	if err != nil {
+		diags.AddAttributeError(path.Root("data"), "Invalid JSON in data", err.Error())
+		return nil
+	}
+
+	merged, err := json.Marshal(deepMerge(current, update))
+	if err != nil {
+		diags.AddAttributeError(path.Root("data"), "Invalid JSON in data", err.Error())
+		return nil
+	}
+	return merged
+}
+
+// deepMerge returns src merged on top of dst: objects are merged key by key,
+// and anything else in src, including arrays and nulls, replaces dst
+func deepMerge(dst, src interface{}) interface{} {
+	srcObj, ok := src.(map[string]interface{})
+	if !ok {
+		return src
+	}
+	dstObj, ok := dst.(map[string]interface{})
+	if !ok {
+		return src
+	}
+
+	merged := make(map[string]interface{}, len(dstObj)+len(srcObj))
+	for k, v := range dstObj {
+		merged[k] = v
+	}
+	for k, v := range srcObj {
+		if existing, exists := merged[k]; exists {
+			merged[k] = deepMerge(existing, v)
+		} else {
+			merged[k] = v
+		}
+	}
+	return merged
+}
+
+// carryForwardComputed sets the computed attributes of a plan that didn't
+// require writing the overlay from state
+func carryForwardComputed(data *OverlayResourceModel, state OverlayResourceModel) {
+	data.ID = state.ID
+	data.OrganizationID = state.OrganizationID
+	data.CreatedBy = state.CreatedBy
+	data.CreatedAt = state.CreatedAt
+	data.UpdatedAt = state.UpdatedAt
+	data.Version = state.Version
+	if data.DataHash.IsUnknown() {
+		data.DataHash = state.DataHash
+	}
+	if data.DefinitionSize.IsUnknown() {
+		data.DefinitionSize = state.DefinitionSize
+	}
+	if data.CubeNames.IsUnknown() {
+		data.CubeNames = state.CubeNames
+	}
+}
+
 func (r *OverlayResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
 	var data OverlayResourceModel
 
@@ -340,10 +1893,36 @@ func (r *OverlayResource) Delete(ctx context.Context, req resource.DeleteRequest
 		return
 	}
 
-	err := r.client.DeleteOverlay(data.ID.ValueString())
+	if data.DeletionProtect.ValueBool() {
+		resp.Diagnostics.AddError(
+			"Deletion Protection Enabled",
+			fmt.Sprintf("Overlay %s has deletion_protection enabled, so it can't be destroyed or replaced. "+
+				"Set deletion_protection = false and apply that first to destroy it.", data.ID.ValueString()),
+		)
+		return
+	}
+
+	deleteTimeout, diags := data.Timeouts.Delete(ctx, 0)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	ctx, cancel := withTimeout(ctx, deleteTimeout)
+	defer cancel()
+
+	var err error
+	if data.DeletionMode.ValueString() == deletionModeArchive {
+		err = r.client.ArchiveOverlay(ctx, data.ID.ValueString(), overlayPrecondition(data))
+	} else {
+		err = r.client.DeleteOverlay(ctx, data.ID.ValueString(), overlayPrecondition(data))
+	}
 	if err != nil {
 		// If 404, treat as success?
-		if len(err.Error()) > 13 && err.Error()[0:13] == "API error 404" {
+		if client.IsNotFound(err) {
+			return
+		}
+		if client.IsPreconditionFailed(err) {
+			resp.Diagnostics.AddError("Overlay Modified Concurrently", staleStateDetail)
 			return
 		}
 		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to delete overlay, got error: %s", err))
@@ -351,33 +1930,83 @@ func (r *OverlayResource) Delete(ctx context.Context, req resource.DeleteRequest
 	}
 }
 
+// splitImportID splits a composite <organization_id>/<name> import ID.
+// Overlay names can't contain "/", so IDs and plain names never match.
+func splitImportID(id string) (organizationID, name string, ok bool) {
+	i := strings.LastIndex(id, "/")
+	if i <= 0 || i == len(id)-1 {
+		return "", "", false
+	}
+	return id[:i], id[i+1:], true
+}
+
 func (r *OverlayResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
 	id := req.ID
 
-	// Try to get overlay by ID first
-	overlay, err := r.client.GetOverlay(id)
+	var overlay *client.CubeOverlay
+	var err error
+	if organizationID, name, ok := splitImportID(id); ok {
+		overlay, err = r.client.GetOverlayByOrganizationAndName(ctx, organizationID, name)
+	} else {
+		// Try to get overlay by ID first, then by name if there is no such ID.
+		// Failing both, the ID may be of an overlay created a moment ago that
+		// the API doesn't serve yet.
+		overlay, err = r.client.GetOverlay(ctx, id)
+		if client.IsNotFound(err) {
+			overlay, err = r.client.GetOverlayByName(ctx, id)
+		}
+		if client.IsNotFound(err) {
+			overlay, err = r.client.GetCreatedOverlay(ctx, id)
+		}
+	}
 	if err != nil {
-		// If failed, try to get by name
-		overlay, err = r.client.GetOverlayByName(id)
-		if err != nil {
+		if client.IsNotFound(err) {
 			resp.Diagnostics.AddError(
-				"Import Error",
-				fmt.Sprintf("Unable to import overlay. Tried as ID and name, got error: %s", err),
+				"Overlay Not Found",
+				fmt.Sprintf("Unable to import overlay: no overlay matches %q. "+
+					"The import ID must be an overlay ID, a name, or an organization ID and name as <organization_id>/<name>.", id),
 			)
 			return
 		}
+		resp.Diagnostics.AddError(
+			"Import Error",
+			fmt.Sprintf("Unable to import overlay %q, got error: %s", id, err),
+		)
+		return
 	}
 
 	// Set all state attributes from the fetched overlay
 	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("id"), overlay.ID)...)
 	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("name"), overlay.Name)...)
-	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("description"), overlay.Description)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("description"), descriptionValue(overlay.Description))...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("enabled"), overlay.IsEnabled())...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("type"), overlay.OverlayType())...)
 	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("organization_id"), overlay.OrganizationID)...)
 	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("created_by"), overlay.CreatedBy)...)
 	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("created_at"), overlay.CreatedAt)...)
 	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("updated_at"), overlay.UpdatedAt)...)
+	// version is left to the read that follows every import, so that it
+	// reads the whole overlay rather than asking if it was modified
 
-	// Normalize JSON data
-	dataBytes, _ := json.Marshal(overlay.Data)
+	tags, diags := tagsValue(ctx, resourceTags(overlay.Tags, r.defaultTags, nil))
+	resp.Diagnostics.Append(diags...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("tags"), tags)...)
+	tagsAll, diags := tagsValue(ctx, overlay.Tags)
+	resp.Diagnostics.Append(diags...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("tags_all"), tagsAll)...)
+
+	// Keep the data exactly as the API returned it, rather than re-encoding
+	// it, so that state matches the server representation
+	dataBytes := []byte(overlay.Data)
+	if len(dataBytes) == 0 {
+		dataBytes = []byte("null")
+	}
 	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("data"), string(dataBytes))...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("data_format"), dataFormatJSON)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("deletion_mode"), deletionModeDelete)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("deletion_protection"), false)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("merge_strategy"), mergeStrategyReplace)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("data_hash"), dataHashValue(types.StringValue(string(dataBytes))))...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("definition_size_bytes"), definitionSizeValue(types.StringValue(string(dataBytes))))...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("cube_names"), cubeNamesValue(types.StringValue(string(dataBytes))))...)
 }
@@ -0,0 +1,124 @@
+package provider
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// TestOverlayResource_KeyReorderingDrift is an end-to-end regression test
+// for perpetual diffs: the API returns overlay data with its keys in a
+// different order than submitted, which must never show up as a change.
+func TestOverlayResource_KeyReorderingDrift(t *testing.T) {
+	tests := []struct {
+		name   string
+		config map[string]interface{}
+	}{
+		{
+			name: "flat object",
+			config: map[string]interface{}{
+				"data": `{"a":1,"b":"two","c":true}`,
+			},
+		},
+		{
+			name: "nested cubes",
+			config: map[string]interface{}{
+				"data": `{"cubes":[{"name":"orders","sql_table":"orders","measures":[{"name":"count","type":"count"}],"dimensions":[{"name":"id","sql":"id","type":"number","primary_key":true}]}],"views":[]}`,
+			},
+		},
+		{
+			name: "pretty printed",
+			config: map[string]interface{}{
+				"data": "{\n  \"alpha\": {\n    \"x\": 1,\n    \"y\": [1, 2, 3]\n  },\n  \"beta\": null\n}",
+			},
+		},
+		{
+			name: "with description, tags and data_vars",
+			config: map[string]interface{}{
+				"description": "reordered",
+				"data":        `{"schema":"${schema}","cubes":[{"name":"orders","sql_table":"${schema}.orders"}]}`,
+				"data_vars":   map[string]interface{}{"schema": "analytics"},
+				"tags":        map[string]interface{}{"team": "data", "env": "test"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newMockRevosServer(t)
+			h := newMockHarness(t, m)
+
+			config := map[string]interface{}{"name": "reordered"}
+			for k, v := range tt.config {
+				config[k] = v
+			}
+
+			null := tftypes.NewValue(h.resourceSchema("revos_overlay").ValueType(), nil)
+			state, diags := h.apply("revos_overlay", null, config)
+			requireNoErrors(t, "create", diags)
+
+			// Make sure the scenario is real: the API doesn't echo the data
+			// back byte for byte
+			if raw := rawOverlay(t, m, attrString(t, state, "id")); strings.Contains(raw, compactJSON(t, attrString(t, state, "data"))) {
+				t.Fatalf("mock API returned the data in its submitted order: %s", raw)
+			}
+
+			for i := 0; i < 2; i++ {
+				state, diags = h.read("revos_overlay", state)
+				requireNoErrors(t, "refresh", diags)
+
+				planned, diags := h.plan("revos_overlay", state, config)
+				requireNoErrors(t, "plan", diags)
+				if !planned.Equal(state) {
+					t.Fatalf("refresh %d: expected an empty plan, got %s", i+1, planned)
+				}
+			}
+
+			// The configured text is kept, not the API's ordering
+			if got := attrString(t, state, "data"); got != config["data"] {
+				t.Errorf("data = %s, want the configured text", got)
+			}
+			if got := m.requestCount("PATCH", "/cube-overlays/"+attrString(t, state, "id")); got != 0 {
+				t.Errorf("PATCH called %d times, want 0", got)
+			}
+		})
+	}
+}
+
+// rawOverlay fetches an overlay from the mock API without decoding it.
+func rawOverlay(t *testing.T, m *mockRevosServer, id string) string {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodGet, m.URL+"/cube-overlays/"+id, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer test-token")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(body)
+}
+
+// compactJSON renders data the way it is submitted to the API, after
+// substituting any data_vars used by the tests.
+func compactJSON(t *testing.T, data string) string {
+	t.Helper()
+
+	rendered := strings.ReplaceAll(data, "${schema}", "analytics")
+	canonical, err := canonicalJSON(rendered)
+	if err != nil {
+		t.Fatalf("canonicalJSON: %s", err)
+	}
+	return string(canonical)
+}
@@ -0,0 +1,181 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/revosai/terraform-provider-revos/internal/client"
+)
+
+// Ensure implementation satisfies interfaces.
+var _ resource.Resource = &OverlayBindingResource{}
+var _ resource.ResourceWithImportState = &OverlayBindingResource{}
+
+func NewOverlayBindingResource() resource.Resource {
+	return &OverlayBindingResource{}
+}
+
+type OverlayBindingResource struct {
+	client *client.Client
+}
+
+type OverlayBindingResourceModel struct {
+	ID           types.String `tfsdk:"id"`
+	OverlayID    types.String `tfsdk:"overlay_id"`
+	DataSourceID types.String `tfsdk:"data_source_id"`
+	CreatedAt    types.String `tfsdk:"created_at"`
+}
+
+func (r *OverlayBindingResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_overlay_binding"
+}
+
+func (r *OverlayBindingResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Binds a Revos Cube Overlay to the data source (database connection) it is evaluated against.",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed:      true,
+				Description:   "The ID of the binding.",
+				PlanModifiers: []planmodifier.String{stringplanmodifier.UseStateForUnknown()},
+			},
+			"overlay_id": schema.StringAttribute{
+				Required:      true,
+				Description:   "The ID of the overlay to bind.",
+				PlanModifiers: []planmodifier.String{stringplanmodifier.RequiresReplace()},
+			},
+			"data_source_id": schema.StringAttribute{
+				Required:    true,
+				Description: "The ID of the data source the overlay uses.",
+			},
+			"created_at": schema.StringAttribute{
+				Computed:      true,
+				PlanModifiers: []planmodifier.String{stringplanmodifier.UseStateForUnknown()},
+			},
+		},
+	}
+}
+
+func (r *OverlayBindingResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	providerData, ok := req.ProviderData.(*RevosProviderData)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Resource Configure Type",
+			fmt.Sprintf("Expected *RevosProviderData, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+		return
+	}
+
+	r.client = providerData.Client
+}
+
+func (r *OverlayBindingResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var data OverlayBindingResourceModel
+
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	binding, err := r.client.CreateOverlayBinding(ctx, data.OverlayID.ValueString(), client.OverlayBindingPayload{
+		DataSourceID: data.DataSourceID.ValueString(),
+	})
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to create overlay binding, got error: %s", err))
+		return
+	}
+
+	data.ID = types.StringValue(binding.ID)
+	data.CreatedAt = types.StringValue(binding.CreatedAt)
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *OverlayBindingResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var data OverlayBindingResourceModel
+
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	binding, err := r.client.GetOverlayBinding(ctx, data.OverlayID.ValueString(), data.ID.ValueString())
+	if err != nil {
+		// The binding, or the overlay itself, is gone
+		if client.IsNotFound(err) {
+			resp.State.RemoveResource(ctx)
+			return
+		}
+
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to read overlay binding, got error: %s", err))
+		return
+	}
+
+	data.DataSourceID = types.StringValue(binding.DataSourceID)
+	data.CreatedAt = types.StringValue(binding.CreatedAt)
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *OverlayBindingResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var data OverlayBindingResourceModel
+
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Switching data sources keeps the binding; moving it to another overlay
+	// forces replacement
+	binding, err := r.client.UpdateOverlayBinding(ctx, data.OverlayID.ValueString(), data.ID.ValueString(), client.OverlayBindingPayload{
+		DataSourceID: data.DataSourceID.ValueString(),
+	})
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to update overlay binding, got error: %s", err))
+		return
+	}
+
+	data.CreatedAt = types.StringValue(binding.CreatedAt)
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *OverlayBindingResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var data OverlayBindingResourceModel
+
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	err := r.client.DeleteOverlayBinding(ctx, data.OverlayID.ValueString(), data.ID.ValueString())
+	if err != nil && !client.IsNotFound(err) {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to delete overlay binding, got error: %s", err))
+	}
+}
+
+// ImportState accepts a composite "<overlay_id>/<binding_id>" ID
+func (r *OverlayBindingResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	overlayID, bindingID, ok := strings.Cut(req.ID, "/")
+	if !ok || overlayID == "" || bindingID == "" {
+		resp.Diagnostics.AddError(
+			"Invalid Import ID",
+			fmt.Sprintf("Expected an import ID of the form <overlay_id>/<binding_id>, got %q", req.ID),
+		)
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("overlay_id"), overlayID)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("id"), bindingID)...)
+}
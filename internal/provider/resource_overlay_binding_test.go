@@ -0,0 +1,102 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+func TestOverlayBindingResource_Lifecycle(t *testing.T) {
+	m := newMockRevosServer(t)
+	h := newMockHarness(t, m)
+
+	overlayNull := tftypes.NewValue(h.resourceSchema("revos_overlay").ValueType(), nil)
+	overlay, diags := h.apply("revos_overlay", overlayNull, map[string]interface{}{
+		"name": "bound",
+		"data": `{}`,
+	})
+	requireNoErrors(t, "create overlay", diags)
+	overlayID := attrString(t, overlay, "id")
+
+	const typeName = "revos_overlay_binding"
+	null := tftypes.NewValue(h.resourceSchema(typeName).ValueType(), nil)
+	config := map[string]interface{}{
+		"overlay_id":     overlayID,
+		"data_source_id": "ds-warehouse",
+	}
+
+	// Create
+	state, diags := h.apply(typeName, null, config)
+	requireNoErrors(t, "create", diags)
+	bindingID := attrString(t, state, "id")
+	if got := m.binding(overlayID, bindingID); got == nil || got["dataSourceId"] != "ds-warehouse" {
+		t.Fatalf("expected binding to ds-warehouse on the server, got %v", got)
+	}
+
+	// Refresh and plan are no-ops
+	state, diags = h.read(typeName, state)
+	requireNoErrors(t, "read", diags)
+	planned, diags := h.plan(typeName, state, config)
+	requireNoErrors(t, "plan", diags)
+	if !planned.Equal(state) {
+		t.Errorf("plan: expected no changes, got %s", planned)
+	}
+
+	// Changing the data source updates in place
+	config["data_source_id"] = "ds-replica"
+	state, diags = h.apply(typeName, state, config)
+	requireNoErrors(t, "update", diags)
+	if got := attrString(t, state, "id"); got != bindingID {
+		t.Errorf("update: id changed from %q to %q", bindingID, got)
+	}
+	if got := m.binding(overlayID, bindingID)["dataSourceId"]; got != "ds-replica" {
+		t.Errorf("update: server data source = %v, want %q", got, "ds-replica")
+	}
+
+	// Changes made outside Terraform show up on refresh
+	m.mu.Lock()
+	m.bindings[overlayID][bindingID]["dataSourceId"] = "ds-manual"
+	m.mu.Unlock()
+	state, diags = h.read(typeName, state)
+	requireNoErrors(t, "read after drift", diags)
+	if got := attrString(t, state, "data_source_id"); got != "ds-manual" {
+		t.Errorf("read after drift: data_source_id = %q, want %q", got, "ds-manual")
+	}
+	state, diags = h.apply(typeName, state, config)
+	requireNoErrors(t, "apply after drift", diags)
+	if got := m.binding(overlayID, bindingID)["dataSourceId"]; got != "ds-replica" {
+		t.Errorf("apply after drift: server data source = %v, want %q", got, "ds-replica")
+	}
+
+	// Moving the binding to another overlay replaces it
+	_, replace, diags := h.planChange(typeName, state, map[string]interface{}{
+		"overlay_id":     "other-overlay",
+		"data_source_id": "ds-replica",
+	})
+	requireNoErrors(t, "plan overlay change", diags)
+	if len(replace) != 1 || !replace[0].Equal(tftypes.NewAttributePath().WithAttributeName("overlay_id")) {
+		t.Errorf("plan overlay change: expected replacement on overlay_id, got %v", replace)
+	}
+
+	// Import by composite ID
+	imported, diags := h.importState(typeName, overlayID+"/"+bindingID)
+	requireNoErrors(t, "import", diags)
+	if !imported.Equal(state) {
+		t.Errorf("import: state mismatch\n got: %s\nwant: %s", imported, state)
+	}
+
+	_, diags = h.importState(typeName, bindingID)
+	requireError(t, diags, "Invalid Import ID")
+
+	// Destroy
+	requireNoErrors(t, "destroy", h.destroy(typeName, state))
+	if got := m.binding(overlayID, bindingID); got != nil {
+		t.Errorf("expected binding to be deleted, got %v", got)
+	}
+
+	state, diags = h.read(typeName, state)
+	requireNoErrors(t, "read after destroy", diags)
+	if !state.IsNull() {
+		t.Errorf("read after destroy: expected resource to be removed, got %s", state)
+	}
+}
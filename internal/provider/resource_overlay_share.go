@@ -0,0 +1,188 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/revosai/terraform-provider-revos/internal/client"
+)
+
+// Ensure implementation satisfies interfaces.
+var _ resource.Resource = &OverlayShareResource{}
+var _ resource.ResourceWithImportState = &OverlayShareResource{}
+
+func NewOverlayShareResource() resource.Resource {
+	return &OverlayShareResource{}
+}
+
+type OverlayShareResource struct {
+	client *client.Client
+}
+
+type OverlayShareResourceModel struct {
+	ID          types.String `tfsdk:"id"`
+	OverlayID   types.String `tfsdk:"overlay_id"`
+	PrincipalID types.String `tfsdk:"principal_id"`
+	Role        types.String `tfsdk:"role"`
+	CreatedAt   types.String `tfsdk:"created_at"`
+}
+
+func (r *OverlayShareResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_overlay_share"
+}
+
+func (r *OverlayShareResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Shares a Revos Cube Overlay with a user or team.",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed:      true,
+				Description:   "The ID of the share.",
+				PlanModifiers: []planmodifier.String{stringplanmodifier.UseStateForUnknown()},
+			},
+			"overlay_id": schema.StringAttribute{
+				Required:      true,
+				Description:   "The ID of the overlay to share.",
+				PlanModifiers: []planmodifier.String{stringplanmodifier.RequiresReplace()},
+			},
+			"principal_id": schema.StringAttribute{
+				Required:      true,
+				Description:   "The ID of the user or team the overlay is shared with.",
+				PlanModifiers: []planmodifier.String{stringplanmodifier.RequiresReplace()},
+			},
+			"role": schema.StringAttribute{
+				Required:    true,
+				Description: "The role granted to the principal, e.g. \"viewer\" or \"editor\".",
+			},
+			"created_at": schema.StringAttribute{
+				Computed:      true,
+				PlanModifiers: []planmodifier.String{stringplanmodifier.UseStateForUnknown()},
+			},
+		},
+	}
+}
+
+func (r *OverlayShareResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	providerData, ok := req.ProviderData.(*RevosProviderData)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Resource Configure Type",
+			fmt.Sprintf("Expected *RevosProviderData, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+		return
+	}
+
+	r.client = providerData.Client
+}
+
+func (r *OverlayShareResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var data OverlayShareResourceModel
+
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	share, err := r.client.CreateOverlayShare(ctx, data.OverlayID.ValueString(), client.OverlaySharePayload{
+		PrincipalID: data.PrincipalID.ValueString(),
+		Role:        data.Role.ValueString(),
+	})
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to create overlay share, got error: %s", err))
+		return
+	}
+
+	data.ID = types.StringValue(share.ID)
+	data.CreatedAt = types.StringValue(share.CreatedAt)
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *OverlayShareResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var data OverlayShareResourceModel
+
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	share, err := r.client.GetOverlayShare(ctx, data.OverlayID.ValueString(), data.ID.ValueString())
+	if err != nil {
+		// The share, or the overlay itself, is gone
+		if client.IsNotFound(err) {
+			resp.State.RemoveResource(ctx)
+			return
+		}
+
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to read overlay share, got error: %s", err))
+		return
+	}
+
+	data.PrincipalID = types.StringValue(share.PrincipalID)
+	data.Role = types.StringValue(share.Role)
+	data.CreatedAt = types.StringValue(share.CreatedAt)
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *OverlayShareResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var data OverlayShareResourceModel
+
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Only the role can change in place; other changes force replacement
+	share, err := r.client.UpdateOverlayShare(ctx, data.OverlayID.ValueString(), data.ID.ValueString(), client.OverlaySharePayload{
+		Role: data.Role.ValueString(),
+	})
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to update overlay share, got error: %s", err))
+		return
+	}
+
+	data.CreatedAt = types.StringValue(share.CreatedAt)
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *OverlayShareResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var data OverlayShareResourceModel
+
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	err := r.client.DeleteOverlayShare(ctx, data.OverlayID.ValueString(), data.ID.ValueString())
+	if err != nil && !client.IsNotFound(err) {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to delete overlay share, got error: %s", err))
+	}
+}
+
+// ImportState accepts a composite "<overlay_id>/<share_id>" ID
+func (r *OverlayShareResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	overlayID, shareID, ok := strings.Cut(req.ID, "/")
+	if !ok || overlayID == "" || shareID == "" {
+		resp.Diagnostics.AddError(
+			"Invalid Import ID",
+			fmt.Sprintf("Expected an import ID of the form <overlay_id>/<share_id>, got %q", req.ID),
+		)
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("overlay_id"), overlayID)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("id"), shareID)...)
+}
@@ -0,0 +1,78 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+func TestOverlayShareResource_Lifecycle(t *testing.T) {
+	m := newMockRevosServer(t)
+	h := newMockHarness(t, m)
+
+	overlayNull := tftypes.NewValue(h.resourceSchema("revos_overlay").ValueType(), nil)
+	overlay, diags := h.apply("revos_overlay", overlayNull, map[string]interface{}{
+		"name": "shared",
+		"data": `{}`,
+	})
+	requireNoErrors(t, "create overlay", diags)
+	overlayID := attrString(t, overlay, "id")
+
+	const typeName = "revos_overlay_share"
+	null := tftypes.NewValue(h.resourceSchema(typeName).ValueType(), nil)
+	config := map[string]interface{}{
+		"overlay_id":   overlayID,
+		"principal_id": "team-analytics",
+		"role":         "viewer",
+	}
+
+	// Create
+	state, diags := h.apply(typeName, null, config)
+	requireNoErrors(t, "create", diags)
+	shareID := attrString(t, state, "id")
+	if got := m.shareCount(overlayID); got != 1 {
+		t.Fatalf("expected 1 share on the server, got %d", got)
+	}
+
+	// Refresh and plan are no-ops
+	state, diags = h.read(typeName, state)
+	requireNoErrors(t, "read", diags)
+	planned, diags := h.plan(typeName, state, config)
+	requireNoErrors(t, "plan", diags)
+	if !planned.Equal(state) {
+		t.Errorf("plan: expected no changes, got %s", planned)
+	}
+
+	// Changing the role updates in place
+	config["role"] = "editor"
+	state, diags = h.apply(typeName, state, config)
+	requireNoErrors(t, "update", diags)
+	if got := attrString(t, state, "id"); got != shareID {
+		t.Errorf("update: id changed from %q to %q", shareID, got)
+	}
+	if got := attrString(t, state, "role"); got != "editor" {
+		t.Errorf("update: role = %q, want %q", got, "editor")
+	}
+
+	// Import by composite ID
+	imported, diags := h.importState(typeName, overlayID+"/"+shareID)
+	requireNoErrors(t, "import", diags)
+	if !imported.Equal(state) {
+		t.Errorf("import: state mismatch\n got: %s\nwant: %s", imported, state)
+	}
+
+	_, diags = h.importState(typeName, shareID)
+	requireError(t, diags, "Invalid Import ID")
+
+	// Destroy
+	requireNoErrors(t, "destroy", h.destroy(typeName, state))
+	if got := m.shareCount(overlayID); got != 0 {
+		t.Errorf("expected share to be deleted, %d remain", got)
+	}
+
+	state, diags = h.read(typeName, state)
+	requireNoErrors(t, "read after destroy", diags)
+	if !state.IsNull() {
+		t.Errorf("read after destroy: expected resource to be removed, got %s", state)
+	}
+}
@@ -0,0 +1,249 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/revosai/terraform-provider-revos/internal/client"
+)
+
+// Ensure implementation satisfies interfaces.
+var _ resource.Resource = &OverlayTemplateResource{}
+var _ resource.ResourceWithImportState = &OverlayTemplateResource{}
+var _ resource.ResourceWithModifyPlan = &OverlayTemplateResource{}
+
+func NewOverlayTemplateResource() resource.Resource {
+	return &OverlayTemplateResource{}
+}
+
+// OverlayTemplateResource manages a parameterized overlay definition. Its
+// data may contain ${key} placeholders; overlays render it by passing it as
+// their data along with data_vars.
+type OverlayTemplateResource struct {
+	client *client.Client
+}
+
+type OverlayTemplateResourceModel struct {
+	ID           types.String   `tfsdk:"id"`
+	Name         types.String   `tfsdk:"name"`
+	Description  types.String   `tfsdk:"description"`
+	Data         NormalizedJSON `tfsdk:"data"`
+	Placeholders types.List     `tfsdk:"placeholders"`
+	CreatedAt    types.String   `tfsdk:"created_at"`
+	UpdatedAt    types.String   `tfsdk:"updated_at"`
+}
+
+func (r *OverlayTemplateResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_overlay_template"
+}
+
+func (r *OverlayTemplateResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Manages a Revos Cube Overlay template, a definition with ${key} placeholders that isn't a valid Cube by itself. " +
+			"Render it into an overlay by setting the overlay's data to the template's data and data_vars to the values.",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed:      true,
+				Description:   "The ID of the template.",
+				PlanModifiers: []planmodifier.String{stringplanmodifier.UseStateForUnknown()},
+			},
+			"name": schema.StringAttribute{
+				Required:    true,
+				Description: "The name of the template.",
+			},
+			"description": schema.StringAttribute{
+				Optional:    true,
+				Description: "The description of the template.",
+			},
+			"data": schema.StringAttribute{
+				CustomType:  NormalizedJSONType{},
+				Required:    true,
+				Description: "The JSON string representation of the Cube definition, with ${key} placeholders inside JSON strings. It isn't validated as a Cube definition.",
+			},
+			"placeholders": schema.ListAttribute{
+				Computed:    true,
+				ElementType: types.StringType,
+				Description: "The sorted names of the placeholders in data.",
+			},
+			"created_at": schema.StringAttribute{
+				Computed:      true,
+				PlanModifiers: []planmodifier.String{stringplanmodifier.UseStateForUnknown()},
+			},
+			"updated_at": schema.StringAttribute{
+				Computed: true,
+			},
+		},
+	}
+}
+
+func (r *OverlayTemplateResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	providerData, ok := req.ProviderData.(*RevosProviderData)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Resource Configure Type",
+			fmt.Sprintf("Expected *RevosProviderData, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+		return
+	}
+
+	r.client = providerData.Client
+}
+
+// ModifyPlan plans the placeholders from the planned data, so overlays using
+// them see the new list before apply
+func (r *OverlayTemplateResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	if req.Plan.Raw.IsNull() {
+		return
+	}
+
+	var data NormalizedJSON
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("data"), &data)...)
+	if resp.Diagnostics.HasError() || data.IsUnknown() {
+		return
+	}
+
+	placeholders, diags := placeholdersValue(ctx, data.ValueString())
+	resp.Diagnostics.Append(diags...)
+	resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("placeholders"), placeholders)...)
+}
+
+// placeholdersValue lists the placeholders in template data, sorted as the
+// API may reorder the data
+func placeholdersValue(ctx context.Context, data string) (types.List, diag.Diagnostics) {
+	names := append([]string{}, templatePlaceholders(data)...)
+	sort.Strings(names)
+	return types.ListValueFrom(ctx, types.StringType, names)
+}
+
+// setOverlayTemplate copies an API template into the model. The data is
+// replaced even if only reformatted; semantic equality keeps the prior value.
+func setOverlayTemplate(ctx context.Context, data *OverlayTemplateResourceModel, template *client.OverlayTemplate) diag.Diagnostics {
+	data.ID = types.StringValue(template.ID)
+	data.Name = types.StringValue(template.Name)
+	data.Description = descriptionValue(template.Description)
+	data.Data = NewNormalizedJSONValue(string(template.Data))
+	data.CreatedAt = types.StringValue(template.CreatedAt)
+	data.UpdatedAt = types.StringValue(template.UpdatedAt)
+
+	placeholders, diags := placeholdersValue(ctx, string(template.Data))
+	data.Placeholders = placeholders
+	return diags
+}
+
+// templatePayload builds the API payload from the planned template
+func templatePayload(data OverlayTemplateResourceModel) (client.OverlayTemplatePayload, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	rawData, err := parseOverlayData(data.Data.ValueString())
+	if err != nil {
+		diags.AddAttributeError(path.Root("data"), "Invalid JSON in data", err.Error())
+		return client.OverlayTemplatePayload{}, diags
+	}
+
+	return client.OverlayTemplatePayload{
+		Name:        data.Name.ValueString(),
+		Description: data.Description.ValueString(),
+		Data:        rawData,
+	}, diags
+}
+
+func (r *OverlayTemplateResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var data OverlayTemplateResourceModel
+
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	payload, diags := templatePayload(data)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	template, err := r.client.CreateOverlayTemplate(ctx, payload)
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to create overlay template, got error: %s", err))
+		return
+	}
+
+	resp.Diagnostics.Append(setOverlayTemplate(ctx, &data, template)...)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *OverlayTemplateResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var data OverlayTemplateResourceModel
+
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	template, err := r.client.GetOverlayTemplate(ctx, data.ID.ValueString())
+	if err != nil {
+		if client.IsNotFound(err) {
+			resp.State.RemoveResource(ctx)
+			return
+		}
+
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to read overlay template, got error: %s", err))
+		return
+	}
+
+	resp.Diagnostics.Append(setOverlayTemplate(ctx, &data, template)...)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *OverlayTemplateResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var data OverlayTemplateResourceModel
+
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	payload, diags := templatePayload(data)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	template, err := r.client.UpdateOverlayTemplate(ctx, data.ID.ValueString(), payload)
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to update overlay template, got error: %s", err))
+		return
+	}
+
+	resp.Diagnostics.Append(setOverlayTemplate(ctx, &data, template)...)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *OverlayTemplateResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var data OverlayTemplateResourceModel
+
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	err := r.client.DeleteOverlayTemplate(ctx, data.ID.ValueString())
+	if err != nil && !client.IsNotFound(err) {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to delete overlay template, got error: %s", err))
+	}
+}
+
+func (r *OverlayTemplateResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
+}
@@ -0,0 +1,105 @@
+package provider
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+func TestOverlayTemplateResource_Lifecycle(t *testing.T) {
+	m := newMockRevosServer(t)
+	h := newMockHarness(t, m)
+
+	const typeName = "revos_overlay_template"
+	null := tftypes.NewValue(h.resourceSchema(typeName).ValueType(), nil)
+	config := map[string]interface{}{
+		"name": "regional-sales",
+		"data": `{"cubes":[{"name":"sales_${region}","sql_table":"${schema}.sales"}]}`,
+	}
+
+	// Create
+	planned, diags := h.plan(typeName, null, config)
+	requireNoErrors(t, "plan create", diags)
+	if got := attrList(t, planned, "placeholders"); fmt.Sprint(got) != "[region schema]" {
+		t.Errorf("planned placeholders = %v, want [region schema]", got)
+	}
+
+	state, diags := h.apply(typeName, null, config)
+	requireNoErrors(t, "create", diags)
+	templateID := attrString(t, state, "id")
+	if got := m.templateCount(); got != 1 {
+		t.Fatalf("expected 1 template on the server, got %d", got)
+	}
+
+	// Refresh and plan are no-ops, although the API reorders the data
+	state, diags = h.read(typeName, state)
+	requireNoErrors(t, "read", diags)
+	if got := attrString(t, state, "data"); got != config["data"] {
+		t.Errorf("read: data = %s, want the configured %s", got, config["data"])
+	}
+	planned, diags = h.plan(typeName, state, config)
+	requireNoErrors(t, "plan", diags)
+	if !planned.Equal(state) {
+		t.Errorf("plan: expected no changes, got %s", planned)
+	}
+
+	// A template renders into an overlay through data_vars
+	overlayNull := tftypes.NewValue(h.resourceSchema("revos_overlay").ValueType(), nil)
+	overlay, diags := h.apply("revos_overlay", overlayNull, map[string]interface{}{
+		"name":      "sales-emea",
+		"data":      attrString(t, state, "data"),
+		"data_vars": map[string]interface{}{"region": "emea", "schema": "prod"},
+	})
+	requireNoErrors(t, "create overlay", diags)
+	cubes := m.overlayField(attrString(t, overlay, "id"), "data").(map[string]interface{})["cubes"].([]interface{})
+	if got := cubes[0].(map[string]interface{})["sql_table"]; got != "prod.sales" {
+		t.Errorf("rendered sql_table = %v, want prod.sales", got)
+	}
+
+	// Changing the data updates in place
+	config["data"] = `{"cubes":[{"name":"sales_${region}","sql_table":"sales"}]}`
+	state, diags = h.apply(typeName, state, config)
+	requireNoErrors(t, "update", diags)
+	if got := attrString(t, state, "id"); got != templateID {
+		t.Errorf("update: id changed from %q to %q", templateID, got)
+	}
+	if got := attrList(t, state, "placeholders"); fmt.Sprint(got) != "[region]" {
+		t.Errorf("update: placeholders = %v, want [region]", got)
+	}
+
+	// Import by ID
+	imported, diags := h.importState(typeName, templateID)
+	requireNoErrors(t, "import", diags)
+	if got := attrString(t, imported, "name"); got != "regional-sales" {
+		t.Errorf("import: name = %q, want regional-sales", got)
+	}
+
+	// Destroy
+	requireNoErrors(t, "destroy", h.destroy(typeName, state))
+	if got := m.templateCount(); got != 0 {
+		t.Errorf("expected template to be deleted, %d remain", got)
+	}
+
+	state, diags = h.read(typeName, state)
+	requireNoErrors(t, "read after destroy", diags)
+	if !state.IsNull() {
+		t.Errorf("read after destroy: expected resource to be removed, got %s", state)
+	}
+}
+
+func TestOverlayTemplateResource_InvalidData(t *testing.T) {
+	m := newMockRevosServer(t)
+	h := newMockHarness(t, m)
+
+	const typeName = "revos_overlay_template"
+	null := tftypes.NewValue(h.resourceSchema(typeName).ValueType(), nil)
+	_, diags := h.apply(typeName, null, map[string]interface{}{
+		"name": "broken",
+		"data": `["${region}"]`,
+	})
+	requireError(t, diags, "Invalid JSON in data")
+	if got := m.templateCount(); got != 0 {
+		t.Errorf("expected no template to be created, got %d", got)
+	}
+}
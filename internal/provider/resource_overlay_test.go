@@ -2,10 +2,26 @@ package provider
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
 	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/revosai/terraform-provider-revos/internal/client"
 )
 
 func TestJsonEqual(t *testing.T) {
@@ -99,6 +115,24 @@ func TestJsonEqual(t *testing.T) {
 			b:        `{"num": 42}`,
 			expected: true,
 		},
+		{
+			name:     "exponent",
+			a:        `{"num": 1e3}`,
+			b:        `{"num": 1000}`,
+			expected: true,
+		},
+		{
+			name:     "insignificant zeros",
+			a:        `{"num": 1.50}`,
+			b:        `{"num": 1.5}`,
+			expected: true,
+		},
+		{
+			name:     "large integers beyond float64 precision",
+			a:        `{"num": 12345678901234567890}`,
+			b:        `{"num": 12345678901234567891}`,
+			expected: false,
+		},
 		{
 			name:     "boolean values",
 			a:        `{"flag": true}`,
@@ -406,3 +440,3066 @@ func TestJsonSemanticEqualModifier_Description(t *testing.T) {
 		t.Errorf("MarkdownDescription = %q, want %q", mdDesc, desc)
 	}
 }
+
+func TestOverlayResource_Lifecycle(t *testing.T) {
+	m := newMockRevosServer(t)
+	h := newMockHarness(t, m)
+
+	testOverlayLifecycle(t, h, "lifecycle-test")
+
+	if got := m.overlayCount(); got != 0 {
+		t.Errorf("expected all overlays to be deleted, %d remain", got)
+	}
+}
+
+// TestAccOverlayResource_Lifecycle runs the same lifecycle against the real
+// API configured through REVOSAI_API_URL and REVOSAI_TOKEN.
+func TestAccOverlayResource_Lifecycle(t *testing.T) {
+	if os.Getenv("TF_ACC") == "" {
+		t.Skip("set TF_ACC=1 to run acceptance tests against the Revos API")
+	}
+
+	h := newTestHarness(t, map[string]interface{}{})
+
+	testOverlayLifecycle(t, h, fmt.Sprintf("%s%d", sweepPrefix, time.Now().UnixNano()))
+}
+
+func testOverlayLifecycle(t *testing.T, h *testHarness, name string) {
+	const typeName = "revos_overlay"
+	const definition = `{"measures":{"count":{"type":"count"}},"joins":{"clerk_Users":{"relationship":"one_to_many","sql":"y"}}}`
+
+	config := map[string]interface{}{
+		"name":        name,
+		"description": "first",
+		"data":        definition,
+	}
+	null := tftypes.NewValue(h.resourceSchema(typeName).ValueType(), nil)
+
+	// Create
+	state, diags := h.apply(typeName, null, config)
+	requireNoErrors(t, "create", diags)
+	id := attrString(t, state, "id")
+	if id == "" || id == "<unknown>" || id == "<null>" {
+		t.Fatalf("create: expected an ID, got %q", id)
+	}
+	if got := attrString(t, state, "data"); got != definition {
+		t.Errorf("create: data = %s, want %s", got, definition)
+	}
+
+	// Refresh keeps the configured data despite the API reordering keys
+	state, diags = h.read(typeName, state)
+	requireNoErrors(t, "read", diags)
+	if got := attrString(t, state, "data"); got != definition {
+		t.Errorf("read: data = %s, want %s", got, definition)
+	}
+
+	// Planning the same configuration is a no-op
+	planned, diags := h.plan(typeName, state, config)
+	requireNoErrors(t, "plan", diags)
+	if !planned.Equal(state) {
+		t.Errorf("plan: expected no changes, got %s", planned)
+	}
+
+	// Planning reordered but equivalent data is a no-op as well
+	reordered := map[string]interface{}{
+		"name":        name,
+		"description": "first",
+		"data":        `{"joins":{"clerk_Users":{"sql":"y","relationship":"one_to_many"}},"measures":{"count":{"type":"count"}}}`,
+	}
+	planned, diags = h.plan(typeName, state, reordered)
+	requireNoErrors(t, "plan reordered", diags)
+	if !planned.Equal(state) {
+		t.Errorf("plan reordered: expected no changes, got %s", planned)
+	}
+
+	// Update
+	config["description"] = "second"
+	state, diags = h.apply(typeName, state, config)
+	requireNoErrors(t, "update", diags)
+	if got := attrString(t, state, "description"); got != "second" {
+		t.Errorf("update: description = %q, want %q", got, "second")
+	}
+	if got := attrString(t, state, "id"); got != id {
+		t.Errorf("update: id changed from %q to %q", id, got)
+	}
+
+	// Import by ID
+	imported, diags := h.importState(typeName, id)
+	requireNoErrors(t, "import by id", diags)
+	if got := attrString(t, imported, "name"); got != name {
+		t.Errorf("import by id: name = %q, want %q", got, name)
+	}
+	if got := attrString(t, imported, "data"); !jsonEqual(got, definition) {
+		t.Errorf("import by id: data = %s, want semantically %s", got, definition)
+	}
+
+	// Import by name
+	imported, diags = h.importState(typeName, name)
+	requireNoErrors(t, "import by name", diags)
+	if got := attrString(t, imported, "id"); got != id {
+		t.Errorf("import by name: id = %q, want %q", got, id)
+	}
+	if got := attrString(t, imported, "description"); got != "second" {
+		t.Errorf("import by name: description = %q, want %q", got, "second")
+	}
+
+	// Destroy
+	requireNoErrors(t, "destroy", h.destroy(typeName, state))
+
+	// The overlay is gone, so refreshing removes it from state
+	state, diags = h.read(typeName, state)
+	requireNoErrors(t, "read after destroy", diags)
+	if !state.IsNull() {
+		t.Errorf("read after destroy: expected resource to be removed, got %s", state)
+	}
+}
+
+func TestOverlayNameValidator(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name        string
+		value       types.String
+		expectError bool
+	}{
+		{name: "simple", value: types.StringValue("my-overlay")},
+		{name: "dots and underscores", value: types.StringValue("team_a.sales-v2")},
+		{name: "digits first", value: types.StringValue("2024_overlay")},
+		{name: "max length", value: types.StringValue(strings.Repeat("a", overlayNameMaxLength))},
+		{name: "null", value: types.StringNull()},
+		{name: "unknown", value: types.StringUnknown()},
+		{name: "empty", value: types.StringValue(""), expectError: true},
+		{name: "too long", value: types.StringValue(strings.Repeat("a", overlayNameMaxLength+1)), expectError: true},
+		{name: "spaces", value: types.StringValue("my overlay"), expectError: true},
+		{name: "leading hyphen", value: types.StringValue("-overlay"), expectError: true},
+		{name: "slash", value: types.StringValue("team/overlay"), expectError: true},
+		{name: "unicode", value: types.StringValue("überlay"), expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := validator.StringRequest{
+				Path:        path.Root("name"),
+				ConfigValue: tt.value,
+			}
+			resp := &validator.StringResponse{}
+
+			overlayNameValidator{}.ValidateString(ctx, req, resp)
+
+			if resp.Diagnostics.HasError() != tt.expectError {
+				t.Errorf("HasError = %v, want %v (diagnostics: %v)", resp.Diagnostics.HasError(), tt.expectError, resp.Diagnostics)
+			}
+		})
+	}
+}
+
+func TestOverlayResource_InvalidNameFailsPlan(t *testing.T) {
+	m := newMockRevosServer(t)
+	h := newMockHarness(t, m)
+
+	null := tftypes.NewValue(h.resourceSchema("revos_overlay").ValueType(), nil)
+	_, diags := h.plan("revos_overlay", null, map[string]interface{}{
+		"name": "not a valid name",
+		"data": `{}`,
+	})
+	requireError(t, diags, "Invalid Overlay Name")
+
+	if got := m.requestCount("POST", "/cube-overlays"); got != 0 {
+		t.Errorf("expected no API calls, got %d", got)
+	}
+}
+
+func TestGenerateOverlayName(t *testing.T) {
+	seen := map[string]bool{}
+	for i := 0; i < 100; i++ {
+		name, err := generateOverlayName("sales-")
+		if err != nil {
+			t.Fatalf("generateOverlayName: %s", err)
+		}
+		if !strings.HasPrefix(name, "sales-") {
+			t.Errorf("%q does not start with the prefix", name)
+		}
+		if len(name) != len("sales-")+generatedNameSuffixLength {
+			t.Errorf("%q has length %d, want %d", name, len(name), len("sales-")+generatedNameSuffixLength)
+		}
+		if !overlayNameRegexp.MatchString(name) {
+			t.Errorf("%q is not a valid overlay name", name)
+		}
+		if seen[name] {
+			t.Errorf("%q generated twice", name)
+		}
+		seen[name] = true
+	}
+}
+
+func TestOverlayResource_NamePrefix(t *testing.T) {
+	m := newMockRevosServer(t)
+	h := newMockHarness(t, m)
+
+	config := map[string]interface{}{
+		"name_prefix": "sales-",
+		"data":        `{"cubes":[]}`,
+	}
+
+	null := tftypes.NewValue(h.resourceSchema("revos_overlay").ValueType(), nil)
+	state, diags := h.apply("revos_overlay", null, config)
+	requireNoErrors(t, "create", diags)
+
+	name := attrString(t, state, "name")
+	if !strings.HasPrefix(name, "sales-") || len(name) != len("sales-")+generatedNameSuffixLength {
+		t.Errorf("name = %q, want sales- followed by %d generated characters", name, generatedNameSuffixLength)
+	}
+
+	planned, diags := h.plan("revos_overlay", state, config)
+	requireNoErrors(t, "plan", diags)
+	if !planned.Equal(state) {
+		t.Errorf("expected an empty plan, got %s", planned)
+	}
+
+	t.Run("validation", func(t *testing.T) {
+		tests := []struct {
+			name          string
+			config        map[string]interface{}
+			expectedError string
+		}{
+			{name: "both set", config: map[string]interface{}{"name": "sales", "name_prefix": "sales-"}, expectedError: "Conflicting Name Attributes"},
+			{name: "neither set", config: map[string]interface{}{}, expectedError: "Missing Name"},
+			{name: "prefix too long", config: map[string]interface{}{"name_prefix": strings.Repeat("a", overlayNameMaxLength-generatedNameSuffixLength+1)}, expectedError: "Invalid Overlay Name"},
+			{name: "invalid prefix", config: map[string]interface{}{"name_prefix": "-sales"}, expectedError: "Invalid Overlay Name"},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				tt.config["data"] = `{}`
+				_, diags := h.plan("revos_overlay", null, tt.config)
+				requireError(t, diags, tt.expectedError)
+			})
+		}
+	})
+}
+
+func TestOverlayResource_ConcurrentModification(t *testing.T) {
+	m := newMockRevosServer(t)
+	h := newMockHarness(t, m)
+
+	null := tftypes.NewValue(h.resourceSchema("revos_overlay").ValueType(), nil)
+	config := map[string]interface{}{
+		"name":        "concurrent",
+		"description": "mine",
+		"data":        `{"a":1}`,
+	}
+	state, diags := h.apply("revos_overlay", null, config)
+	requireNoErrors(t, "create", diags)
+	if got := attrString(t, state, "version"); got != `"1"` {
+		t.Errorf("version = %s, want %q", got, `"1"`)
+	}
+
+	// Someone else edits the overlay after Terraform last read it
+	m.setOverlayField(attrString(t, state, "id"), "description", "theirs")
+
+	config["description"] = "mine again"
+	_, diags = h.apply("revos_overlay", state, config)
+	requireError(t, diags, "refresh")
+
+	requireError(t, h.destroy("revos_overlay", state), "Overlay Modified Concurrently")
+
+	// After a refresh the update goes through
+	state, diags = h.read("revos_overlay", state)
+	requireNoErrors(t, "refresh", diags)
+	state, diags = h.apply("revos_overlay", state, config)
+	requireNoErrors(t, "update after refresh", diags)
+	if got := attrString(t, state, "version"); got != `"3"` {
+		t.Errorf("version = %s, want %q", got, `"3"`)
+	}
+	requireNoErrors(t, "destroy", h.destroy("revos_overlay", state))
+}
+
+func TestOverlayResource_ConcurrencyCheck(t *testing.T) {
+	for _, check := range []bool{false, true} {
+		t.Run(fmt.Sprintf("concurrency_check=%t", check), func(t *testing.T) {
+			// Without versions, only updatedAt can tell a stale state
+			m := newMockRevosServer(t)
+			m.unversioned = true
+			h := newTestHarness(t, map[string]interface{}{
+				"api_url":           m.URL,
+				"token":             "test-token",
+				"concurrency_check": check,
+			})
+
+			null := tftypes.NewValue(h.resourceSchema("revos_overlay").ValueType(), nil)
+			config := map[string]interface{}{
+				"name": "unversioned",
+				"data": `{"a":1}`,
+			}
+			state, diags := h.apply("revos_overlay", null, config)
+			requireNoErrors(t, "create", diags)
+			if got := attrString(t, state, "version"); got != "" {
+				t.Fatalf("version = %q, want none", got)
+			}
+
+			// Someone else edits the overlay after Terraform last read it
+			id := attrString(t, state, "id")
+			m.setOverlayField(id, "updatedAt", time.Now().UTC().Add(time.Hour).Format(time.RFC3339))
+
+			config["data"] = `{"a":2}`
+			_, diags = h.apply("revos_overlay", state, config)
+			if !check {
+				requireNoErrors(t, "update", diags)
+				return
+			}
+			requireError(t, diags, "Overlay Modified Concurrently")
+			requireError(t, h.destroy("revos_overlay", state), "refresh")
+
+			// After a refresh the update goes through
+			state, diags = h.read("revos_overlay", state)
+			requireNoErrors(t, "refresh", diags)
+			_, diags = h.apply("revos_overlay", state, config)
+			requireNoErrors(t, "update after refresh", diags)
+		})
+	}
+}
+
+func TestParseOverlayData(t *testing.T) {
+	tests := []struct {
+		name          string
+		data          string
+		expectedError string
+	}{
+		{name: "object", data: `{"measures": {}}`},
+		{name: "empty object", data: `{}`},
+		{name: "string", data: `"hello"`, expectedError: "got a string"},
+		{name: "number", data: `42`, expectedError: "got a number"},
+		{name: "boolean", data: `true`, expectedError: "got a boolean"},
+		{name: "array", data: `[1, 2, 3]`, expectedError: "got an array"},
+		{name: "null", data: `null`, expectedError: "got null"},
+		{name: "invalid", data: `{"a":`, expectedError: "unexpected end of JSON input"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raw, err := parseOverlayData(tt.data)
+			if tt.expectedError == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				if string(raw) != tt.data {
+					t.Errorf("raw = %s, want %s", raw, tt.data)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+				t.Errorf("error = %v, want it to contain %q", err, tt.expectedError)
+			}
+		})
+	}
+}
+
+func TestOverlayResource_DataMustBeObject(t *testing.T) {
+	m := newMockRevosServer(t)
+	h := newMockHarness(t, m)
+
+	null := tftypes.NewValue(h.resourceSchema("revos_overlay").ValueType(), nil)
+	_, diags := h.apply("revos_overlay", null, map[string]interface{}{
+		"name": "not-an-object",
+		"data": `[{"measures": {}}]`,
+	})
+	requireError(t, diags, "data must be a JSON object")
+
+	for _, d := range diags {
+		if d.Severity == tfprotov6.DiagnosticSeverityError && diagAttribute(d) != "data" {
+			t.Errorf("expected error on the data attribute, got %q", diagAttribute(d))
+		}
+	}
+	if got := m.requestCount("POST", "/cube-overlays"); got != 0 {
+		t.Errorf("expected no create request, got %d", got)
+	}
+}
+
+func TestMergeTags(t *testing.T) {
+	tests := []struct {
+		name     string
+		defaults map[string]string
+		tags     map[string]string
+		expected map[string]string
+	}{
+		{
+			name:     "no tags",
+			expected: map[string]string{},
+		},
+		{
+			name:     "defaults only",
+			defaults: map[string]string{"env": "prod"},
+			expected: map[string]string{"env": "prod"},
+		},
+		{
+			name:     "resource tags only",
+			tags:     map[string]string{"team": "data"},
+			expected: map[string]string{"team": "data"},
+		},
+		{
+			name:     "resource tags override defaults",
+			defaults: map[string]string{"env": "prod", "team": "platform"},
+			tags:     map[string]string{"team": "data"},
+			expected: map[string]string{"env": "prod", "team": "data"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := mergeTags(tt.defaults, tt.tags)
+			if got == nil {
+				t.Fatal("mergeTags returned nil")
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("mergeTags() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestResourceTags(t *testing.T) {
+	defaults := map[string]string{"env": "prod", "team": "platform"}
+
+	tests := []struct {
+		name     string
+		apiTags  map[string]string
+		prior    map[string]string
+		expected map[string]string
+	}{
+		{
+			name:     "inherited defaults are dropped",
+			apiTags:  map[string]string{"env": "prod", "team": "platform"},
+			expected: map[string]string{},
+		},
+		{
+			name:     "overridden defaults are kept",
+			apiTags:  map[string]string{"env": "prod", "team": "data"},
+			expected: map[string]string{"team": "data"},
+		},
+		{
+			name:     "tags set on the resource are kept even if they match a default",
+			apiTags:  map[string]string{"env": "prod", "team": "platform"},
+			prior:    map[string]string{"env": "prod"},
+			expected: map[string]string{"env": "prod"},
+		},
+		{
+			name:     "tags added outside Terraform are kept",
+			apiTags:  map[string]string{"env": "prod", "team": "platform", "owner": "alice"},
+			expected: map[string]string{"owner": "alice"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := resourceTags(tt.apiTags, defaults, tt.prior)
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("resourceTags() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestOverlayResource_DefaultTags(t *testing.T) {
+	m := newMockRevosServer(t)
+	h := newTestHarness(t, map[string]interface{}{
+		"api_url": m.URL,
+		"token":   "test-token",
+		"default_tags": map[string]interface{}{
+			"env":  "prod",
+			"team": "platform",
+		},
+	})
+
+	null := tftypes.NewValue(h.resourceSchema("revos_overlay").ValueType(), nil)
+	config := map[string]interface{}{
+		"name": "tagged",
+		"data": `{"a":1}`,
+		"tags": map[string]interface{}{"team": "data"},
+	}
+
+	planned, diags := h.plan("revos_overlay", null, config)
+	requireNoErrors(t, "plan", diags)
+	want := map[string]string{"env": "prod", "team": "data"}
+	if got := attrMap(t, planned, "tags_all"); !reflect.DeepEqual(got, want) {
+		t.Errorf("planned tags_all = %v, want %v", got, want)
+	}
+
+	state, diags := h.apply("revos_overlay", null, config)
+	requireNoErrors(t, "create", diags)
+	id := attrString(t, state, "id")
+	if got := m.overlayField(id, "tags"); !reflect.DeepEqual(got, map[string]interface{}{"env": "prod", "team": "data"}) {
+		t.Errorf("API tags = %v, want %v", got, want)
+	}
+
+	// A refresh keeps only the resource's own tags in tags, so the plan is empty
+	state, diags = h.read("revos_overlay", state)
+	requireNoErrors(t, "refresh", diags)
+	if got := attrMap(t, state, "tags"); !reflect.DeepEqual(got, map[string]string{"team": "data"}) {
+		t.Errorf("tags after refresh = %v", got)
+	}
+	planned, diags = h.plan("revos_overlay", state, config)
+	requireNoErrors(t, "plan after refresh", diags)
+	if !planned.Equal(state) {
+		t.Errorf("expected an empty plan after refresh, got %s", planned)
+	}
+
+	// Removing the resource tags falls back to the defaults
+	delete(config, "tags")
+	state, diags = h.apply("revos_overlay", state, config)
+	requireNoErrors(t, "update", diags)
+	if got := m.overlayField(id, "tags"); !reflect.DeepEqual(got, map[string]interface{}{"env": "prod", "team": "platform"}) {
+		t.Errorf("API tags after update = %v", got)
+	}
+	if got := attrString(t, state, "tags"); got != "<null>" {
+		t.Errorf("tags after update = %s, want null", got)
+	}
+
+	requireNoErrors(t, "destroy", h.destroy("revos_overlay", state))
+}
+
+func TestOverlayResource_NoTags(t *testing.T) {
+	m := newMockRevosServer(t)
+	h := newMockHarness(t, m)
+
+	null := tftypes.NewValue(h.resourceSchema("revos_overlay").ValueType(), nil)
+	config := map[string]interface{}{
+		"name": "untagged",
+		"data": `{"a":1}`,
+	}
+	state, diags := h.apply("revos_overlay", null, config)
+	requireNoErrors(t, "create", diags)
+
+	if got := m.overlayField(attrString(t, state, "id"), "tags"); !reflect.DeepEqual(got, map[string]interface{}{}) {
+		t.Errorf("API tags = %#v, want an empty object", got)
+	}
+	for _, attr := range []string{"tags", "tags_all"} {
+		if got := attrString(t, state, attr); got != "<null>" {
+			t.Errorf("%s = %s, want null", attr, got)
+		}
+	}
+
+	state, diags = h.read("revos_overlay", state)
+	requireNoErrors(t, "refresh", diags)
+	planned, diags := h.plan("revos_overlay", state, config)
+	requireNoErrors(t, "plan after refresh", diags)
+	if !planned.Equal(state) {
+		t.Errorf("expected an empty plan after refresh, got %s", planned)
+	}
+}
+
+func TestDataHash(t *testing.T) {
+	base, err := dataHash(`{"measures":{"count":{"type":"count"}},"dimensions":{"id":{"sql":"id","type":"number"}}}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	same := []string{
+		`{"dimensions":{"id":{"type":"number","sql":"id"}},"measures":{"count":{"type":"count"}}}`,
+		`{
+  "measures": { "count": { "type": "count" } },
+  "dimensions": { "id": { "sql": "id", "type": "number" } }
+}`,
+	}
+	for _, s := range same {
+		got, err := dataHash(s)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if got != base {
+			t.Errorf("dataHash(%s) = %s, want %s", s, got, base)
+		}
+	}
+
+	different, err := dataHash(`{"measures":{"count":{"type":"sum"}},"dimensions":{"id":{"sql":"id","type":"number"}}}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if different == base {
+		t.Error("expected a different hash for different data")
+	}
+
+	if _, err := dataHash(`{invalid`); err == nil {
+		t.Error("expected an error for invalid JSON")
+	}
+}
+
+func TestOverlayResource_DataHash(t *testing.T) {
+	m := newMockRevosServer(t)
+	h := newMockHarness(t, m)
+
+	null := tftypes.NewValue(h.resourceSchema("revos_overlay").ValueType(), nil)
+	config := map[string]interface{}{
+		"name": "hashed",
+		"data": `{"a":1,"b":{"c":2}}`,
+	}
+	want, err := dataHash(`{"a":1,"b":{"c":2}}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// Known at plan time, so dependents can use it before apply
+	planned, diags := h.plan("revos_overlay", null, config)
+	requireNoErrors(t, "plan", diags)
+	if got := attrString(t, planned, "data_hash"); got != want {
+		t.Errorf("planned data_hash = %s, want %s", got, want)
+	}
+
+	state, diags := h.apply("revos_overlay", null, config)
+	requireNoErrors(t, "create", diags)
+	state, diags = h.read("revos_overlay", state)
+	requireNoErrors(t, "refresh", diags)
+	if got := attrString(t, state, "data_hash"); got != want {
+		t.Errorf("data_hash after refresh = %s, want %s", got, want)
+	}
+
+	// Reordering the keys doesn't change the hash or cause a diff
+	config["data"] = `{"b":{"c":2},"a":1}`
+	planned, diags = h.plan("revos_overlay", state, config)
+	requireNoErrors(t, "plan reordered", diags)
+	if !planned.Equal(state) {
+		t.Errorf("expected an empty plan for reordered data, got %s", planned)
+	}
+
+	config["data"] = `{"a":2,"b":{"c":2}}`
+	state, diags = h.apply("revos_overlay", state, config)
+	requireNoErrors(t, "update", diags)
+	if got := attrString(t, state, "data_hash"); got == want {
+		t.Error("expected data_hash to change with the data")
+	}
+}
+
+func TestOverlayResource_RenamedOutsideTerraform(t *testing.T) {
+	m := newMockRevosServer(t)
+	h := newMockHarness(t, m)
+
+	null := tftypes.NewValue(h.resourceSchema("revos_overlay").ValueType(), nil)
+	config := map[string]interface{}{
+		"name": "original",
+		"data": `{"a":1}`,
+	}
+	state, diags := h.apply("revos_overlay", null, config)
+	requireNoErrors(t, "create", diags)
+
+	// Renamed in the UI
+	m.setOverlayField(attrString(t, state, "id"), "name", "renamed-in-ui")
+
+	state, diags = h.read("revos_overlay", state)
+	requireNoErrors(t, "refresh", diags)
+	if got := attrString(t, state, "name"); got != "renamed-in-ui" {
+		t.Fatalf("name after refresh = %s, want %s", got, "renamed-in-ui")
+	}
+
+	// The plan renames it back, and only the name is planned to change
+	planned, diags := h.plan("revos_overlay", state, config)
+	requireNoErrors(t, "plan", diags)
+	if got := attrString(t, planned, "name"); got != "original" {
+		t.Errorf("planned name = %s, want %s", got, "original")
+	}
+	if got := attrString(t, planned, "id"); got != attrString(t, state, "id") {
+		t.Errorf("planned id = %s, want the overlay to be updated in place", got)
+	}
+
+	state, diags = h.apply("revos_overlay", state, config)
+	requireNoErrors(t, "rename back", diags)
+	if got := m.overlayField(attrString(t, state, "id"), "name"); got != "original" {
+		t.Errorf("API name = %v, want %s", got, "original")
+	}
+}
+
+func TestOverlayResource_ReadWithFake(t *testing.T) {
+	existing := client.CubeOverlay{
+		ID:             "ov-1",
+		Name:           "sales",
+		OrganizationID: "org-1",
+		Data:           []byte(`{"a": 1}`),
+		CreatedBy:      "user-1",
+		CreatedAt:      "2024-01-01T00:00:00Z",
+		UpdatedAt:      "2024-02-01T00:00:00Z",
+		Version:        "2",
+	}
+
+	tests := []struct {
+		name          string
+		api           *fakeOverlayAPI
+		id            string
+		expectRemoved bool
+		expectedError string
+	}{
+		{
+			name: "found",
+			api:  newFakeOverlayAPI(existing),
+			id:   "ov-1",
+		},
+		{
+			name:          "not found removes the resource",
+			api:           newFakeOverlayAPI(),
+			id:            "ov-1",
+			expectRemoved: true,
+		},
+		{
+			name:          "API error",
+			api:           &fakeOverlayAPI{err: &client.APIError{StatusCode: 500, Body: "boom"}},
+			id:            "ov-1",
+			expectedError: "Unable to read overlay",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			r := &OverlayResource{client: tt.api}
+			state := overlayState(t, r, testOverlayModel(tt.id))
+
+			resp := resource.ReadResponse{State: state}
+			r.Read(ctx, resource.ReadRequest{State: state}, &resp)
+
+			if tt.expectedError != "" {
+				if !resp.Diagnostics.HasError() {
+					t.Fatal("expected an error")
+				}
+				if got := resp.Diagnostics.Errors()[0].Detail(); !strings.Contains(got, tt.expectedError) {
+					t.Errorf("error = %q, want it to contain %q", got, tt.expectedError)
+				}
+				return
+			}
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("unexpected error: %v", resp.Diagnostics)
+			}
+			if tt.expectRemoved {
+				if !resp.State.Raw.IsNull() {
+					t.Error("expected the resource to be removed from state")
+				}
+				return
+			}
+
+			var got OverlayResourceModel
+			resp.Diagnostics.Append(resp.State.Get(ctx, &got)...)
+			if got.UpdatedAt.ValueString() != existing.UpdatedAt {
+				t.Errorf("updated_at = %s, want %s", got.UpdatedAt.ValueString(), existing.UpdatedAt)
+			}
+			if got.Version.ValueString() != "2" {
+				t.Errorf("version = %s, want 2", got.Version.ValueString())
+			}
+			// Semantically equal data keeps the state's formatting
+			if got.Data.ValueString() != `{"a":1}` {
+				t.Errorf("data = %s, want the state value", got.Data.ValueString())
+			}
+		})
+	}
+}
+
+func TestOverlayResource_DeleteWithFake(t *testing.T) {
+	tests := []struct {
+		name          string
+		api           *fakeOverlayAPI
+		expectedError string
+	}{
+		{
+			name: "deleted",
+			api:  newFakeOverlayAPI(client.CubeOverlay{ID: "ov-1"}),
+		},
+		{
+			name: "already gone",
+			api:  newFakeOverlayAPI(),
+		},
+		{
+			name:          "modified concurrently",
+			api:           &fakeOverlayAPI{err: &client.APIError{StatusCode: 412, Body: "Precondition Failed"}},
+			expectedError: "Overlay Modified Concurrently",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &OverlayResource{client: tt.api}
+			state := overlayState(t, r, testOverlayModel("ov-1"))
+
+			var resp resource.DeleteResponse
+			r.Delete(context.Background(), resource.DeleteRequest{State: state}, &resp)
+
+			if tt.expectedError == "" {
+				if resp.Diagnostics.HasError() {
+					t.Fatalf("unexpected error: %v", resp.Diagnostics)
+				}
+				if len(tt.api.overlays) != 0 {
+					t.Error("expected the overlay to be deleted")
+				}
+				return
+			}
+			if !resp.Diagnostics.HasError() || resp.Diagnostics.Errors()[0].Summary() != tt.expectedError {
+				t.Errorf("diagnostics = %v, want %q", resp.Diagnostics, tt.expectedError)
+			}
+		})
+	}
+}
+
+func TestRenderTemplate(t *testing.T) {
+	tests := []struct {
+		name          string
+		data          string
+		vars          map[string]string
+		expected      string
+		expectedError string
+	}{
+		{
+			name:     "no placeholders",
+			data:     `{"sql":"orders"}`,
+			vars:     map[string]string{},
+			expected: `{"sql":"orders"}`,
+		},
+		{
+			name:     "substitutes placeholders",
+			data:     `{"sql":"${schema}.orders","db":"${db}","again":"${schema}"}`,
+			vars:     map[string]string{"schema": "prod", "db": "warehouse"},
+			expected: `{"sql":"prod.orders","db":"warehouse","again":"prod"}`,
+		},
+		{
+			name:     "extra vars are ignored",
+			data:     `{"sql":"${schema}.orders"}`,
+			vars:     map[string]string{"schema": "prod", "unused": "x"},
+			expected: `{"sql":"prod.orders"}`,
+		},
+		{
+			name:     "values are JSON-escaped",
+			data:     `{"sql":"${filter}"}`,
+			vars:     map[string]string{"filter": `status = "open"`},
+			expected: `{"sql":"status = \"open\""}`,
+		},
+		{
+			name:          "missing vars",
+			data:          `{"sql":"${schema}.${table}","db":"${db}"}`,
+			vars:          map[string]string{"schema": "prod"},
+			expectedError: "table, db",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := renderTemplate(tt.data, tt.vars)
+			if tt.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+					t.Errorf("error = %v, want it to contain %q", err, tt.expectedError)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if got != tt.expected {
+				t.Errorf("renderTemplate() = %s, want %s", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestOverlayResource_DataVars(t *testing.T) {
+	m := newMockRevosServer(t)
+	h := newMockHarness(t, m)
+
+	null := tftypes.NewValue(h.resourceSchema("revos_overlay").ValueType(), nil)
+
+	t.Run("missing var fails plan", func(t *testing.T) {
+		_, diags := h.plan("revos_overlay", null, map[string]interface{}{
+			"name":      "missing-var",
+			"data":      `{"sql":"${schema}.orders"}`,
+			"data_vars": map[string]interface{}{"db": "warehouse"},
+		})
+		requireError(t, diags, "schema")
+	})
+
+	t.Run("extra var warns", func(t *testing.T) {
+		_, diags := h.plan("revos_overlay", null, map[string]interface{}{
+			"name":      "extra-var",
+			"data":      `{"sql":"${schema}.orders"}`,
+			"data_vars": map[string]interface{}{"schema": "prod", "db": "warehouse"},
+		})
+		requireNoErrors(t, "plan", diags)
+		found := false
+		for _, d := range diags {
+			if d.Severity == tfprotov6.DiagnosticSeverityWarning && strings.Contains(d.Detail, `"db"`) {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("expected an unused variable warning, got %s", formatDiags(diags))
+		}
+	})
+
+	t.Run("substituted data is sent", func(t *testing.T) {
+		config := map[string]interface{}{
+			"name":      "templated",
+			"data":      `{"sql":"${schema}.orders"}`,
+			"data_vars": map[string]interface{}{"schema": "prod"},
+		}
+		state, diags := h.apply("revos_overlay", null, config)
+		requireNoErrors(t, "create", diags)
+
+		if got := m.overlayField(attrString(t, state, "id"), "data"); !reflect.DeepEqual(got, map[string]interface{}{"sql": "prod.orders"}) {
+			t.Errorf("API data = %v", got)
+		}
+		if got := attrString(t, state, "data"); got != `{"sql":"${schema}.orders"}` {
+			t.Errorf("data in state = %s, want the template", got)
+		}
+
+		// The API's rendered data matches the template, so there's no drift
+		state, diags = h.read("revos_overlay", state)
+		requireNoErrors(t, "refresh", diags)
+		planned, diags := h.plan("revos_overlay", state, config)
+		requireNoErrors(t, "plan after refresh", diags)
+		if !planned.Equal(state) {
+			t.Errorf("expected an empty plan after refresh, got %s", planned)
+		}
+
+		// Changing a variable updates the overlay
+		config["data_vars"] = map[string]interface{}{"schema": "staging"}
+		state, diags = h.apply("revos_overlay", state, config)
+		requireNoErrors(t, "update", diags)
+		if got := m.overlayField(attrString(t, state, "id"), "data"); !reflect.DeepEqual(got, map[string]interface{}{"sql": "staging.orders"}) {
+			t.Errorf("API data after update = %v", got)
+		}
+	})
+}
+
+func TestOverlayResource_CreateRecoversCreatedOverlay(t *testing.T) {
+	m := newMockRevosServer(t)
+	h := newMockHarness(t, m)
+
+	// The POST succeeds server-side, but the response can't be parsed
+	m.truncateCreateResponse = true
+
+	null := tftypes.NewValue(h.resourceSchema("revos_overlay").ValueType(), nil)
+	config := map[string]interface{}{
+		"name": "half-created",
+		"data": `{"a":1}`,
+	}
+	state, diags := h.apply("revos_overlay", null, config)
+	requireError(t, diags, "Overlay Partially Created")
+
+	if state.IsNull() {
+		t.Fatal("expected the created overlay to be saved to state")
+	}
+	if got := attrString(t, state, "id"); got != "ov-1" {
+		t.Errorf("id = %s, want ov-1", got)
+	}
+	if got := attrString(t, state, "version"); got != `"1"` {
+		t.Errorf("version = %s, want %q", got, `"1"`)
+	}
+
+	// With the overlay in state, the next run doesn't create a duplicate
+	m.truncateCreateResponse = false
+	state, diags = h.read("revos_overlay", state)
+	requireNoErrors(t, "refresh", diags)
+	planned, diags := h.plan("revos_overlay", state, config)
+	requireNoErrors(t, "plan", diags)
+	if !planned.Equal(state) {
+		t.Errorf("expected an empty plan, got %s", planned)
+	}
+	if got := m.overlayCount(); got != 1 {
+		t.Errorf("overlay count = %d, want 1", got)
+	}
+}
+
+func TestOverlayResource_CreateRecoversAfterTimeout(t *testing.T) {
+	m := newMockRevosServer(t)
+	h := newMockHarness(t, m)
+
+	// The overlay is created, but the response only arrives after the
+	// create timeout, whose context the lookup can't use
+	m.slowCreateResponse = 2 * time.Second
+
+	null := tftypes.NewValue(h.resourceSchema("revos_overlay").ValueType(), nil)
+	state, diags := h.apply("revos_overlay", null, map[string]interface{}{
+		"name":     "timed-out",
+		"data":     `{"a":1}`,
+		"timeouts": map[string]interface{}{"create": "200ms"},
+	})
+	requireError(t, diags, "Overlay Partially Created")
+	if state.IsNull() {
+		t.Fatal("expected the created overlay to be saved to state")
+	}
+	if got := attrString(t, state, "id"); got != "ov-1" {
+		t.Errorf("id = %s, want ov-1", got)
+	}
+}
+
+func TestOverlayResource_CreateFailureIsNotRecovered(t *testing.T) {
+	t.Run("archived overlay with the name", func(t *testing.T) {
+		m := newMockRevosServer(t)
+		h := newMockHarness(t, m)
+
+		null := tftypes.NewValue(h.resourceSchema("revos_overlay").ValueType(), nil)
+		config := map[string]interface{}{
+			"name": "reused",
+			"data": `{"a":1}`,
+		}
+		_, diags := h.apply("revos_overlay", null, config)
+		requireNoErrors(t, "create", diags)
+		m.setOverlayField("ov-1", "archived", true)
+
+		// The create fails on the server, so the archived overlay is an
+		// older one and mustn't be taken for the new one
+		m.createStatus = http.StatusInternalServerError
+		state, diags := h.apply("revos_overlay", null, config)
+		requireError(t, diags, "Unable to create overlay")
+		if !state.IsNull() {
+			t.Errorf("expected no state, got %s", state)
+		}
+	})
+
+	t.Run("read-only", func(t *testing.T) {
+		m := newMockRevosServer(t)
+		h := newTestHarness(t, map[string]interface{}{
+			"api_url":   m.URL,
+			"token":     "test-token",
+			"read_only": true,
+		})
+
+		null := tftypes.NewValue(h.resourceSchema("revos_overlay").ValueType(), nil)
+		state, diags := h.apply("revos_overlay", null, map[string]interface{}{
+			"name": "refused",
+			"data": `{"a":1}`,
+		})
+		requireError(t, diags, "read-only")
+		if !state.IsNull() {
+			t.Errorf("expected no state, got %s", state)
+		}
+		if got := m.requestCount("GET", "/cube-overlays"); got != 0 {
+			t.Errorf("expected no lookup by name, got %d", got)
+		}
+	})
+}
+
+func TestOverlayResource_CreateConflictIsNotRecovered(t *testing.T) {
+	m := newMockRevosServer(t)
+	h := newMockHarness(t, m)
+
+	null := tftypes.NewValue(h.resourceSchema("revos_overlay").ValueType(), nil)
+	config := map[string]interface{}{
+		"name": "taken",
+		"data": `{"a":1}`,
+	}
+	_, diags := h.apply("revos_overlay", null, config)
+	requireNoErrors(t, "create", diags)
+
+	// A 409 means nothing was created, so the existing overlay isn't adopted
+	state, diags := h.apply("revos_overlay", null, config)
+	requireError(t, diags, "Unable to create overlay")
+	if !state.IsNull() {
+		t.Errorf("expected no state, got %s", state)
+	}
+	if got := m.requestCount("GET", "/cube-overlays"); got != 0 {
+		t.Errorf("expected no lookup by name, got %d", got)
+	}
+}
+
+func TestOverlayResource_Timeouts(t *testing.T) {
+	m := newMockRevosServer(t)
+	h := newMockHarness(t, m)
+
+	null := tftypes.NewValue(h.resourceSchema("revos_overlay").ValueType(), nil)
+
+	t.Run("invalid duration", func(t *testing.T) {
+		_, diags := h.plan("revos_overlay", null, map[string]interface{}{
+			"name":     "invalid-timeout",
+			"data":     `{"a":1}`,
+			"timeouts": map[string]interface{}{"create": "soon"},
+		})
+		if !hasErrors(diags) {
+			t.Fatal("expected an error for an invalid duration")
+		}
+	})
+
+	t.Run("create timeout applies", func(t *testing.T) {
+		m.setDelay(500 * time.Millisecond)
+		defer m.setDelay(0)
+
+		_, diags := h.apply("revos_overlay", null, map[string]interface{}{
+			"name":     "slow",
+			"data":     `{"a":1}`,
+			"timeouts": map[string]interface{}{"create": "50ms"},
+		})
+		requireError(t, diags, "deadline exceeded")
+	})
+
+	t.Run("longer timeouts allow slow requests", func(t *testing.T) {
+		m.setDelay(50 * time.Millisecond)
+		defer m.setDelay(0)
+
+		config := map[string]interface{}{
+			"name": "patient",
+			"data": `{"a":1}`,
+			"timeouts": map[string]interface{}{
+				"create": "10s",
+				"read":   "10s",
+				"update": "10s",
+				"delete": "10s",
+			},
+		}
+		state, diags := h.apply("revos_overlay", null, config)
+		requireNoErrors(t, "create", diags)
+		state, diags = h.read("revos_overlay", state)
+		requireNoErrors(t, "refresh", diags)
+
+		var timeouts map[string]tftypes.Value
+		if err := attrValue(t, state, "timeouts").As(&timeouts); err != nil {
+			t.Fatalf("timeouts: %s", err)
+		}
+		var create string
+		if err := timeouts["create"].As(&create); err != nil || create != "10s" {
+			t.Errorf("timeouts.create = %q, want 10s", create)
+		}
+
+		requireNoErrors(t, "destroy", h.destroy("revos_overlay", state))
+	})
+}
+
+func TestWithTimeout(t *testing.T) {
+	ctx, cancel := withTimeout(context.Background(), 0)
+	defer cancel()
+	if _, ok := ctx.Deadline(); ok {
+		t.Error("expected no deadline for a zero timeout")
+	}
+
+	ctx, cancel = withTimeout(context.Background(), time.Minute)
+	defer cancel()
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		t.Fatal("expected a deadline")
+	}
+	if remaining := time.Until(deadline); remaining <= 0 || remaining > time.Minute {
+		t.Errorf("deadline in %s, want within a minute", remaining)
+	}
+}
+
+func TestOverlayResource_EmptyDescription(t *testing.T) {
+	tests := []struct {
+		name        string
+		initial     interface{}
+		description interface{}
+		expected    string
+	}{
+		{
+			name:        "unset",
+			description: nil,
+			expected:    "<null>",
+		},
+		{
+			name:        "explicit empty string",
+			description: "",
+			expected:    "",
+		},
+		{
+			name:        "cleared by removing it",
+			initial:     "something",
+			description: nil,
+			expected:    "<null>",
+		},
+		{
+			name:        "cleared by setting it empty",
+			initial:     "something",
+			description: "",
+			expected:    "",
+		},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newMockRevosServer(t)
+			h := newMockHarness(t, m)
+
+			state := tftypes.NewValue(h.resourceSchema("revos_overlay").ValueType(), nil)
+			config := map[string]interface{}{
+				"name": fmt.Sprintf("description-%d", i),
+				"data": `{"a":1}`,
+			}
+			var diags []*tfprotov6.Diagnostic
+			if tt.initial != nil {
+				config["description"] = tt.initial
+				state, diags = h.apply("revos_overlay", state, config)
+				requireNoErrors(t, "create", diags)
+			}
+
+			config["description"] = tt.description
+			state, diags = h.apply("revos_overlay", state, config)
+			requireNoErrors(t, "apply", diags)
+			if got := m.overlayField(attrString(t, state, "id"), "description"); got != "" {
+				t.Errorf("API description = %v, want empty", got)
+			}
+
+			state, diags = h.read("revos_overlay", state)
+			requireNoErrors(t, "refresh", diags)
+			if got := attrString(t, state, "description"); got != tt.expected {
+				t.Errorf("description after refresh = %q, want %q", got, tt.expected)
+			}
+
+			planned, diags := h.plan("revos_overlay", state, config)
+			requireNoErrors(t, "plan", diags)
+			if !planned.Equal(state) {
+				t.Errorf("expected an empty plan, got %s", planned)
+			}
+		})
+	}
+}
+
+func TestOverlayResource_ImportEmptyDescription(t *testing.T) {
+	m := newMockRevosServer(t)
+	h := newMockHarness(t, m)
+
+	null := tftypes.NewValue(h.resourceSchema("revos_overlay").ValueType(), nil)
+	state, diags := h.apply("revos_overlay", null, map[string]interface{}{
+		"name": "imported",
+		"data": `{"a":1}`,
+	})
+	requireNoErrors(t, "create", diags)
+
+	imported, diags := h.importState("revos_overlay", attrString(t, state, "id"))
+	requireNoErrors(t, "import", diags)
+	if got := attrString(t, imported, "description"); got != "<null>" {
+		t.Errorf("imported description = %q, want null", got)
+	}
+}
+
+func TestOverlayResource_DataFormatJSON5(t *testing.T) {
+	m := newMockRevosServer(t)
+	h := newMockHarness(t, m)
+
+	null := tftypes.NewValue(h.resourceSchema("revos_overlay").ValueType(), nil)
+
+	t.Run("invalid format", func(t *testing.T) {
+		_, diags := h.plan("revos_overlay", null, map[string]interface{}{
+			"name":        "yaml",
+			"data":        `{"a":1}`,
+			"data_format": "yaml",
+		})
+		requireError(t, diags, "Invalid Data Format")
+	})
+
+	t.Run("comments are rejected in strict JSON", func(t *testing.T) {
+		_, diags := h.apply("revos_overlay", null, map[string]interface{}{
+			"name": "strict",
+			"data": "{\n  // comment\n  \"a\": 1\n}",
+		})
+		requireError(t, diags, "Invalid JSON in data")
+	})
+
+	t.Run("invalid JSON5 fails validation", func(t *testing.T) {
+		_, diags := h.plan("revos_overlay", null, map[string]interface{}{
+			"name":        "broken",
+			"data":        `{"a": 1 /* unterminated`,
+			"data_format": "json5",
+		})
+		requireError(t, diags, "unterminated block comment")
+	})
+
+	t.Run("commented data is normalized", func(t *testing.T) {
+		data := `{
+  // Orders placed through the web shop
+  "measures": {
+    "count": { "type": "count", }, /* the only measure */
+  },
+}`
+		config := map[string]interface{}{
+			"name":        "commented",
+			"data":        data,
+			"data_format": "json5",
+		}
+		state, diags := h.apply("revos_overlay", null, config)
+		requireNoErrors(t, "create", diags)
+
+		want := map[string]interface{}{"measures": map[string]interface{}{"count": map[string]interface{}{"type": "count"}}}
+		if got := m.overlayField(attrString(t, state, "id"), "data"); !reflect.DeepEqual(got, want) {
+			t.Errorf("API data = %v, want %v", got, want)
+		}
+		wantHash, _ := dataHash(`{"measures":{"count":{"type":"count"}}}`)
+		if got := attrString(t, state, "data_hash"); got != wantHash {
+			t.Errorf("data_hash = %s, want the hash of the normalized data", got)
+		}
+
+		state, diags = h.read("revos_overlay", state)
+		requireNoErrors(t, "refresh", diags)
+		if got := attrString(t, state, "data"); got != data {
+			t.Errorf("data after refresh = %s, want the configured text", got)
+		}
+		planned, diags := h.plan("revos_overlay", state, config)
+		requireNoErrors(t, "plan after refresh", diags)
+		if !planned.Equal(state) {
+			t.Errorf("expected an empty plan after refresh, got %s", planned)
+		}
+	})
+}
+
+func TestOverlayResource_DeletionMode(t *testing.T) {
+	tests := []struct {
+		mode     interface{}
+		endpoint string
+		archived bool
+	}{
+		{mode: nil, endpoint: "DELETE /cube-overlays/ov-1"},
+		{mode: "delete", endpoint: "DELETE /cube-overlays/ov-1"},
+		{mode: "archive", endpoint: "POST /cube-overlays/ov-1/archive", archived: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%v", tt.mode), func(t *testing.T) {
+			m := newMockRevosServer(t)
+			h := newMockHarness(t, m)
+
+			null := tftypes.NewValue(h.resourceSchema("revos_overlay").ValueType(), nil)
+			state, diags := h.apply("revos_overlay", null, map[string]interface{}{
+				"name":          "retained",
+				"data":          `{"a":1}`,
+				"deletion_mode": tt.mode,
+			})
+			requireNoErrors(t, "create", diags)
+			if tt.mode == nil {
+				if got := attrString(t, state, "deletion_mode"); got != "delete" {
+					t.Errorf("deletion_mode = %q, want the default %q", got, "delete")
+				}
+			}
+
+			requireNoErrors(t, "destroy", h.destroy("revos_overlay", state))
+
+			method, path, _ := strings.Cut(tt.endpoint, " ")
+			if got := m.requestCount(method, path); got != 1 {
+				t.Errorf("%s called %d times, want 1", tt.endpoint, got)
+			}
+			if tt.archived {
+				if m.overlayCount() != 1 || m.overlayField("ov-1", "archived") != true {
+					t.Error("expected the overlay to be kept and archived")
+				}
+			} else if m.overlayCount() != 0 {
+				t.Error("expected the overlay to be deleted")
+			}
+		})
+	}
+
+	t.Run("invalid mode", func(t *testing.T) {
+		m := newMockRevosServer(t)
+		h := newMockHarness(t, m)
+
+		null := tftypes.NewValue(h.resourceSchema("revos_overlay").ValueType(), nil)
+		_, diags := h.plan("revos_overlay", null, map[string]interface{}{
+			"name":          "retained",
+			"data":          `{"a":1}`,
+			"deletion_mode": "purge",
+		})
+		requireError(t, diags, "Invalid Deletion Mode")
+	})
+
+	t.Run("changing the mode does not update the overlay", func(t *testing.T) {
+		m := newMockRevosServer(t)
+		h := newMockHarness(t, m)
+
+		config := map[string]interface{}{
+			"name": "retained",
+			"data": `{"a":1}`,
+		}
+		null := tftypes.NewValue(h.resourceSchema("revos_overlay").ValueType(), nil)
+		state, diags := h.apply("revos_overlay", null, config)
+		requireNoErrors(t, "create", diags)
+
+		config["deletion_mode"] = "archive"
+		updated, diags := h.apply("revos_overlay", state, config)
+		requireNoErrors(t, "update", diags)
+		if got := m.requestCount("PATCH", "/cube-overlays/ov-1"); got != 0 {
+			t.Errorf("PATCH called %d times, want 0", got)
+		}
+		if got := attrString(t, updated, "deletion_mode"); got != "archive" {
+			t.Errorf("deletion_mode = %q, want %q", got, "archive")
+		}
+		if got, want := attrString(t, updated, "version"), attrString(t, state, "version"); got != want {
+			t.Errorf("version = %q, want %q", got, want)
+		}
+	})
+}
+
+func TestOverlayResource_ImportArchived(t *testing.T) {
+	m := newMockRevosServer(t)
+	h := newMockHarness(t, m)
+
+	null := tftypes.NewValue(h.resourceSchema("revos_overlay").ValueType(), nil)
+	state, diags := h.apply("revos_overlay", null, map[string]interface{}{
+		"name":          "archived",
+		"data":          `{"a":1}`,
+		"deletion_mode": "archive",
+	})
+	requireNoErrors(t, "create", diags)
+	requireNoErrors(t, "destroy", h.destroy("revos_overlay", state))
+
+	for _, id := range []string{"ov-1", "archived"} {
+		imported, diags := h.importState("revos_overlay", id)
+		requireNoErrors(t, "import "+id, diags)
+		if got := attrString(t, imported, "id"); got != "ov-1" {
+			t.Errorf("import %s: id = %q, want ov-1", id, got)
+		}
+	}
+}
+
+func TestSplitImportID(t *testing.T) {
+	tests := []struct {
+		id                     string
+		expectedOrganizationID string
+		expectedName           string
+		expectedOK             bool
+	}{
+		{id: "ov-1"},
+		{id: "sales"},
+		{id: "org-1/sales", expectedOrganizationID: "org-1", expectedName: "sales", expectedOK: true},
+		{id: "tenants/acme/sales", expectedOrganizationID: "tenants/acme", expectedName: "sales", expectedOK: true},
+		{id: "/sales"},
+		{id: "org-1/"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			organizationID, name, ok := splitImportID(tt.id)
+			if organizationID != tt.expectedOrganizationID || name != tt.expectedName || ok != tt.expectedOK {
+				t.Errorf("splitImportID(%q) = %q, %q, %v, want %q, %q, %v", tt.id, organizationID, name, ok,
+					tt.expectedOrganizationID, tt.expectedName, tt.expectedOK)
+			}
+		})
+	}
+}
+
+func TestOverlayResource_ImportCompositeID(t *testing.T) {
+	m := newMockRevosServer(t)
+	h := newMockHarness(t, m)
+
+	null := tftypes.NewValue(h.resourceSchema("revos_overlay").ValueType(), nil)
+	for _, name := range []string{"sales", "sales-org-2"} {
+		_, diags := h.apply("revos_overlay", null, map[string]interface{}{
+			"name": name,
+			"data": `{"cubes":[]}`,
+		})
+		requireNoErrors(t, "create "+name, diags)
+	}
+
+	// Two organizations with an overlay of the same name
+	m.setOverlayField("ov-2", "organizationId", "org-2")
+	m.setOverlayField("ov-2", "name", "sales")
+
+	for _, tt := range []struct{ importID, expectedID string }{{"org-1/sales", "ov-1"}, {"org-2/sales", "ov-2"}} {
+		imported, diags := h.importState("revos_overlay", tt.importID)
+		requireNoErrors(t, "import "+tt.importID, diags)
+		if got := attrString(t, imported, "id"); got != tt.expectedID {
+			t.Errorf("import %s: id = %q, want %q", tt.importID, got, tt.expectedID)
+		}
+		if got := attrString(t, imported, "organization_id"); got+"/sales" != tt.importID {
+			t.Errorf("import %s: organization_id = %q", tt.importID, got)
+		}
+	}
+
+	_, diags := h.importState("revos_overlay", "org-3/sales")
+	requireError(t, diags, "Overlay Not Found")
+}
+
+func TestJSONTopLevelDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		a, b     string
+		expected []string
+	}{
+		{
+			name:     "equal with different ordering",
+			a:        `{"a":1,"b":{"c":2,"d":3}}`,
+			b:        `{"b":{"d":3,"c":2},"a":1}`,
+			expected: nil,
+		},
+		{
+			name:     "changed nested value",
+			a:        `{"cubes":[{"name":"orders"}],"views":[]}`,
+			b:        `{"cubes":[{"name":"users"}],"views":[]}`,
+			expected: []string{"cubes"},
+		},
+		{
+			name:     "added and removed keys",
+			a:        `{"a":1,"b":2,"c":3}`,
+			b:        `{"b":2,"c":4,"d":5}`,
+			expected: []string{"a", "c", "d"},
+		},
+		{
+			name:     "not an object",
+			a:        `[1,2]`,
+			b:        `{"a":1}`,
+			expected: nil,
+		},
+		{
+			name:     "invalid JSON",
+			a:        `{"a":1}`,
+			b:        `{invalid`,
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := jsonTopLevelDiff(tt.a, tt.b); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("jsonTopLevelDiff() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestOverlayResource_ImportWithFake(t *testing.T) {
+	tests := []struct {
+		name          string
+		api           *fakeOverlayAPI
+		importID      string
+		expectedID    string
+		expectedError string
+		expectedCalls []string
+	}{
+		{
+			name:          "by ID",
+			api:           newFakeOverlayAPI(client.CubeOverlay{ID: "ov-1", Name: "sales", Data: []byte(`{}`)}),
+			importID:      "ov-1",
+			expectedID:    "ov-1",
+			expectedCalls: []string{"GetOverlay"},
+		},
+		{
+			name:          "by name",
+			api:           newFakeOverlayAPI(client.CubeOverlay{ID: "ov-1", Name: "sales", Data: []byte(`{}`)}),
+			importID:      "sales",
+			expectedID:    "ov-1",
+			expectedCalls: []string{"GetOverlay", "GetOverlayByName"},
+		},
+		{
+			name:          "neither ID nor name",
+			api:           newFakeOverlayAPI(),
+			importID:      "missing",
+			expectedError: "Overlay Not Found",
+			expectedCalls: []string{"GetOverlay", "GetOverlayByName", "GetCreatedOverlay"},
+		},
+		{
+			name:          "API failure is not treated as not found",
+			api:           &fakeOverlayAPI{err: &client.APIError{StatusCode: 503, Body: "Service Unavailable"}},
+			importID:      "ov-1",
+			expectedError: "Service Unavailable",
+			expectedCalls: []string{"GetOverlay"},
+		},
+		{
+			name:          "network error",
+			api:           &fakeOverlayAPI{err: errors.New("connection refused")},
+			importID:      "ov-1",
+			expectedError: "connection refused",
+			expectedCalls: []string{"GetOverlay"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &OverlayResource{client: tt.api}
+			resp := resource.ImportStateResponse{State: overlayState(t, r, nil)}
+			r.ImportState(context.Background(), resource.ImportStateRequest{ID: tt.importID}, &resp)
+
+			if !reflect.DeepEqual(tt.api.calls, tt.expectedCalls) {
+				t.Errorf("calls = %v, want %v", tt.api.calls, tt.expectedCalls)
+			}
+			if tt.expectedError != "" {
+				if !resp.Diagnostics.HasError() {
+					t.Fatalf("expected error containing %q", tt.expectedError)
+				}
+				if got := fmt.Sprint(resp.Diagnostics); !strings.Contains(got, tt.expectedError) {
+					t.Errorf("expected error containing %q, got %s", tt.expectedError, got)
+				}
+				if tt.expectedError != "Overlay Not Found" && strings.Contains(fmt.Sprint(resp.Diagnostics), "Overlay Not Found") {
+					t.Error("API failures must not be reported as not found")
+				}
+				return
+			}
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("unexpected error: %v", resp.Diagnostics)
+			}
+			var id types.String
+			resp.State.GetAttribute(context.Background(), path.Root("id"), &id)
+			if id.ValueString() != tt.expectedID {
+				t.Errorf("id = %q, want %q", id.ValueString(), tt.expectedID)
+			}
+		})
+	}
+}
+
+func TestOverlayResource_UpdateSkipsNoOp(t *testing.T) {
+	tests := []struct {
+		name     string
+		plan     func(*OverlayResourceModel)
+		expected []string
+	}{
+		{
+			name:     "nothing changed",
+			plan:     func(m *OverlayResourceModel) {},
+			expected: nil,
+		},
+		{
+			name: "data only reformatted",
+			plan: func(m *OverlayResourceModel) {
+				m.Data = NewNormalizedJSONValue("{\n  \"a\": 1\n}")
+			},
+			expected: nil,
+		},
+		{
+			name: "computed fields unknown",
+			plan: func(m *OverlayResourceModel) {
+				m.UpdatedAt = types.StringUnknown()
+				m.Version = types.StringUnknown()
+			},
+			expected: nil,
+		},
+		{
+			name: "name changed",
+			plan: func(m *OverlayResourceModel) {
+				m.Name = types.StringValue("renamed")
+			},
+			expected: []string{"UpdateOverlay"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := newFakeOverlayAPI(client.CubeOverlay{ID: "ov-1", Name: "sales", Data: []byte(`{"a":1}`)})
+			r := &OverlayResource{client: api}
+
+			current := testOverlayModel("ov-1")
+			planned := testOverlayModel("ov-1")
+			tt.plan(planned)
+
+			state := overlayState(t, r, current)
+			plan := overlayState(t, r, planned)
+			resp := resource.UpdateResponse{State: state}
+			r.Update(context.Background(), resource.UpdateRequest{
+				Plan:  tfsdk.Plan{Schema: plan.Schema, Raw: plan.Raw},
+				State: state,
+			}, &resp)
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("unexpected error: %v", resp.Diagnostics)
+			}
+
+			if !reflect.DeepEqual(api.calls, tt.expected) {
+				t.Errorf("calls = %v, want %v", api.calls, tt.expected)
+			}
+			if tt.expected == nil {
+				var got OverlayResourceModel
+				resp.State.Get(context.Background(), &got)
+				if got.Version != current.Version || got.UpdatedAt != current.UpdatedAt {
+					t.Errorf("version/updated_at = %s/%s, want the state's %s/%s",
+						got.Version, got.UpdatedAt, current.Version, current.UpdatedAt)
+				}
+			}
+		})
+	}
+}
+
+func TestOverlayResource_DependsOnOverlays(t *testing.T) {
+	m := newMockRevosServer(t)
+	h := newMockHarness(t, m)
+
+	null := tftypes.NewValue(h.resourceSchema("revos_overlay").ValueType(), nil)
+	base, diags := h.apply("revos_overlay", null, map[string]interface{}{
+		"name": "base",
+		"data": `{"cubes":[]}`,
+	})
+	requireNoErrors(t, "create base", diags)
+
+	tests := []struct {
+		name          string
+		dependsOn     []interface{}
+		expectedError string
+	}{
+		{name: "by ID", dependsOn: []interface{}{attrString(t, base, "id")}},
+		{name: "by name", dependsOn: []interface{}{"base"}},
+		{name: "missing", dependsOn: []interface{}{"base", "missing"}, expectedError: "Missing Overlay Dependency"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, diags := h.plan("revos_overlay", null, map[string]interface{}{
+				"name":                "dependent",
+				"data":                `{"views":[]}`,
+				"depends_on_overlays": tt.dependsOn,
+			})
+			if tt.expectedError == "" {
+				requireNoErrors(t, "plan", diags)
+				return
+			}
+			requireError(t, diags, tt.expectedError)
+			for _, d := range diags {
+				if d.Severity == tfprotov6.DiagnosticSeverityError && d.Attribute.String() != tftypes.NewAttributePath().WithAttributeName("depends_on_overlays").WithElementKeyInt(1).String() {
+					t.Errorf("error on %s, want depends_on_overlays[1]", d.Attribute)
+				}
+			}
+		})
+	}
+
+	t.Run("lookup failure only warns", func(t *testing.T) {
+		api := &fakeOverlayAPI{err: &client.APIError{StatusCode: 503, Body: "Service Unavailable"}}
+		r := &OverlayResource{client: api}
+		dependsOn, _ := types.ListValueFrom(context.Background(), types.StringType, []string{"base"})
+
+		var resp resource.ModifyPlanResponse
+		r.checkDependencies(context.Background(), dependsOn, &resp)
+		if resp.Diagnostics.HasError() || resp.Diagnostics.WarningsCount() != 1 {
+			t.Errorf("expected a single warning, got %v", resp.Diagnostics)
+		}
+	})
+}
+
+func TestOverlayResource_MissingOrganizationID(t *testing.T) {
+	m := newMockRevosServer(t)
+	h := newMockHarness(t, m)
+
+	config := map[string]interface{}{
+		"name": "legacy",
+		"data": `{"cubes":[]}`,
+	}
+
+	null := tftypes.NewValue(h.resourceSchema("revos_overlay").ValueType(), nil)
+	state, diags := h.apply("revos_overlay", null, config)
+	requireNoErrors(t, "create", diags)
+	if got := attrString(t, state, "organization_id"); got != "org-1" {
+		t.Fatalf("organization_id = %q, want org-1", got)
+	}
+
+	m.omitOrganizationID = true
+
+	for i := 0; i < 2; i++ {
+		state, diags = h.read("revos_overlay", state)
+		requireNoErrors(t, "refresh", diags)
+		if got := attrString(t, state, "organization_id"); got != "org-1" {
+			t.Errorf("refresh %d: organization_id = %q, want org-1", i+1, got)
+		}
+
+		planned, diags := h.plan("revos_overlay", state, config)
+		requireNoErrors(t, "plan", diags)
+		if !planned.Equal(state) {
+			t.Errorf("refresh %d: expected an empty plan, got %s", i+1, planned)
+		}
+	}
+
+	config["description"] = "updated"
+	state, diags = h.apply("revos_overlay", state, config)
+	requireNoErrors(t, "update", diags)
+	if got := attrString(t, state, "organization_id"); got != "org-1" {
+		t.Errorf("after update: organization_id = %q, want org-1", got)
+	}
+}
+
+func TestOverlayResource_Enabled(t *testing.T) {
+	m := newMockRevosServer(t)
+	h := newMockHarness(t, m)
+
+	config := map[string]interface{}{
+		"name": "staged",
+		"data": `{"cubes":[]}`,
+	}
+
+	null := tftypes.NewValue(h.resourceSchema("revos_overlay").ValueType(), nil)
+	state, diags := h.apply("revos_overlay", null, config)
+	requireNoErrors(t, "create", diags)
+	if !attrBool(t, state, "enabled") {
+		t.Error("enabled should default to true")
+	}
+	id := attrString(t, state, "id")
+
+	for _, enabled := range []bool{false, true} {
+		config["enabled"] = enabled
+		state, diags = h.apply("revos_overlay", state, config)
+		requireNoErrors(t, fmt.Sprintf("set enabled = %v", enabled), diags)
+		if got := m.overlayField(id, "enabled"); got != enabled {
+			t.Errorf("API enabled = %v, want %v", got, enabled)
+		}
+		if attrBool(t, state, "enabled") != enabled {
+			t.Errorf("state enabled = %v, want %v", !enabled, enabled)
+		}
+	}
+
+	// Deactivated outside of Terraform
+	m.setOverlayField(id, "enabled", false)
+	state, diags = h.read("revos_overlay", state)
+	requireNoErrors(t, "refresh", diags)
+	if attrBool(t, state, "enabled") {
+		t.Error("refresh should reflect the API's enabled = false")
+	}
+
+	// API versions without activation don't return it
+	m.setOverlayField(id, "enabled", nil)
+	state, diags = h.read("revos_overlay", state)
+	requireNoErrors(t, "refresh", diags)
+	if !attrBool(t, state, "enabled") {
+		t.Error("an overlay without enabled should be enabled")
+	}
+}
+
+func TestOverlayResource_APIWarnings(t *testing.T) {
+	m := newMockRevosServer(t)
+	h := newMockHarness(t, m)
+
+	m.warnings = []string{"deprecated join syntax: use one_to_many", "cube orders has no primary key"}
+
+	requireWarnings := func(step string, diags []*tfprotov6.Diagnostic) {
+		t.Helper()
+		var warnings []string
+		for _, d := range diags {
+			if d.Severity == tfprotov6.DiagnosticSeverityWarning && d.Summary == "Revos API Warning" {
+				warnings = append(warnings, d.Detail)
+			}
+		}
+		if fmt.Sprint(warnings) != fmt.Sprint(m.warnings) {
+			t.Errorf("%s: warnings = %q, want %q", step, warnings, m.warnings)
+		}
+	}
+
+	config := map[string]interface{}{
+		"name": "warned",
+		"data": `{"cubes":[]}`,
+	}
+	null := tftypes.NewValue(h.resourceSchema("revos_overlay").ValueType(), nil)
+	state, diags := h.apply("revos_overlay", null, config)
+	requireNoErrors(t, "create", diags)
+	requireWarnings("create", diags)
+
+	config["description"] = "updated"
+	state, diags = h.apply("revos_overlay", state, config)
+	requireNoErrors(t, "update", diags)
+	requireWarnings("update", diags)
+
+	// Warnings are advisories on the change, not part of the overlay
+	_, diags = h.read("revos_overlay", state)
+	requireNoErrors(t, "refresh", diags)
+	if len(diags) != 0 {
+		t.Errorf("refresh: unexpected diagnostics:\n%s", formatDiags(diags))
+	}
+}
+
+func TestOverlayResource_AdoptServerData(t *testing.T) {
+	const configured = `{"cubes":[{"name":"orders","sql_table":"orders"}]}`
+
+	tests := []struct {
+		name         string
+		serverData   interface{}
+		adopt        bool
+		expectChange bool
+	}{
+		{
+			name: "added keys without adopting",
+			serverData: map[string]interface{}{
+				"cubes":   []interface{}{map[string]interface{}{"name": "orders", "sql_table": "orders", "public": true}},
+				"version": 2,
+			},
+			expectChange: true,
+		},
+		{
+			name: "added keys",
+			serverData: map[string]interface{}{
+				"cubes":   []interface{}{map[string]interface{}{"name": "orders", "sql_table": "orders", "public": true}},
+				"version": 2,
+			},
+			adopt: true,
+		},
+		{
+			name: "changed configured value",
+			serverData: map[string]interface{}{
+				"cubes": []interface{}{map[string]interface{}{"name": "orders", "sql_table": "orders_v2", "public": true}},
+			},
+			adopt:        true,
+			expectChange: true,
+		},
+		{
+			name: "added array element",
+			serverData: map[string]interface{}{
+				"cubes": []interface{}{
+					map[string]interface{}{"name": "orders", "sql_table": "orders"},
+					map[string]interface{}{"name": "defaults"},
+				},
+			},
+			adopt:        true,
+			expectChange: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newMockRevosServer(t)
+			h := newTestHarness(t, map[string]interface{}{
+				"api_url":           m.URL,
+				"token":             "test-token",
+				"adopt_server_data": tt.adopt,
+			})
+
+			config := map[string]interface{}{"name": "augmented", "data": configured}
+			null := tftypes.NewValue(h.resourceSchema("revos_overlay").ValueType(), nil)
+			state, diags := h.apply("revos_overlay", null, config)
+			requireNoErrors(t, "create", diags)
+
+			// The API rewrites the data
+			m.setOverlayField(attrString(t, state, "id"), "data", tt.serverData)
+			state, diags = h.read("revos_overlay", state)
+			requireNoErrors(t, "refresh", diags)
+			if jsonEqual(attrString(t, state, "data"), configured) {
+				t.Fatalf("refresh: expected the server's data in state, got %s", attrString(t, state, "data"))
+			}
+
+			planned, diags := h.plan("revos_overlay", state, config)
+			requireNoErrors(t, "plan", diags)
+			if changed := !planned.Equal(state); changed != tt.expectChange {
+				t.Errorf("plan changed = %t, want %t:\n%s", changed, tt.expectChange, attrString(t, planned, "data"))
+			}
+			if tt.expectChange {
+				return
+			}
+
+			// Changing a configured value is still planned and applied
+			config["data"] = `{"cubes":[{"name":"orders","sql_table":"orders_v3"}]}`
+			planned, diags = h.plan("revos_overlay", state, config)
+			requireNoErrors(t, "plan change", diags)
+			if got := attrString(t, planned, "data"); got != config["data"] {
+				t.Errorf("planned data = %s, want %s", got, config["data"])
+			}
+			_, diags = h.apply("revos_overlay", state, config)
+			requireNoErrors(t, "update", diags)
+		})
+	}
+}
+
+func TestOverlayResource_DataInputTrimmed(t *testing.T) {
+	const (
+		clean = `{"cubes":[{"name":"orders","sql_table":"orders"}]}`
+		bom   = "\ufeff"
+	)
+
+	tests := []struct {
+		name   string
+		data   string
+		format string
+	}{
+		{name: "byte order mark", data: bom + clean},
+		{name: "trailing newline", data: clean + "\n"},
+		{name: "surrounding whitespace", data: "\n\t " + clean + " \r\n"},
+		{name: "byte order mark and newline", data: bom + "{\n  \"cubes\": [{\"name\": \"orders\", \"sql_table\": \"orders\"}]\n}\n"},
+		{name: "json5 with byte order mark", data: bom + "{\n  // orders\n  \"cubes\": [{\"name\": \"orders\", \"sql_table\": \"orders\",}],\n}\n", format: "json5"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newMockRevosServer(t)
+			h := newMockHarness(t, m)
+
+			config := map[string]interface{}{"name": "from-file", "data": tt.data}
+			if tt.format != "" {
+				config["data_format"] = tt.format
+			}
+			null := tftypes.NewValue(h.resourceSchema("revos_overlay").ValueType(), nil)
+			state, diags := h.apply("revos_overlay", null, config)
+			requireNoErrors(t, "create", diags)
+
+			sent, err := json.Marshal(m.overlayField(attrString(t, state, "id"), "data"))
+			if err != nil {
+				t.Fatalf("marshal: %s", err)
+			}
+			if !jsonEqual(string(sent), clean) {
+				t.Errorf("sent data = %s, want %s", sent, clean)
+			}
+			hash, _ := dataHash(clean)
+			if got := attrString(t, state, "data_hash"); got != hash {
+				t.Errorf("data_hash = %s, want the hash of the trimmed data %s", got, hash)
+			}
+
+			state, diags = h.read("revos_overlay", state)
+			requireNoErrors(t, "refresh", diags)
+			if got := attrString(t, state, "data"); got != tt.data {
+				t.Errorf("refresh: data = %q, want the configured value kept", got)
+			}
+
+			planned, diags := h.plan("revos_overlay", state, config)
+			requireNoErrors(t, "plan", diags)
+			if !planned.Equal(state) {
+				t.Errorf("expected no changes, got data %q", attrString(t, planned, "data"))
+			}
+		})
+	}
+}
+
+func TestOverlayResource_AdoptExisting(t *testing.T) {
+	const configured = `{"cubes":[{"name":"orders","sql_table":"orders_v2"}]}`
+
+	tests := []struct {
+		name        string
+		existing    bool
+		archived    bool
+		adopt       bool
+		expectAdopt bool
+		// The API rejects creating an overlay with the name of another one
+		expectError string
+	}{
+		{name: "no existing overlay", adopt: true},
+		{name: "existing overlay", existing: true, adopt: true, expectAdopt: true},
+		{name: "existing overlay without adopting", existing: true, expectError: "name already exists"},
+		{name: "archived overlay", existing: true, archived: true, adopt: true, expectError: "name already exists"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newMockRevosServer(t)
+			h := newMockHarness(t, m)
+			null := tftypes.NewValue(h.resourceSchema("revos_overlay").ValueType(), nil)
+
+			var existingID string
+			if tt.existing {
+				existing, diags := h.apply("revos_overlay", null, map[string]interface{}{
+					"name":        "orders",
+					"description": "created by hand",
+					"data":        `{"cubes":[{"name":"orders","sql_table":"orders"}]}`,
+				})
+				requireNoErrors(t, "create existing", diags)
+				existingID = attrString(t, existing, "id")
+				if tt.archived {
+					m.setOverlayField(existingID, "archived", true)
+				}
+			}
+
+			state, diags := h.apply("revos_overlay", null, map[string]interface{}{
+				"name":           "orders",
+				"data":           configured,
+				"adopt_existing": tt.adopt,
+			})
+			if tt.expectError != "" {
+				requireError(t, diags, tt.expectError)
+				if got := m.requestCount("PATCH", "/cube-overlays/"+existingID); got != 0 {
+					t.Errorf("PATCH requests = %d, want the existing overlay left alone", got)
+				}
+				return
+			}
+			requireNoErrors(t, "create", diags)
+			id := attrString(t, state, "id")
+
+			if adopted := id == existingID; adopted != tt.expectAdopt {
+				t.Fatalf("adopted = %t, want %t", adopted, tt.expectAdopt)
+			}
+			if got := m.overlayCount(); got != 1 {
+				t.Errorf("overlays = %d, want 1", got)
+			}
+
+			// The adopted overlay is converged to the configuration
+			sent, err := json.Marshal(m.overlayField(id, "data"))
+			if err != nil {
+				t.Fatalf("marshal: %s", err)
+			}
+			if !jsonEqual(string(sent), configured) {
+				t.Errorf("data = %s, want %s", sent, configured)
+			}
+			if got := m.overlayField(id, "description"); got != "" {
+				t.Errorf("description = %v, want it cleared", got)
+			}
+			if got := attrString(t, state, "version"); got == "" {
+				t.Error("expected the version to be set")
+			}
+
+			state, diags = h.read("revos_overlay", state)
+			requireNoErrors(t, "refresh", diags)
+			planned, diags := h.plan("revos_overlay", state, map[string]interface{}{
+				"name":           "orders",
+				"data":           configured,
+				"adopt_existing": tt.adopt,
+			})
+			requireNoErrors(t, "plan", diags)
+			if !planned.Equal(state) {
+				t.Error("expected no changes after create")
+			}
+		})
+	}
+}
+
+func TestOverlayResource_APIValidationErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		errors   []map[string]string
+		expected []string
+	}{
+		{
+			name: "per field",
+			errors: []map[string]string{
+				{"path": "cubes.0.name", "message": "required"},
+				{"path": "data.cubes[1].measures.count/all", "message": "unknown type"},
+				{"path": "name", "message": "too long"},
+				{"message": "definition is empty"},
+			},
+			expected: []string{
+				"data: The API rejected the value at /cubes/0/name in data: required",
+				"data: The API rejected the value at /cubes/1/measures/count~1all in data: unknown type",
+				"name: The API rejected name: too long",
+				"data: The API rejected data: definition is empty",
+			},
+		},
+		{
+			name:     "unstructured",
+			errors:   []map[string]string{},
+			expected: []string{`: Unable to %s overlay, got error: API error 422: {"errors":[]}`},
+		},
+	}
+
+	for _, tt := range tests {
+		for _, action := range []string{"create", "update"} {
+			t.Run(tt.name+" "+action, func(t *testing.T) {
+				m := newMockRevosServer(t)
+				h := newMockHarness(t, m)
+
+				config := map[string]interface{}{"name": "rejected", "data": `{"cubes":[{"name":"orders"}]}`}
+				state := tftypes.NewValue(h.resourceSchema("revos_overlay").ValueType(), nil)
+				if action == "update" {
+					var diags []*tfprotov6.Diagnostic
+					state, diags = h.apply("revos_overlay", state, config)
+					requireNoErrors(t, "create", diags)
+					config["data"] = `{"cubes":[{"name":"orders"},{"name":"customers"}]}`
+				}
+
+				m.validationErrors = tt.errors
+				_, diags := h.apply("revos_overlay", state, config)
+
+				var got []string
+				for _, d := range diags {
+					if d.Severity == tfprotov6.DiagnosticSeverityError {
+						got = append(got, diagAttribute(d)+": "+strings.Split(d.Detail, " (request ID:")[0])
+					}
+				}
+				expected := make([]string, len(tt.expected))
+				for i, e := range tt.expected {
+					if strings.Contains(e, "%s") {
+						e = fmt.Sprintf(e, action)
+					}
+					expected[i] = e
+				}
+				if fmt.Sprint(got) != fmt.Sprint(expected) {
+					t.Errorf("errors = %q, want %q", got, expected)
+				}
+			})
+		}
+	}
+}
+
+func TestCanonicalJSON_SQLOperators(t *testing.T) {
+	input := `{"sql": "SELECT * FROM orders WHERE amount > 10 AND status <> 'void' & 1"}`
+	expected := `{"sql":"SELECT * FROM orders WHERE amount > 10 AND status <> 'void' & 1"}`
+
+	got, err := canonicalJSON(input)
+	if err != nil {
+		t.Fatalf("canonicalJSON: %s", err)
+	}
+	if string(got) != expected {
+		t.Errorf("canonicalJSON(%s) = %s, want %s", input, got, expected)
+	}
+}
+
+func TestCanonicalJSON_Numbers(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{name: "exponent", input: `{"b": 1e3, "a": 1E-2}`, expected: `{"a":1E-2,"b":1e3}`},
+		{name: "insignificant zeros", input: `{"num": 1.50, "zero": 0.0}`, expected: `{"num":1.50,"zero":0.0}`},
+		{name: "large integer", input: `{"id": 12345678901234567890123}`, expected: `{"id":12345678901234567890123}`},
+		{name: "negative", input: `[-0, -1.5e+10]`, expected: `[-0,-1.5e+10]`},
+		{name: "nested", input: "{\n  \"cubes\": [{\"refresh\": 3.600e3, \"name\": \"orders\"}]\n}", expected: `{"cubes":[{"name":"orders","refresh":3.600e3}]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := canonicalJSON(tt.input)
+			if err != nil {
+				t.Fatalf("canonicalJSON: %s", err)
+			}
+			if string(got) != tt.expected {
+				t.Errorf("canonicalJSON(%s) = %s, want %s", tt.input, got, tt.expected)
+			}
+		})
+	}
+
+	// Numbers written differently hash differently, as the hash is of the
+	// definition as written, but are still semantically equal
+	a, _ := dataHash(`{"num":1e3}`)
+	b, _ := dataHash(`{"num":1000}`)
+	if a == b {
+		t.Error("expected 1e3 and 1000 to hash differently")
+	}
+	if !jsonEqual(`{"num":1e3}`, `{"num":1000}`) {
+		t.Error("expected 1e3 and 1000 to be equal")
+	}
+}
+
+func TestDefinitionSizeValue_SQLOperators(t *testing.T) {
+	// The size is of the data as sent, without escaping <, > and &
+	data := `{"sql":"SELECT 1 WHERE a > b AND c < d OR e & f"}`
+	if got := definitionSizeValue(types.StringValue(data)); got.ValueInt64() != int64(len(data)) {
+		t.Errorf("definitionSizeValue(%s) = %s, want %d", data, got, len(data))
+	}
+}
+
+func TestOverlayResource_DefinitionSize(t *testing.T) {
+	m := newMockRevosServer(t)
+	h := newMockHarness(t, m)
+
+	null := tftypes.NewValue(h.resourceSchema("revos_overlay").ValueType(), nil)
+	config := map[string]interface{}{
+		"name": "sized",
+		"data": "{\n  \"b\": {\"c\": \"${table}\"},\n  \"a\": 1\n}",
+		"data_vars": map[string]interface{}{"table": "orders"},
+	}
+	canonical, err := canonicalJSON(`{"a":1,"b":{"c":"orders"}}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	want := int64(len(canonical))
+
+	planned, diags := h.plan("revos_overlay", null, config)
+	requireNoErrors(t, "plan", diags)
+	if got := attrInt64(t, planned, "definition_size_bytes"); got != want {
+		t.Errorf("planned definition_size_bytes = %d, want %d", got, want)
+	}
+
+	state, diags := h.apply("revos_overlay", null, config)
+	requireNoErrors(t, "create", diags)
+	state, diags = h.read("revos_overlay", state)
+	requireNoErrors(t, "refresh", diags)
+	if got := attrInt64(t, state, "definition_size_bytes"); got != want {
+		t.Errorf("definition_size_bytes after refresh = %d, want %d", got, want)
+	}
+
+	config["data_vars"] = map[string]interface{}{"table": "orders_archive"}
+	state, diags = h.apply("revos_overlay", state, config)
+	requireNoErrors(t, "update", diags)
+	if got := attrInt64(t, state, "definition_size_bytes"); got != want+int64(len("_archive")) {
+		t.Errorf("definition_size_bytes after update = %d, want %d", got, want+int64(len("_archive")))
+	}
+
+	imported, diags := h.importState("revos_overlay", attrString(t, state, "id"))
+	requireNoErrors(t, "import", diags)
+	if got := attrInt64(t, imported, "definition_size_bytes"); got != want+int64(len("_archive")) {
+		t.Errorf("definition_size_bytes after import = %d, want %d", got, want+int64(len("_archive")))
+	}
+}
+
+func TestOverlayResource_Type(t *testing.T) {
+	m := newMockRevosServer(t)
+	h := newMockHarness(t, m)
+	null := tftypes.NewValue(h.resourceSchema("revos_overlay").ValueType(), nil)
+
+	for _, overlayType := range []string{"", client.OverlayTypeCube, client.OverlayTypeView, client.OverlayTypeDashboard} {
+		t.Run("type "+overlayType, func(t *testing.T) {
+			config := map[string]interface{}{
+				"name": "typed-" + overlayType,
+				"data": `{"cubes":[]}`,
+			}
+			want := client.OverlayTypeCube
+			if overlayType != "" {
+				config["type"] = overlayType
+				want = overlayType
+			}
+
+			state, diags := h.apply("revos_overlay", null, config)
+			requireNoErrors(t, "create", diags)
+			id := attrString(t, state, "id")
+			if got := m.overlayField(id, "type"); got != want {
+				t.Errorf("sent type = %v, want %s", got, want)
+			}
+			state, diags = h.read("revos_overlay", state)
+			requireNoErrors(t, "refresh", diags)
+			if got := attrString(t, state, "type"); got != want {
+				t.Errorf("type = %s, want %s", got, want)
+			}
+		})
+	}
+
+	_, diags := h.plan("revos_overlay", null, map[string]interface{}{
+		"name": "typed",
+		"type": "report",
+		"data": `{"cubes":[]}`,
+	})
+	requireError(t, diags, "Invalid Overlay Type")
+
+	// Overlays of API versions without types are cube overlays
+	config := map[string]interface{}{
+		"name": "untyped",
+		"data": `{"cubes":[]}`,
+	}
+	state, diags := h.apply("revos_overlay", null, config)
+	requireNoErrors(t, "create untyped", diags)
+	m.setOverlayField(attrString(t, state, "id"), "type", nil)
+	state, diags = h.read("revos_overlay", state)
+	requireNoErrors(t, "refresh untyped", diags)
+	if got := attrString(t, state, "type"); got != client.OverlayTypeCube {
+		t.Errorf("type without one from the API = %s, want cube", got)
+	}
+	planned, replace, diags := h.planChange("revos_overlay", state, config)
+	requireNoErrors(t, "plan untyped", diags)
+	if !planned.Equal(state) || len(replace) != 0 {
+		t.Errorf("expected an empty plan, got %s (replacing %v)", planned, replace)
+	}
+
+	// Changing the type replaces the overlay
+	config["type"] = client.OverlayTypeView
+	_, replace, diags = h.planChange("revos_overlay", state, config)
+	requireNoErrors(t, "plan type change", diags)
+	if len(replace) != 1 || !replace[0].Equal(tftypes.NewAttributePath().WithAttributeName("type")) {
+		t.Errorf("requires replace = %v, want type", replace)
+	}
+}
+
+func TestOverlayResource_DataBase64(t *testing.T) {
+	m := newMockRevosServer(t)
+	h := newMockHarness(t, m)
+	null := tftypes.NewValue(h.resourceSchema("revos_overlay").ValueType(), nil)
+
+	const definition = `{"cubes":[{"name":"orders","sql_table":"orders"}]}`
+	encoded := base64.StdEncoding.EncodeToString([]byte(definition))
+
+	tests := []struct {
+		name          string
+		config        map[string]interface{}
+		expectedError string
+	}{
+		{name: "malformed", config: map[string]interface{}{"data_base64": "not base64!"}, expectedError: "Invalid Base64 in data_base64"},
+		{name: "not JSON", config: map[string]interface{}{"data_base64": base64.StdEncoding.EncodeToString([]byte(`{"cubes":`))}, expectedError: "Invalid JSON in data_base64"},
+		{name: "not an object", config: map[string]interface{}{"data_base64": base64.StdEncoding.EncodeToString([]byte(`[]`))}, expectedError: "data must be a JSON object"},
+		{name: "both", config: map[string]interface{}{"data": definition, "data_base64": encoded}, expectedError: "Conflicting Data Attributes"},
+		{name: "neither", config: map[string]interface{}{}, expectedError: "Missing Data"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config["name"] = "encoded"
+			_, diags := h.plan("revos_overlay", null, tt.config)
+			requireError(t, diags, tt.expectedError)
+		})
+	}
+
+	// Line breaks and missing padding, as CI variables may have, are accepted
+	wrapped := strings.TrimRight(encoded[:20]+"\n"+encoded[20:], "=")
+	config := map[string]interface{}{
+		"name":        "encoded",
+		"data_base64": wrapped,
+	}
+	planned, diags := h.plan("revos_overlay", null, config)
+	requireNoErrors(t, "plan", diags)
+	if got := attrString(t, planned, "data"); got != definition {
+		t.Errorf("planned data = %s, want %s", got, definition)
+	}
+
+	state, diags := h.apply("revos_overlay", null, config)
+	requireNoErrors(t, "create", diags)
+	id := attrString(t, state, "id")
+	if got, _ := json.Marshal(m.overlayField(id, "data")); !jsonEqual(string(got), definition) {
+		t.Errorf("sent data = %s, want %s", got, definition)
+	}
+
+	state, diags = h.read("revos_overlay", state)
+	requireNoErrors(t, "refresh", diags)
+	planned, diags = h.plan("revos_overlay", state, config)
+	requireNoErrors(t, "plan unchanged", diags)
+	if !planned.Equal(state) {
+		t.Errorf("expected an empty plan, got %s", planned)
+	}
+
+	// Changes made outside of Terraform are compared against the decoded data
+	m.setOverlayField(id, "data", map[string]interface{}{
+		"cubes": []interface{}{map[string]interface{}{"name": "orders", "sql_table": "orders_v2"}},
+	})
+	state, diags = h.read("revos_overlay", state)
+	requireNoErrors(t, "refresh drifted", diags)
+	planned, diags = h.plan("revos_overlay", state, config)
+	requireNoErrors(t, "plan drifted", diags)
+	if got := attrString(t, planned, "data"); got != definition {
+		t.Errorf("planned data after drift = %s, want %s", got, definition)
+	}
+	if attrValue(t, planned, "version").IsKnown() {
+		t.Error("expected the version to be planned unknown for the update")
+	}
+	state, diags = h.apply("revos_overlay", state, config)
+	requireNoErrors(t, "update", diags)
+	if got, _ := json.Marshal(m.overlayField(id, "data")); !jsonEqual(string(got), definition) {
+		t.Errorf("data after update = %s, want %s", got, definition)
+	}
+
+	// Switching to data with the same definition changes nothing
+	delete(config, "data_base64")
+	config["data"] = definition
+	planned, diags = h.plan("revos_overlay", state, config)
+	requireNoErrors(t, "plan switch", diags)
+	if !jsonEqual(attrString(t, planned, "data"), definition) {
+		t.Errorf("planned data after switching = %s, want %s", attrString(t, planned, "data"), definition)
+	}
+}
+
+func TestOverlayResource_PlanPreservesComputedAttributes(t *testing.T) {
+	m := newMockRevosServer(t)
+	h := newMockHarness(t, m)
+
+	null := tftypes.NewValue(h.resourceSchema("revos_overlay").ValueType(), nil)
+	config := map[string]interface{}{
+		"name": "preserved",
+		"data": `{"cubes":[],"title":"before"}`,
+	}
+	state, diags := h.apply("revos_overlay", null, config)
+	requireNoErrors(t, "create", diags)
+
+	// Changing the overlay keeps the immutable attributes and leaves the
+	// mutable ones to the API
+	config["data"] = `{"cubes":[],"title":"after"}`
+	planned, diags := h.plan("revos_overlay", state, config)
+	requireNoErrors(t, "plan change", diags)
+	for _, name := range immutableComputedAttributes {
+		if got, want := attrValue(t, planned, name), attrValue(t, state, name); !got.Equal(want) {
+			t.Errorf("planned %s = %s, want %s from state", name, got, want)
+		}
+	}
+	for _, name := range []string{"updated_at", "version"} {
+		if attrValue(t, planned, name).IsKnown() {
+			t.Errorf("planned %s = %s, want unknown", name, attrValue(t, planned, name))
+		}
+	}
+	if got := attrString(t, planned, "data"); got != config["data"] {
+		t.Errorf("planned data = %s, want %s", got, config["data"])
+	}
+
+	// Only reformatting data and changing deletion_mode keeps them all
+	config["data"] = "{\n  \"title\": \"before\",\n  \"cubes\": []\n}"
+	config["deletion_mode"] = "archive"
+	planned, diags = h.plan("revos_overlay", state, config)
+	requireNoErrors(t, "plan unchanged", diags)
+	for _, name := range append(append([]string{}, immutableComputedAttributes...), mutableComputedAttributes...) {
+		if got, want := attrValue(t, planned, name), attrValue(t, state, name); !got.Equal(want) {
+			t.Errorf("planned %s = %s, want %s from state", name, got, want)
+		}
+	}
+	if got := attrString(t, planned, "deletion_mode"); got != "archive" {
+		t.Errorf("planned deletion_mode = %s, want archive", got)
+	}
+
+	// Applying a change is consistent with the plan
+	config["data"] = `{"cubes":[],"title":"after"}`
+	_, diags = h.apply("revos_overlay", state, config)
+	requireNoErrors(t, "update", diags)
+}
+
+func TestOverlayResource_ComputedAttributesClassified(t *testing.T) {
+	// Computed attributes planned by their own plan modifiers or derived
+	// from other attributes in ModifyPlan
+	plannedElsewhere := map[string]bool{"id": true, "tags_all": true, "data_hash": true, "definition_size_bytes": true, "cube_names": true}
+	classified := map[string]bool{}
+	for _, name := range append(append([]string{}, immutableComputedAttributes...), mutableComputedAttributes...) {
+		classified[name] = true
+	}
+
+	r := NewOverlayResource()
+	var resp resource.SchemaResponse
+	r.Schema(context.Background(), resource.SchemaRequest{}, &resp)
+	for name, attribute := range resp.Schema.Attributes {
+		if !attribute.IsComputed() || attribute.IsOptional() {
+			continue
+		}
+		if !classified[name] && !plannedElsewhere[name] {
+			t.Errorf("computed attribute %s is in neither immutableComputedAttributes nor mutableComputedAttributes", name)
+		}
+	}
+	for name := range classified {
+		if _, ok := resp.Schema.Attributes[name]; !ok {
+			t.Errorf("%s is not an attribute", name)
+		}
+	}
+}
+
+func TestOverlayResource_ConditionalRead(t *testing.T) {
+	m := newMockRevosServer(t)
+	h := newMockHarness(t, m)
+
+	null := tftypes.NewValue(h.resourceSchema("revos_overlay").ValueType(), nil)
+	state, diags := h.apply("revos_overlay", null, map[string]interface{}{
+		"name": "conditional",
+		"data": `{"cubes":[{"name":"orders"}]}`,
+	})
+	requireNoErrors(t, "create", diags)
+	id := attrString(t, state, "id")
+
+	// Change the stored data without a new version, so only a refresh that
+	// decodes the response would see it
+	m.mu.Lock()
+	m.overlays[id]["data"] = map[string]interface{}{"cubes": []interface{}{}}
+	m.mu.Unlock()
+
+	refreshed, diags := h.read("revos_overlay", state)
+	requireNoErrors(t, "refresh", diags)
+	if m.notModified != 1 {
+		t.Errorf("304 responses = %d, want 1", m.notModified)
+	}
+	if !refreshed.Equal(state) {
+		t.Errorf("expected the state to be kept on a 304, got %s", refreshed)
+	}
+
+	// A new version is read in full
+	m.setOverlayField(id, "description", "changed")
+	refreshed, diags = h.read("revos_overlay", state)
+	requireNoErrors(t, "refresh modified", diags)
+	if got := attrString(t, refreshed, "description"); got != "changed" {
+		t.Errorf("description = %q after refresh, want changed", got)
+	}
+	if got := attrString(t, refreshed, "version"); got == attrString(t, state, "version") {
+		t.Errorf("version = %s after refresh, want a new one", got)
+	}
+}
+
+func TestDeepEqualFold(t *testing.T) {
+	a := map[string]interface{}{"relationship": "one_to_many", "sql": "SELECT 1"}
+	b := map[string]interface{}{"relationship": "ONE_TO_MANY", "sql": "select 1"}
+
+	if deepEqualFold(a, b, false) {
+		t.Error("expected values differing in case to differ without foldCase")
+	}
+	if !deepEqualFold(a, b, true) {
+		t.Error("expected values differing in case to be equal with foldCase")
+	}
+	if deepEqualFold(a, map[string]interface{}{"relationship": "many_to_one", "sql": "SELECT 1"}, true) {
+		t.Error("expected different values to differ with foldCase")
+	}
+	// Keys are compared exactly
+	if deepEqualFold(a, map[string]interface{}{"Relationship": "one_to_many", "sql": "SELECT 1"}, true) {
+		t.Error("expected keys differing in case to differ with foldCase")
+	}
+}
+
+func TestOverlayResource_CaseInsensitiveDataValues(t *testing.T) {
+	for _, foldCase := range []bool{false, true} {
+		t.Run(map[bool]string{false: "case sensitive", true: "case insensitive"}[foldCase], func(t *testing.T) {
+			m := newMockRevosServer(t)
+			h := newMockHarness(t, m)
+
+			data := `{"cubes":[{"name":"orders","joins":[{"name":"users","relationship":"one_to_many"}]}]}`
+			config := map[string]interface{}{
+				"name": "normalized",
+				"data": data,
+			}
+			if foldCase {
+				config["case_insensitive_data_values"] = true
+			}
+			null := tftypes.NewValue(h.resourceSchema("revos_overlay").ValueType(), nil)
+			state, diags := h.apply("revos_overlay", null, config)
+			requireNoErrors(t, "create", diags)
+
+			// The API upper-cases enum values
+			m.setOverlayField(attrString(t, state, "id"), "data", map[string]interface{}{
+				"cubes": []interface{}{map[string]interface{}{
+					"name":  "orders",
+					"joins": []interface{}{map[string]interface{}{"name": "users", "relationship": "ONE_TO_MANY"}},
+				}},
+			})
+
+			state, diags = h.read("revos_overlay", state)
+			requireNoErrors(t, "refresh", diags)
+			planned, diags := h.plan("revos_overlay", state, config)
+			requireNoErrors(t, "plan", diags)
+
+			if foldCase {
+				if got := attrString(t, state, "data"); got != data {
+					t.Errorf("refresh: data = %s, want the configured %s", got, data)
+				}
+				if !planned.Equal(state) {
+					t.Errorf("expected an empty plan, got %s", planned)
+				}
+			}
+			if !foldCase && planned.Equal(state) {
+				t.Error("expected the upper-cased value to show as a change")
+			}
+		})
+	}
+}
+
+func TestOverlayResource_Locked(t *testing.T) {
+	m := newMockRevosServer(t)
+	h := newMockHarness(t, m)
+
+	config := map[string]interface{}{
+		"name":   "locked",
+		"data":   `{"cubes":[]}`,
+		"locked": true,
+	}
+	null := tftypes.NewValue(h.resourceSchema("revos_overlay").ValueType(), nil)
+	state, diags := h.apply("revos_overlay", null, config)
+	requireNoErrors(t, "create", diags)
+	id := attrString(t, state, "id")
+	overlayPath := "/cube-overlays/" + id
+	if got := m.overlayField(id, "locked"); got != true {
+		t.Fatalf("create: server locked = %v, want true", got)
+	}
+	if !attrBool(t, state, "locked") {
+		t.Error("create: expected locked in state")
+	}
+
+	state, diags = h.read("revos_overlay", state)
+	requireNoErrors(t, "read", diags)
+	planned, diags := h.plan("revos_overlay", state, config)
+	requireNoErrors(t, "plan", diags)
+	if !planned.Equal(state) {
+		t.Errorf("plan: expected no changes, got %s", planned)
+	}
+
+	// Updating a locked overlay keeps it locked, at the version the lock
+	// left it at
+	config["data"] = `{"cubes":[{"name":"orders"}]}`
+	state, diags = h.apply("revos_overlay", state, config)
+	requireNoErrors(t, "update while locked", diags)
+	if got := m.requestCount("POST", overlayPath+"/lock"); got != 1 {
+		t.Errorf("update while locked: lock requests = %d, want 1", got)
+	}
+	if got := m.overlayField(id, "locked"); got != true {
+		t.Errorf("update while locked: server locked = %v, want true", got)
+	}
+
+	// Unlocking happens before the update it comes with
+	config["locked"] = false
+	config["data"] = `{"cubes":[{"name":"users"}]}`
+	m.mu.Lock()
+	m.requests = nil
+	m.mu.Unlock()
+	state, diags = h.apply("revos_overlay", state, config)
+	requireNoErrors(t, "unlock", diags)
+	m.mu.Lock()
+	requests := strings.Join(m.requests, ", ")
+	m.mu.Unlock()
+	if want := "POST " + overlayPath + "/unlock, PATCH " + overlayPath; !strings.Contains(requests, want) {
+		t.Errorf("unlock: requests = %s, want them to include %s", requests, want)
+	}
+	if got := m.overlayField(id, "locked"); got != false {
+		t.Errorf("unlock: server locked = %v, want false", got)
+	}
+
+	// Locking on its own doesn't write the overlay
+	config["locked"] = true
+	patches := m.requestCount("PATCH", overlayPath)
+	state, diags = h.apply("revos_overlay", state, config)
+	requireNoErrors(t, "lock", diags)
+	if got := m.requestCount("PATCH", overlayPath); got != patches {
+		t.Errorf("lock: PATCH requests = %d, want %d", got, patches)
+	}
+	if got := m.overlayField(id, "locked"); got != true {
+		t.Errorf("lock: server locked = %v, want true", got)
+	}
+	planned, diags = h.plan("revos_overlay", state, config)
+	requireNoErrors(t, "plan after lock", diags)
+	if !planned.Equal(state) {
+		t.Errorf("plan after lock: expected no changes, got %s", planned)
+	}
+
+	// Unlocking in the UI shows up as drift
+	m.setOverlayField(id, "locked", false)
+	state, diags = h.read("revos_overlay", state)
+	requireNoErrors(t, "read after unlock", diags)
+	if attrBool(t, state, "locked") {
+		t.Error("read after unlock: expected locked = false")
+	}
+	planned, diags = h.plan("revos_overlay", state, config)
+	requireNoErrors(t, "plan after unlock", diags)
+	if planned.Equal(state) {
+		t.Error("plan after unlock: expected the lock to be planned")
+	}
+	_, diags = h.apply("revos_overlay", state, config)
+	requireNoErrors(t, "relock", diags)
+	if got := m.overlayField(id, "locked"); got != true {
+		t.Errorf("relock: server locked = %v, want true", got)
+	}
+}
+
+func TestOverlayResource_DeletionProtection(t *testing.T) {
+	t.Run("unprotected", func(t *testing.T) {
+		m := newMockRevosServer(t)
+		h := newMockHarness(t, m)
+
+		null := tftypes.NewValue(h.resourceSchema("revos_overlay").ValueType(), nil)
+		state, diags := h.apply("revos_overlay", null, map[string]interface{}{
+			"name": "disposable",
+			"data": `{"a":1}`,
+		})
+		requireNoErrors(t, "create", diags)
+		if attrBool(t, state, "deletion_protection") {
+			t.Error("expected deletion_protection to default to false")
+		}
+
+		requireNoErrors(t, "destroy", h.destroy("revos_overlay", state))
+		if m.overlayCount() != 0 {
+			t.Error("expected the overlay to be deleted")
+		}
+	})
+
+	t.Run("protected", func(t *testing.T) {
+		m := newMockRevosServer(t)
+		h := newMockHarness(t, m)
+
+		config := map[string]interface{}{
+			"name":                "critical",
+			"data":                `{"a":1}`,
+			"deletion_protection": true,
+		}
+		null := tftypes.NewValue(h.resourceSchema("revos_overlay").ValueType(), nil)
+		state, diags := h.apply("revos_overlay", null, config)
+		requireNoErrors(t, "create", diags)
+
+		requireError(t, h.destroy("revos_overlay", state), "Deletion Protection Enabled")
+		if got := m.requestCount("DELETE", "/cube-overlays/ov-1"); got != 0 {
+			t.Errorf("DELETE called %d times, want 0", got)
+		}
+		if m.overlayCount() != 1 {
+			t.Fatal("expected the overlay to be kept")
+		}
+
+		// Lifting the protection doesn't update the overlay, and then allows
+		// destroying it
+		config["deletion_protection"] = false
+		state, diags = h.apply("revos_overlay", state, config)
+		requireNoErrors(t, "disable protection", diags)
+		if got := m.requestCount("PATCH", "/cube-overlays/ov-1"); got != 0 {
+			t.Errorf("PATCH called %d times, want 0", got)
+		}
+		requireNoErrors(t, "destroy", h.destroy("revos_overlay", state))
+		if m.overlayCount() != 0 {
+			t.Error("expected the overlay to be deleted")
+		}
+	})
+}
+
+func TestOverlayResource_ImportKeepsServerData(t *testing.T) {
+	serverData := `{
+  "views": [],
+  "cubes": [ { "name": "orders", "sql": "SELECT 1" } ]
+}`
+	api := newFakeOverlayAPI(client.CubeOverlay{ID: "ov-1", Name: "sales", Data: []byte(serverData)})
+	r := &OverlayResource{client: api}
+	resp := resource.ImportStateResponse{State: overlayState(t, r, nil)}
+	r.ImportState(context.Background(), resource.ImportStateRequest{ID: "ov-1"}, &resp)
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected error: %v", resp.Diagnostics)
+	}
+
+	var data NormalizedJSON
+	if diags := resp.State.GetAttribute(context.Background(), path.Root("data"), &data); diags.HasError() {
+		t.Fatalf("data: %v", diags)
+	}
+	if data.ValueString() != serverData {
+		t.Errorf("data = %q, want the server bytes %q", data.ValueString(), serverData)
+	}
+}
+
+func TestDeepMerge(t *testing.T) {
+	tests := []struct {
+		name     string
+		dst      string
+		src      string
+		expected string
+	}{
+		{
+			name:     "adds keys",
+			dst:      `{"a":1}`,
+			src:      `{"b":2}`,
+			expected: `{"a":1,"b":2}`,
+		},
+		{
+			name:     "nested objects are merged",
+			dst:      `{"cubes":{"orders":{"sql":"SELECT 1","measures":{"count":{"type":"count"}}},"users":{"sql":"SELECT 2"}}}`,
+			src:      `{"cubes":{"orders":{"measures":{"total":{"type":"sum"}}}}}`,
+			expected: `{"cubes":{"orders":{"sql":"SELECT 1","measures":{"count":{"type":"count"},"total":{"type":"sum"}}},"users":{"sql":"SELECT 2"}}}`,
+		},
+		{
+			name:     "arrays are replaced",
+			dst:      `{"cubes":[{"name":"orders"},{"name":"users"}],"views":[]}`,
+			src:      `{"cubes":[{"name":"payments"}]}`,
+			expected: `{"cubes":[{"name":"payments"}],"views":[]}`,
+		},
+		{
+			name:     "scalars and types are replaced",
+			dst:      `{"a":1,"b":{"c":1},"d":"x"}`,
+			src:      `{"a":"one","b":[1],"d":null}`,
+			expected: `{"a":"one","b":[1],"d":null}`,
+		},
+		{
+			name:     "non-object source replaces",
+			dst:      `{"a":1}`,
+			src:      `[1,2]`,
+			expected: `[1,2]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dst, err := decodeJSON(tt.dst)
+			if err != nil {
+				t.Fatal(err)
+			}
+			src, err := decodeJSON(tt.src)
+			if err != nil {
+				t.Fatal(err)
+			}
+			merged, err := json.Marshal(deepMerge(dst, src))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !jsonEqual(string(merged), tt.expected) {
+				t.Errorf("deepMerge = %s, want %s", merged, tt.expected)
+			}
+			if got, _ := json.Marshal(dst); !jsonEqual(string(got), tt.dst) {
+				t.Errorf("dst was modified to %s", got)
+			}
+		})
+	}
+}
+
+func TestOverlayResource_MergeStrategy(t *testing.T) {
+	for _, strategy := range []string{mergeStrategyReplace, mergeStrategyMerge} {
+		t.Run(strategy, func(t *testing.T) {
+			m := newMockRevosServer(t)
+			h := newMockHarness(t, m)
+
+			config := map[string]interface{}{
+				"name":           "shared",
+				"data":           `{"cubes":{"orders":{"sql":"SELECT 1"}}}`,
+				"merge_strategy": strategy,
+			}
+			null := tftypes.NewValue(h.resourceSchema("revos_overlay").ValueType(), nil)
+			state, diags := h.apply("revos_overlay", null, config)
+			requireNoErrors(t, "create", diags)
+			id := attrString(t, state, "id")
+
+			// Another team adds a cube and a view to the shared overlay
+			m.setOverlayField(id, "data", map[string]interface{}{
+				"cubes": map[string]interface{}{
+					"orders": map[string]interface{}{"sql": "SELECT 1"},
+					"users":  map[string]interface{}{"sql": "SELECT 2"},
+				},
+				"views": []interface{}{"sales"},
+			})
+
+			state, diags = h.read("revos_overlay", state)
+			requireNoErrors(t, "read", diags)
+			planned, diags := h.plan("revos_overlay", state, config)
+			requireNoErrors(t, "plan", diags)
+			if drift := !planned.Equal(state); drift != (strategy == mergeStrategyReplace) {
+				t.Errorf("plan after the other team's change: drift = %t", drift)
+			}
+
+			config["data"] = `{"cubes":{"orders":{"sql":"SELECT 3"}}}`
+			_, diags = h.apply("revos_overlay", state, config)
+			requireNoErrors(t, "update", diags)
+
+			got, _ := json.Marshal(m.overlayField(id, "data"))
+			expected := `{"cubes":{"orders":{"sql":"SELECT 3"}}}`
+			if strategy == mergeStrategyMerge {
+				expected = `{"cubes":{"orders":{"sql":"SELECT 3"},"users":{"sql":"SELECT 2"}},"views":["sales"]}`
+			}
+			if !jsonEqual(string(got), expected) {
+				t.Errorf("server data = %s, want %s", got, expected)
+			}
+		})
+	}
+
+	t.Run("invalid", func(t *testing.T) {
+		m := newMockRevosServer(t)
+		h := newMockHarness(t, m)
+
+		null := tftypes.NewValue(h.resourceSchema("revos_overlay").ValueType(), nil)
+		_, diags := h.plan("revos_overlay", null, map[string]interface{}{
+			"name":           "shared",
+			"data":           `{}`,
+			"merge_strategy": "patch",
+		})
+		requireError(t, diags, "Invalid Merge Strategy")
+	})
+}
+
+func TestExportJSON(t *testing.T) {
+	got, err := exportJSON(`{"b":{"sql":"SELECT 1 WHERE a < 2"},"a":[1,2]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "{\n  \"a\": [\n    1,\n    2\n  ],\n  \"b\": {\n    \"sql\": \"SELECT 1 WHERE a < 2\"\n  }\n}\n"
+	if string(got) != expected {
+		t.Errorf("exportJSON = %q, want %q", got, expected)
+	}
+
+	if _, err := exportJSON(`{`); err == nil {
+		t.Error("expected an error for invalid JSON")
+	}
+}
+
+func TestOverlayResource_ExportPath(t *testing.T) {
+	m := newMockRevosServer(t)
+	h := newMockHarness(t, m)
+	exportPath := t.TempDir() + "/overlay.json"
+
+	readExport := func(step string, expected string) {
+		t.Helper()
+		got, err := os.ReadFile(exportPath)
+		if err != nil {
+			t.Fatalf("%s: %s", step, err)
+		}
+		want, _ := exportJSON(expected)
+		if string(got) != string(want) {
+			t.Errorf("%s: export = %s, want %s", step, got, want)
+		}
+	}
+
+	config := map[string]interface{}{
+		"name":        "exported",
+		"data":        `{"cubes":{"orders":{"sql":"SELECT 1"}}}`,
+		"export_path": exportPath,
+	}
+	null := tftypes.NewValue(h.resourceSchema("revos_overlay").ValueType(), nil)
+	state, diags := h.apply("revos_overlay", null, config)
+	requireNoErrors(t, "create", diags)
+	readExport("create", `{"cubes":{"orders":{"sql":"SELECT 1"}}}`)
+	id := attrString(t, state, "id")
+
+	config["data"] = `{"cubes":{"orders":{"sql":"SELECT 2"}}}`
+	state, diags = h.apply("revos_overlay", state, config)
+	requireNoErrors(t, "update", diags)
+	readExport("update", `{"cubes":{"orders":{"sql":"SELECT 2"}}}`)
+
+	m.setOverlayField(id, "data", map[string]interface{}{
+		"cubes": map[string]interface{}{"users": map[string]interface{}{"sql": "SELECT 3"}},
+	})
+	state, diags = h.read("revos_overlay", state)
+	requireNoErrors(t, "read", diags)
+	readExport("read", `{"cubes":{"users":{"sql":"SELECT 3"}}}`)
+
+	// An unmodified overlay is read with a 304, and still exported
+	if err := os.Remove(exportPath); err != nil {
+		t.Fatal(err)
+	}
+	_, diags = h.read("revos_overlay", state)
+	requireNoErrors(t, "read unmodified", diags)
+	readExport("read unmodified", `{"cubes":{"users":{"sql":"SELECT 3"}}}`)
+
+	t.Run("unwritable", func(t *testing.T) {
+		config := map[string]interface{}{
+			"name":        "unwritable",
+			"data":        `{}`,
+			"export_path": t.TempDir() + "/missing/overlay.json",
+		}
+		_, diags := h.apply("revos_overlay", null, config)
+		requireNoErrors(t, "create", diags)
+		for _, d := range diags {
+			if d.Severity == tfprotov6.DiagnosticSeverityWarning && d.Summary == "Overlay Export Failed" {
+				return
+			}
+		}
+		t.Fatalf("expected an Overlay Export Failed warning, got:\n%s", formatDiags(diags))
+	})
+}
+
+func TestCubeNamesValue(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		expected []string
+	}{
+		{
+			name:     "one cube",
+			data:     `{"cubes":[{"name":"orders","sql":"SELECT 1"}]}`,
+			expected: []string{"orders"},
+		},
+		{
+			name:     "many cubes in definition order",
+			data:     `{"cubes":[{"name":"users"},{"name":"orders"},{"name":"line_items"}],"views":[{"name":"sales"}]}`,
+			expected: []string{"users", "orders", "line_items"},
+		},
+		{
+			name:     "cubes keyed by name",
+			data:     `{"cubes":{"users":{"sql":"SELECT 2"},"orders":{"sql":"SELECT 1"}}}`,
+			expected: []string{"orders", "users"},
+		},
+		{
+			name:     "no cubes",
+			data:     `{"views":[{"name":"sales"}]}`,
+			expected: []string{},
+		},
+		{
+			name:     "empty cubes",
+			data:     `{"cubes":[]}`,
+			expected: []string{},
+		},
+		{
+			name:     "not an object",
+			data:     `[1,2]`,
+			expected: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value := cubeNamesValue(types.StringValue(tt.data))
+			if value.IsNull() {
+				t.Fatal("cube_names is null")
+			}
+			got := stringElements(value)
+			if got == nil {
+				got = []string{}
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("cube_names = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+
+	if !cubeNamesValue(types.StringValue(`{`)).IsNull() {
+		t.Error("expected null cube_names for invalid JSON")
+	}
+	if !cubeNamesValue(types.StringUnknown()).IsNull() {
+		t.Error("expected null cube_names for unknown data")
+	}
+}
+
+func TestOverlayResource_CubeNames(t *testing.T) {
+	m := newMockRevosServer(t)
+	h := newMockHarness(t, m)
+
+	null := tftypes.NewValue(h.resourceSchema("revos_overlay").ValueType(), nil)
+	config := map[string]interface{}{
+		"name":      "named",
+		"data":      `{"cubes":[{"name":"${cube}"}]}`,
+		"data_vars": map[string]interface{}{"cube": "orders"},
+	}
+
+	planned, diags := h.plan("revos_overlay", null, config)
+	requireNoErrors(t, "plan", diags)
+	if got := attrList(t, planned, "cube_names"); !reflect.DeepEqual(got, []string{"orders"}) {
+		t.Errorf("planned cube_names = %v, want [orders]", got)
+	}
+
+	state, diags := h.apply("revos_overlay", null, config)
+	requireNoErrors(t, "create", diags)
+	if got := attrList(t, state, "cube_names"); !reflect.DeepEqual(got, []string{"orders"}) {
+		t.Errorf("cube_names after create = %v, want [orders]", got)
+	}
+
+	config["data"] = `{"cubes":[{"name":"${cube}"},{"name":"users"}]}`
+	state, diags = h.apply("revos_overlay", state, config)
+	requireNoErrors(t, "update", diags)
+	if got := attrList(t, state, "cube_names"); !reflect.DeepEqual(got, []string{"orders", "users"}) {
+		t.Errorf("cube_names after update = %v, want [orders users]", got)
+	}
+
+	// A cube removed outside Terraform is reflected on refresh
+	m.setOverlayField(attrString(t, state, "id"), "data", map[string]interface{}{
+		"cubes": []interface{}{map[string]interface{}{"name": "users"}},
+	})
+	state, diags = h.read("revos_overlay", state)
+	requireNoErrors(t, "refresh", diags)
+	if got := attrList(t, state, "cube_names"); !reflect.DeepEqual(got, []string{"users"}) {
+		t.Errorf("cube_names after refresh = %v, want [users]", got)
+	}
+
+	imported, diags := h.importState("revos_overlay", attrString(t, state, "id"))
+	requireNoErrors(t, "import", diags)
+	if got := attrList(t, imported, "cube_names"); !reflect.DeepEqual(got, []string{"users"}) {
+		t.Errorf("cube_names after import = %v, want [users]", got)
+	}
+
+	config["data"] = `{"views":[]}`
+	state, diags = h.apply("revos_overlay", state, config)
+	requireNoErrors(t, "update without cubes", diags)
+	if got := attrList(t, state, "cube_names"); len(got) != 0 {
+		t.Errorf("cube_names without cubes = %v, want none", got)
+	}
+}
@@ -0,0 +1,81 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/revosai/terraform-provider-revos/internal/client"
+)
+
+// sweepPrefix starts the names of the overlays acceptance tests create, so
+// the sweeper can delete those left behind by failed runs
+const sweepPrefix = "tf-acc-"
+
+// TestMain runs the sweepers with -sweep, e.g.
+// go test ./internal/provider -v -sweep=all, and the tests otherwise.
+func TestMain(m *testing.M) {
+	resource.TestMain(m)
+}
+
+func init() {
+	resource.AddTestSweepers("revos_overlay", &resource.Sweeper{
+		Name: "revos_overlay",
+		// The API has no regions, so the region is ignored
+		F: func(region string) error {
+			apiURL, token := os.Getenv("REVOSAI_API_URL"), os.Getenv("REVOSAI_TOKEN")
+			if apiURL == "" || token == "" {
+				return fmt.Errorf("REVOSAI_API_URL and REVOSAI_TOKEN must be set to sweep")
+			}
+			c := client.NewClient(apiURL, token)
+			return sweepOverlays(context.Background(), c, sweepPrefix)
+		},
+	})
+}
+
+// sweepOverlays deletes the overlays whose name starts with prefix, including
+// those archived by deletion_mode = "archive"
+func sweepOverlays(ctx context.Context, c *client.Client, prefix string) error {
+	overlays, err := c.ListAllOverlays(ctx)
+	if err != nil {
+		return fmt.Errorf("listing overlays to sweep: %w", err)
+	}
+
+	var ids []string
+	for _, overlay := range overlays {
+		if strings.HasPrefix(overlay.Name, prefix) {
+			ids = append(ids, overlay.ID)
+		}
+	}
+	return c.DeleteOverlays(ctx, ids)
+}
+
+func TestSweepOverlays(t *testing.T) {
+	m := newMockRevosServer(t)
+	h := newMockHarness(t, m)
+
+	null := tftypes.NewValue(h.resourceSchema("revos_overlay").ValueType(), nil)
+	for _, name := range []string{sweepPrefix + "1", "keep", sweepPrefix + "2", sweepPrefix + "archived"} {
+		_, diags := h.apply("revos_overlay", null, map[string]interface{}{
+			"name": name,
+			"data": `{"cubes":[]}`,
+		})
+		requireNoErrors(t, "create "+name, diags)
+	}
+	// Left behind by a run that destroyed it with deletion_mode = "archive"
+	m.setOverlayField("ov-4", "archived", true)
+
+	if err := sweepOverlays(context.Background(), client.NewClient(m.URL, "test-token"), sweepPrefix); err != nil {
+		t.Fatalf("sweep: %s", err)
+	}
+	if got := m.overlayCount(); got != 1 {
+		t.Errorf("%d overlays remain, want only the one without the prefix", got)
+	}
+	if m.overlayField("ov-2", "name") != "keep" {
+		t.Error("the overlay without the prefix was deleted")
+	}
+}